@@ -0,0 +1,299 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlertRule fires when a module logs more than Threshold entries of Severity,
+// or of ERROR and above for an ERROR rule, within one Window-aligned
+// interval, or within the whole analysis when no window is given.
+type AlertRule struct {
+	Name      string       `json:"name"`
+	Module    string       `json:"module"`
+	Severity  string       `json:"severity"`
+	Threshold int64        `json:"threshold"`
+	Window    string       `json:"window"`
+	Output    string       `json:"output"`
+	Routes    []AlertRoute `json:"routes"`
+	window    time.Duration
+}
+
+// AlertRoute sends a rule's alerts to Output while its schedule matches the
+// time of sending; the first matching route wins over the rule's own Output.
+type AlertRoute struct {
+	Days     string `json:"days"`
+	Hours    string `json:"hours"`
+	Output   string `json:"output"`
+	schedule timeSchedule
+}
+
+// AlertSuppression silences alerts about entries logged during a one-off
+// Start/End maintenance window or a recurring Days/Hours schedule.
+type AlertSuppression struct {
+	Reason   string    `json:"reason"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Days     string    `json:"days"`
+	Hours    string    `json:"hours"`
+	schedule timeSchedule
+}
+
+type alertConfig struct {
+	Timezone     string             `json:"timezone"`
+	Rules        []AlertRule        `json:"rules"`
+	Suppressions []AlertSuppression `json:"suppressions"`
+	location     *time.Location
+	// handledAlerts remembers alerts already sent or suppressed when the same
+	// rules are evaluated repeatedly, as in follow mode. Nil disables it.
+	handledAlerts map[handledAlert]bool
+}
+
+type handledAlert struct {
+	rule        string
+	windowStart time.Time
+}
+
+type alert struct {
+	Rule        string    `json:"rule"`
+	Module      string    `json:"module"`
+	Severity    string    `json:"severity"`
+	WindowStart time.Time `json:"windowStart"`
+	Count       int64     `json:"count"`
+	Threshold   int64     `json:"threshold"`
+}
+
+// loadAlertRules accepts either a bare array of rules or an object that adds
+// a timezone and suppression windows around them.
+func loadAlertRules(rulesPath string) (config alertConfig, err error) {
+	data, err := os.ReadFile(rulesPath)
+	if err != nil {
+		return
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &config.Rules)
+	} else {
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return
+	}
+	config.location = time.Local
+	if config.Timezone != "" {
+		if config.location, err = time.LoadLocation(config.Timezone); err != nil {
+			return
+		}
+	}
+	for index := range config.Suppressions {
+		suppression := &config.Suppressions[index]
+		if suppression.schedule, err = parseTimeSchedule(suppression.Days, suppression.Hours); err != nil {
+			return config, errors.New("suppression " + strconv.Itoa(index+1) + ": " + err.Error())
+		}
+	}
+	for index := range config.Rules {
+		rule := &config.Rules[index]
+		if rule.Module == "" {
+			return config, errors.New("alert rule " + strconv.Itoa(index+1) + " needs a module")
+		}
+		if rule.Severity == "" {
+			rule.Severity = "ERROR"
+		}
+		rule.Severity = normalizeSeverity(rule.Severity)
+		if rule.Name == "" {
+			rule.Name = rule.Module + "-" + strings.ToLower(rule.Severity)
+		}
+		if rule.Window != "" {
+			if rule.window, err = time.ParseDuration(rule.Window); err != nil {
+				return config, errors.New("alert rule " + rule.Name + ": " + err.Error())
+			}
+		}
+		if rule.Output == "" {
+			rule.Output = "stderr"
+		}
+		for routeIndex := range rule.Routes {
+			route := &rule.Routes[routeIndex]
+			if route.schedule, err = parseTimeSchedule(route.Days, route.Hours); err != nil {
+				return config, errors.New("alert rule " + rule.Name + ": " + err.Error())
+			}
+		}
+	}
+	return
+}
+
+func (config alertConfig) isSuppressed(firedAlert alert, now time.Time) bool {
+	moment := firedAlert.WindowStart
+	if moment.IsZero() {
+		moment = now
+	}
+	for _, suppression := range config.Suppressions {
+		if !suppression.Start.IsZero() || !suppression.End.IsZero() {
+			if !moment.Before(suppression.Start) && moment.Before(suppression.End) {
+				return true
+			}
+			continue
+		}
+		if suppression.schedule.matches(moment.In(config.location)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (config alertConfig) getOutput(rule AlertRule, now time.Time) string {
+	for _, route := range rule.Routes {
+		if route.schedule.matches(now.In(config.location)) {
+			return route.Output
+		}
+	}
+	return rule.Output
+}
+
+// getAlertFiles returns the files the rules and their routes append alerts
+// to, which -encrypt-key can't encrypt.
+func (config alertConfig) getAlertFiles() (alertFiles []string) {
+	for _, rule := range config.Rules {
+		outputs := []string{rule.Output}
+		for _, route := range rule.Routes {
+			outputs = append(outputs, route.Output)
+		}
+		for _, output := range outputs {
+			if isAlertFile(output) {
+				alertFiles = append(alertFiles, output)
+			}
+		}
+	}
+	return
+}
+
+func isAlertFile(output string) bool {
+	return output != "stderr" && output != "stdout" && !strings.HasPrefix(output, "http://") && !strings.HasPrefix(output, "https://")
+}
+
+// matchesSeverity counts CRITICAL and FATAL entries for an ERROR rule, as
+// error counts do everywhere else, and only the rule's severity otherwise.
+func (rule AlertRule) matchesSeverity(severity string) bool {
+	if rule.Severity == "ERROR" {
+		return isErrorSeverity(severity)
+	}
+	return severity == rule.Severity
+}
+
+func evaluateAlertRule(rule AlertRule, timeBucketCounts map[timeBucketKey]int64) (alerts []alert) {
+	windowCounts := make(map[time.Time]int64)
+	for key, count := range timeBucketCounts {
+		if key.module != rule.Module || !rule.matchesSeverity(key.severity) {
+			continue
+		}
+		var windowStart time.Time
+		if rule.window > 0 {
+			windowStart = key.start.Truncate(rule.window)
+		}
+		windowCounts[windowStart] += count
+	}
+	for windowStart, count := range windowCounts {
+		if count > rule.Threshold {
+			alerts = append(alerts, alert{
+				Rule:        rule.Name,
+				Module:      rule.Module,
+				Severity:    rule.Severity,
+				WindowStart: windowStart,
+				Count:       count,
+				Threshold:   rule.Threshold,
+			})
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].WindowStart.Before(alerts[j].WindowStart)
+	})
+	return
+}
+
+func formatAlert(firedAlert alert) string {
+	scope := "in the analyzed logs"
+	if !firedAlert.WindowStart.IsZero() {
+		scope = "in the window starting " + firedAlert.WindowStart.Format(layout)
+	}
+	return "ALERT " + firedAlert.Rule + ": " + firedAlert.Module + " logged " + strconv.FormatInt(firedAlert.Count, 10) + " " +
+		firedAlert.Severity + " entries " + scope + " (threshold " + strconv.FormatInt(firedAlert.Threshold, 10) + ")"
+}
+
+// httpClient sends every request the tool makes, giving up on a service
+// that doesn't answer rather than hanging the run.
+var httpClient = &http.Client{Timeout: time.Minute}
+
+func sendAlerts(output string, alerts []alert) error {
+	switch {
+	case output == "stderr":
+		for _, firedAlert := range alerts {
+			fmt.Fprintln(os.Stderr, formatAlert(firedAlert))
+		}
+		return nil
+	case output == "stdout":
+		for _, firedAlert := range alerts {
+			fmt.Println(formatAlert(firedAlert))
+		}
+		return nil
+	case strings.HasPrefix(output, "http://") || strings.HasPrefix(output, "https://"):
+		payload, err := json.Marshal(alerts)
+		if err != nil {
+			return err
+		}
+		response, err := httpClient.Post(output, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode >= 300 {
+			return errors.New("alert webhook returned " + response.Status)
+		}
+		return nil
+	default:
+		alertFile, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer alertFile.Close()
+		for _, firedAlert := range alerts {
+			if _, err := fmt.Fprintln(alertFile, formatAlert(firedAlert)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func evaluateAlertRules(config alertConfig, timeBucketCounts map[timeBucketKey]int64, now time.Time) (firedAlerts int, suppressedAlerts int, err error) {
+	for _, rule := range config.Rules {
+		var alerts []alert
+		for _, firedAlert := range evaluateAlertRule(rule, timeBucketCounts) {
+			if config.handledAlerts != nil {
+				key := handledAlert{rule: firedAlert.Rule, windowStart: firedAlert.WindowStart}
+				if config.handledAlerts[key] {
+					continue
+				}
+				config.handledAlerts[key] = true
+			}
+			if config.isSuppressed(firedAlert, now) {
+				suppressedAlerts += 1
+				continue
+			}
+			alerts = append(alerts, firedAlert)
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+		firedAlerts += len(alerts)
+		if sendErr := sendAlerts(config.getOutput(rule, now), alerts); sendErr != nil {
+			err = errors.Join(err, errors.New("alert rule "+rule.Name+": "+sendErr.Error()))
+		}
+	}
+	return
+}
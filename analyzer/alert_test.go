@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateAlertRules(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:10:00.000", module: "payments", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:20:00.000", module: "batch", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:30:00.000", module: "batch", severity: "ERROR"},
+		{timestamp: "2024-01-01 01:30:00.000", module: "batch", severity: "ERROR"},
+	}
+	timeBucketCounts := getTimeBucketCounts(testLogs)
+
+	alertPath := filepath.Join(t.TempDir(), "alerts.log")
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	rules := `[
+		{"module": "payments", "threshold": 0, "output": "` + alertPath + `"},
+		{"name": "batch-hourly", "module": "batch", "threshold": 1, "window": "1h", "output": "` + alertPath + `"},
+		{"module": "web", "threshold": 0, "output": "` + alertPath + `"}
+	]`
+	if err := os.WriteFile(rulesPath, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	alertRules, err := loadAlertRules(rulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firedAlerts, _, err := evaluateAlertRules(alertRules, timeBucketCounts, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firedAlerts != 2 {
+		t.Errorf("evaluateAlertRules() fired %d alerts, want 2", firedAlerts)
+	}
+
+	data, err := os.ReadFile(alertPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"ALERT payments-error: payments logged 1 ERROR entries in the analyzed logs (threshold 0)",
+		"ALERT batch-hourly: batch logged 2 ERROR entries in the window starting 2024-01-01 00:00:00 (threshold 1)",
+	}
+	if len(lines) != len(want) || lines[0] != want[0] || lines[1] != want[1] {
+		t.Errorf("alert output = %q, want %q", lines, want)
+	}
+}
+
+func TestErrorAlertRuleCountsMoreSevereEntries(t *testing.T) {
+	timeBucketCounts := getTimeBucketCounts([]LogMessage{
+		{timestamp: "2024-01-01 00:10:00.000", module: "pay", severity: "CRITICAL"},
+		{timestamp: "2024-01-01 00:20:00.000", module: "pay", severity: "FATAL"},
+		{timestamp: "2024-01-01 00:30:00.000", module: "pay", severity: "WARNING"},
+	})
+	alerts := evaluateAlertRule(AlertRule{Module: "pay", Severity: "ERROR"}, timeBucketCounts)
+	if len(alerts) != 1 || alerts[0].Count != 2 {
+		t.Errorf("evaluateAlertRule() for ERROR = %+v, want 1 alert counting 2 entries", alerts)
+	}
+	alerts = evaluateAlertRule(AlertRule{Module: "pay", Severity: "CRITICAL"}, timeBucketCounts)
+	if len(alerts) != 1 || alerts[0].Count != 1 {
+		t.Errorf("evaluateAlertRule() for CRITICAL = %+v, want 1 alert counting 1 entry", alerts)
+	}
+}
+
+func TestAlertRoutingAndSuppression(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 02:10:00.000", module: "payments", severity: "ERROR"},
+		{timestamp: "2024-01-01 05:10:00.000", module: "payments", severity: "ERROR"},
+	}
+	directory := t.TempDir()
+	businessPath := filepath.Join(directory, "business.log")
+	onCallPath := filepath.Join(directory, "oncall.log")
+	rulesPath := filepath.Join(directory, "rules.json")
+	rules := `{
+		"timezone": "UTC",
+		"rules": [{
+			"module": "payments", "threshold": 0, "window": "1h", "output": "` + onCallPath + `",
+			"routes": [{"days": "Mon-Fri", "hours": "09:00-17:00", "output": "` + businessPath + `"}]
+		}],
+		"suppressions": [{"reason": "maintenance", "start": "2024-01-01T02:00:00Z", "end": "2024-01-01T03:00:00Z"}]
+	}`
+	if err := os.WriteFile(rulesPath, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	alertRules, err := loadAlertRules(rulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tuesday 10:00 UTC is business hours
+	firedAlerts, suppressedAlerts, err := evaluateAlertRules(alertRules, getTimeBucketCounts(testLogs), time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firedAlerts != 1 || suppressedAlerts != 1 {
+		t.Errorf("evaluateAlertRules() fired %d and suppressed %d, want 1 and 1", firedAlerts, suppressedAlerts)
+	}
+	if _, err := os.Stat(businessPath); err != nil {
+		t.Errorf("Expected business hours alert output: %v", err)
+	}
+
+	// Saturday night goes to on-call
+	if _, _, err := evaluateAlertRules(alertRules, getTimeBucketCounts(testLogs), time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(onCallPath); err != nil {
+		t.Errorf("Expected on-call alert output: %v", err)
+	}
+}
+
+func TestTimeSchedule(t *testing.T) {
+	overnight, err := parseTimeSchedule("Fri", "22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		moment time.Time
+		want   bool
+	}{
+		{moment: time.Date(2024, 1, 5, 23, 0, 0, 0, time.UTC), want: true},
+		{moment: time.Date(2024, 1, 6, 5, 59, 0, 0, time.UTC), want: true},
+		{moment: time.Date(2024, 1, 6, 6, 0, 0, 0, time.UTC), want: false},
+		{moment: time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC), want: false},
+		{moment: time.Date(2024, 1, 5, 5, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		if got := overnight.matches(tt.moment); got != tt.want {
+			t.Errorf("matches(%v) = %v, want %v", tt.moment, got, tt.want)
+		}
+	}
+
+	if _, err := parseTimeSchedule("Funday", ""); err == nil {
+		t.Errorf("parseTimeSchedule() accepted an unknown weekday")
+	}
+}
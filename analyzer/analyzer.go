@@ -0,0 +1,1263 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const layout string = "2006-01-02 15:04:05.999"
+
+var errMissingDelimiter = errors.New("Missing delimiter")
+var errBadTimestamp = errors.New("Bad timestamp")
+var errBadLineNumber = errors.New("Bad line number")
+var errMissingSeverity = errors.New("Empty severity")
+
+type LogMessage struct {
+	timestamp  string
+	severity   string
+	module     string
+	function   string
+	lineNumber int64
+	message    string
+	thread     string
+	pid        string
+	clientIP   string
+	attributes map[string]string
+	// key is what the entry is counted by when a MessageKeyer is set
+	key string
+}
+
+type LogAnalysis struct {
+	logPath                      string
+	bytesRead                    int64
+	numEntries                   int
+	logSeverityFrequency         LogSeverityFrequency
+	topFiveLogMessages           []string
+	topFiveLogMessageFrequencies []int64
+	topFiveLogMessageTrends      []messageTrend
+	startTime                    time.Time
+	endTime                      time.Time
+	moduleMonthCounts            map[moduleMonth]moduleMonthCount
+	timeBucketCounts             map[timeBucketKey]int64
+	messageBucketCounts          map[messageBucketKey]int64
+	dataQualityWarnings          []string
+	severityModel                *severityModel
+	unlabeledMessages            []LogMessage
+	inferredSeverityFrequency    LogSeverityFrequency
+	// facilitySeverityFrequencies is nil unless some entry has a syslog facility
+	facilitySeverityFrequencies map[string]LogSeverityFrequency
+	// ageTierCounts is nil unless -age-tiers is given
+	ageTierCounts    map[string]ageTierCount
+	parseErrorCounts map[fileParseError]int64
+	messageTraceIDs  map[string][]string
+	versionCounts    map[string]versionCount
+	threadCounts     map[string]threadCount
+	moduleStats      map[string]moduleStats
+	clientIPCounts   map[string]clientIPCount
+	clientIPBaseline []netip.Prefix
+	// topMessageBaseline is nil unless -baseline is given
+	topMessageBaseline *topMessageBaseline
+	histogramBucket    time.Duration
+	spikeOptions       spikeOptions
+	queryProfile       queryProfile
+	gcProfile          gcProfile
+	httpProfile        httpProfile
+	lines              int64
+	malformedLines     int64
+	badLines           []badLine
+	messageFrequencies map[string]int64
+	// messageExamples holds raw messages for each key with -message-key,
+	// picked from messageExampleCounts once the counts are complete
+	messageExamples       map[string][]string
+	messageExampleCounts  messageExampleCounts
+	processRestarts       []processRestart
+	bootSessions          []bootSession
+	windowNames           []string
+	windowStats           map[string]windowStats
+	messageConcentrations []messageConcentration
+	severityTransitions   map[severityTransition]int64
+	interrupted           bool
+	// fileErrors holds a *FileError for every file that couldn't be read
+	// in full
+	fileErrors []error
+}
+
+type reportOptions struct {
+	exact               bool
+	traceURLTemplate    string
+	histogramBySeverity bool
+	// location shows report times in -timezone instead of UTC
+	location *time.Location
+}
+
+// formatTime writes a time in timeLayout, in UTC or, with -timezone, in
+// that zone followed by its abbreviation.
+func (options reportOptions) formatTime(timestamp time.Time, timeLayout string) string {
+	if options.location == nil {
+		return timestamp.UTC().Format(timeLayout)
+	}
+	return timestamp.In(options.location).Format(timeLayout + " MST")
+}
+
+type analysisOptions struct {
+	inferSeverity       bool
+	internMessages      bool
+	versionPattern      *regexp.Regexp
+	threadPattern       *regexp.Regexp
+	groupByModule       bool
+	groupByClientIP     bool
+	clientIPGrouping    clientIPGrouping
+	clientIPBaseline    []netip.Prefix
+	topMessageBaseline  *topMessageBaseline
+	histogramBucket     time.Duration
+	spikeOptions        spikeOptions
+	queryProfile        bool
+	gcProfile           bool
+	httpProfile         bool
+	detectRestarts      bool
+	bootSessions        bool
+	severityTransitions bool
+	workers             int
+	windows             []namedWindow
+	parser              Parser
+	// newParser, when set, replaces parser with a fresh one for every file
+	newParser func() Parser
+	// badLineSamples is how many malformed lines to keep per file
+	badLineSamples int
+	minSeverity    string
+	since          time.Time
+	until          time.Time
+	// grep keeps only entries whose message matches, and excludeGrep drops
+	// those whose message does
+	grep        *regexp.Regexp
+	excludeGrep *regexp.Regexp
+	// fuzzyGrep, when set, keeps only entries whose message has its words,
+	// give or take a typo
+	fuzzyGrep *fuzzyQuery
+	// lineFilter, when set, drops raw lines before they are parsed
+	lineFilter *lineFilter
+	// lineIndex, when set, is the index of the file being read, which lets
+	// lineFilter skip whole blocks of it
+	lineIndex *lineIndex
+	// entryStartPattern, when set, matches the lines that start an entry;
+	// other lines that fail to parse continue the entry before them
+	entryStartPattern *regexp.Regexp
+	// fileAnalyzed, when set, is called with each file's analysis as it
+	// finishes, from one goroutine
+	fileAnalyzed func(logAnalysis LogAnalysis)
+	// chunkEntries is how many entries of a file are counted at a time, or
+	// defaultChunkEntries when 0
+	chunkEntries int
+	// rangeBytes is the least size of the ranges a large file is split into,
+	// or defaultRangeBytes when 0
+	rangeBytes int64
+	// fileRange, when set, is the part of the file being read, which is one
+	// of several read concurrently
+	fileRange *fileRange
+	// messageKeyer, when set, computes the keys messages are counted by in
+	// place of their text
+	messageKeyer MessageKeyer
+	// ageTiers, when set, counts every file's entries under its age tier
+	ageTiers *ageTierOptions
+	// aggregatesOnly replaces message text with message IDs in every file's
+	// analysis
+	aggregatesOnly bool
+	// interner, when set, is shared by the files of one analysis; each read
+	// has its own otherwise
+	interner *stringInterner
+}
+
+type parseStats struct {
+	bytesRead int64
+	lines     int64
+	// rawLines counts blank lines too, which lines leaves out
+	rawLines       int64
+	malformedLines int64
+	filteredLines  int64
+	interrupted    bool
+	// readErr, when set, is why the file stopped being read before its end
+	readErr          *FileError
+	parseErrorCounts map[string]int64
+	badLines         []badLine
+}
+
+type fileParseError struct {
+	logPath string
+	reason  string
+}
+
+// severityLevels are the known severities from least to most severe.
+// Entries with other, custom severities are counted under their own name.
+var severityLevels = []string{"TRACE", "DEBUG", "INFO", "NOTICE", "WARNING", "ERROR", "CRITICAL", "FATAL"}
+
+// reportedSeverities are listed in reports even when no entry has them.
+var reportedSeverities = []string{"DEBUG", "INFO", "WARNING", "ERROR"}
+
+// severityRanks orders the known severities for -min-severity. Severities
+// outside this list are never filtered out.
+var severityRanks = map[string]int{
+	"TRACE":    0,
+	"DEBUG":    1,
+	"INFO":     2,
+	"NOTICE":   3,
+	"WARNING":  4,
+	"ERROR":    5,
+	"CRITICAL": 6,
+	"FATAL":    7,
+}
+
+// isErrorSeverity reports whether entries of severity count as errors for
+// error rates, budgets and thresholds: ERROR and everything above it.
+func isErrorSeverity(severity string) bool {
+	rank, ok := severityRanks[severity]
+	return ok && rank >= severityRanks["ERROR"]
+}
+
+func isBelowSeverity(severity string, minSeverity string) bool {
+	rank, ok := severityRanks[severity]
+	return ok && minSeverity != "" && rank < severityRanks[minSeverity]
+}
+
+// LogSeverityFrequency counts entries per severity. The zero value is an
+// empty count that addSeverityCount allocates on first use.
+type LogSeverityFrequency map[string]int64
+
+// errors counts the entries of every error severity.
+func (logSeverityFrequency LogSeverityFrequency) errors() (errors int64) {
+	for severity, count := range logSeverityFrequency {
+		if isErrorSeverity(severity) {
+			errors += count
+		}
+	}
+	return
+}
+
+func mergeLogSeverityFrequency(into *LogSeverityFrequency, from LogSeverityFrequency) {
+	for severity, count := range from {
+		addSeverityCount(into, severity, count)
+	}
+}
+
+// getSortedSeverities lists reportedSeverities and every other severity
+// with entries: known levels from least to most severe, then custom ones
+// alphabetically.
+func getSortedSeverities(logSeverityFrequency LogSeverityFrequency) (severities []string) {
+	reported := make(map[string]bool, len(reportedSeverities))
+	for _, severity := range reportedSeverities {
+		reported[severity] = true
+	}
+	for _, severity := range severityLevels {
+		if reported[severity] || logSeverityFrequency[severity] > 0 {
+			severities = append(severities, severity)
+		}
+	}
+	var custom []string
+	for severity, count := range logSeverityFrequency {
+		if _, ok := severityRanks[severity]; !ok && count > 0 {
+			custom = append(custom, severity)
+		}
+	}
+	sort.Strings(custom)
+	return append(severities, custom...)
+}
+
+// formatSeverityCounts lists severities with their counts, like
+// "DEBUG 3, INFO 12".
+func formatSeverityCounts(logSeverityFrequency LogSeverityFrequency, severities []string, options reportOptions) string {
+	counts := make([]string, 0, len(severities))
+	for _, severity := range severities {
+		counts = append(counts, severity+" "+humanizeCount(logSeverityFrequency[severity], options))
+	}
+	return strings.Join(counts, ", ")
+}
+
+func parseLogMessage(logRow string) (LogMessage, error) {
+	return parseNativeMessage(logRow, timeFormat{})
+}
+
+// newNativeParser reads the native format with timestamps in format, for
+// -time-format and -timezone.
+func newNativeParser(format timeFormat) Parser {
+	return func(logRow string) (LogMessage, error) {
+		return parseNativeMessage(logRow, format)
+	}
+}
+
+// parseNativeMessage reads an optional fourth | section of logfmt fields, as
+// convert writes for the fields the layout has no place for.
+func parseNativeMessage(logRow string, format timeFormat) (LogMessage, error) {
+	var logMessage LogMessage
+	leftParts := strings.SplitN(logRow, "|", 4)
+	if len(leftParts) < 3 {
+		return logMessage, errMissingDelimiter
+	}
+	if len(leftParts) == 4 && !parseNativeExtraFields(leftParts[3], &logMessage) {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp = strings.TrimSpace(leftParts[0])
+	logMessage.severity = strings.TrimSpace(leftParts[1])
+	rightParts := strings.Split(leftParts[2], ":")
+	if len(rightParts) < 3 {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.module = strings.TrimSpace(rightParts[0])
+	logMessage.function = strings.TrimSpace(rightParts[1])
+	messageRaw := strings.Split(rightParts[2], "-")
+	if len(messageRaw) < 2 {
+		return logMessage, errMissingDelimiter
+	}
+	lineNumRaw := strings.Split(rightParts[2], "-")[0]
+	message := strings.Split(rightParts[2], "-")[1]
+	lineNum, err := strconv.ParseInt(strings.TrimSpace(lineNumRaw), 0, 16)
+	logMessage.lineNumber = lineNum
+	logMessage.message = strings.TrimSpace(message)
+	if err != nil {
+		return logMessage, errBadLineNumber
+	}
+	if !format.isSet() {
+		if _, err := time.Parse(layout, logMessage.timestamp); err != nil {
+			return logMessage, errBadTimestamp
+		}
+	} else if logMessage.timestamp, err = format.normalize(logMessage.timestamp, layout); err != nil {
+		return logMessage, err
+	}
+	if logMessage.severity == "" {
+		return logMessage, errMissingSeverity
+	}
+	return logMessage, nil
+}
+
+// parseNativeExtraFields reads the logfmt fields after the fourth | into
+// logMessage. It reports false when the section isn't all fields, like a
+// message with a | in it.
+func parseNativeExtraFields(section string, logMessage *LogMessage) bool {
+	if strings.TrimSpace(logfmtPairPattern.ReplaceAllString(section, "")) != "" {
+		return false
+	}
+	for key, value := range parseLogfmt(section) {
+		switch key {
+		case "thread":
+			logMessage.thread = value
+		case "pid":
+			logMessage.pid = value
+		case "client_ip":
+			logMessage.clientIP = value
+		default:
+			if attribute, ok := strings.CutPrefix(key, attributeKeyPrefix); ok {
+				if logMessage.attributes == nil {
+					logMessage.attributes = make(map[string]string)
+				}
+				logMessage.attributes[attribute] = value
+			}
+		}
+	}
+	return true
+}
+
+// defaultChunkEntries bounds how many parsed entries of a file are held in
+// memory at once.
+const defaultChunkEntries = 64 * 1024
+
+// stdinPath is the input name that reads standard input instead of a file.
+const stdinPath string = "-"
+
+// parseLogReader stops early when ctx is done, returning what was parsed so
+// far with stats.interrupted set.
+func parseLogReader(ctx context.Context, logReader io.Reader, logPath string, options analysisOptions) (logMessages []LogMessage, unlabeledMessages []LogMessage, stats parseStats) {
+	stats = streamLogReader(ctx, logReader, logPath, options, 0, func(chunk []LogMessage, unlabeledChunk []LogMessage) {
+		logMessages, unlabeledMessages = chunk, unlabeledChunk
+	})
+	return
+}
+
+// streamLogReader parses logReader like parseLogReader, handing its entries
+// to flush every chunkEntries of them, or all at once when chunkEntries is
+// 0. An entry is only flushed once the next one starts, so -multiline
+// continuation lines never straddle a chunk.
+func streamLogReader(ctx context.Context, logReader io.Reader, logPath string, options analysisOptions, chunkEntries int, flush func(logMessages []LogMessage, unlabeledMessages []LogMessage)) (stats parseStats) {
+	var logMessages, unlabeledMessages []LogMessage
+	var entries int64
+	reader := bufio.NewReaderSize(logReader, streamBufferSize)
+	head, _ := reader.Peek(sniffLength)
+	// A split file is sniffed once, before it is split
+	if options.fileRange == nil && isBinaryContent(head) {
+		fmt.Fprintln(os.Stderr, "Skipping binary file:", logPath)
+		metrics.counter("files_skipped").Add(1)
+		return
+	}
+	parser := options.parser
+	if options.newParser != nil {
+		parser = options.newParser()
+	}
+	if parser == nil {
+		parser = parseLogMessage
+	}
+	if options.lineFilter != nil {
+		parser = options.lineFilter.filterParser(parser, options.entryStartPattern)
+	}
+	interner := options.interner
+	if interner == nil {
+		interner = newStringInterner(metrics)
+	}
+
+	var skip *blockSkip
+	if options.lineIndex != nil && options.lineFilter != nil && options.entryStartPattern == nil {
+		firstLine, endLine := int64(0), options.lineIndex.lines
+		if options.fileRange != nil {
+			firstLine, endLine = options.fileRange.firstLine, options.fileRange.endLine
+		}
+		skip = newBlockSkip(options.lineIndex, options.lineFilter, firstLine, endLine)
+	}
+
+	parsedLineChan := make(chan parsedLine, 1024)
+	var readErr error
+	go func() {
+		stats.bytesRead, stats.rawLines, readErr = streamLogMessages(ctx, reader, parser, skip, parsedLineChan)
+		close(parsedLineChan)
+	}()
+	// With -multiline, continuation lines belong to the last entry, or are
+	// filtered out with it
+	lastEntry, lastFiltered := -1, false
+	for parsed := range parsedLineChan {
+		if parsed.skippedLines > 0 {
+			stats.lines += parsed.skippedLines
+			stats.filteredLines += parsed.skippedLines
+			lastEntry, lastFiltered = -1, true
+			continue
+		}
+		stats.lines += 1
+		if parsed.err != nil && options.entryStartPattern != nil && (lastEntry >= 0 || lastFiltered) &&
+			!errors.Is(parsed.err, errMissingSeverity) && isContinuationLine(parsed.logRow, options.entryStartPattern) {
+			if lastFiltered {
+				stats.filteredLines += 1
+			} else {
+				appendContinuationLine(&logMessages[lastEntry], parsed.logRow)
+			}
+			continue
+		}
+		lastEntry, lastFiltered = -1, false
+		if errors.Is(parsed.err, errLineFiltered) {
+			stats.filteredLines += 1
+			lastFiltered = true
+			continue
+		}
+		if parsed.err == nil {
+			if isBelowSeverity(parsed.logMessage.severity, options.minSeverity) ||
+				isOutsideTimeRange(parsed.logMessage.timestamp, options.since, options.until) ||
+				isExcludedMessage(parsed.logMessage.message, options.grep, options.excludeGrep) ||
+				(options.fuzzyGrep != nil && !options.fuzzyGrep.matches(parsed.logMessage.message)) {
+				stats.filteredLines += 1
+				lastFiltered = true
+				continue
+			}
+			if chunkEntries > 0 && len(logMessages) >= chunkEntries {
+				flush(logMessages, unlabeledMessages)
+				logMessages, unlabeledMessages = make([]LogMessage, 0, chunkEntries), nil
+			}
+			logMessages = append(logMessages, interner.internLogMessage(parsed.logMessage, options.internMessages))
+			lastEntry = len(logMessages) - 1
+			entries += 1
+			continue
+		}
+		stats.malformedLines += 1
+		if stats.parseErrorCounts == nil {
+			stats.parseErrorCounts = make(map[string]int64)
+		}
+		stats.parseErrorCounts[parsed.err.Error()] += 1
+		if len(stats.badLines) < options.badLineSamples {
+			stats.badLines = append(stats.badLines, badLine{logPath: logPath, lineNumber: parsed.lineNumber, reason: parsed.err.Error(), text: parsed.logRow})
+		}
+		if errors.Is(parsed.err, errMissingSeverity) {
+			unlabeledMessages = append(unlabeledMessages, parsed.logMessage)
+		}
+	}
+	if ctx.Err() != nil && errors.Is(readErr, ctx.Err()) {
+		stats.interrupted = true
+	} else if readErr != nil {
+		stats.readErr = newFileError(logPath, stats.rawLines+1, readErr)
+		metrics.counter("files_failed").Add(1)
+	}
+	flush(logMessages, unlabeledMessages)
+	metrics.counter("lines_parsed").Add(entries)
+	metrics.counter("lines_malformed").Add(stats.malformedLines)
+	metrics.counter("lines_filtered").Add(stats.filteredLines)
+	return
+}
+
+func getNumEntries(logMessages []LogMessage) (numLogMessages int) {
+	numLogMessages = len(logMessages)
+	return
+}
+
+func countSeverity(logSeverityFrequency *LogSeverityFrequency, severity string) {
+	addSeverityCount(logSeverityFrequency, severity, 1)
+}
+
+func addSeverityCount(logSeverityFrequency *LogSeverityFrequency, severity string, count int64) {
+	// Entries without a severity are left to -infer-severity
+	if severity == "" {
+		return
+	}
+	if *logSeverityFrequency == nil {
+		*logSeverityFrequency = make(LogSeverityFrequency)
+	}
+	(*logSeverityFrequency)[severity] += count
+}
+
+func getLogSeverityFrequency(logMessages []LogMessage) (logSeverityFrequency LogSeverityFrequency) {
+	for _, logMessage := range logMessages {
+		countSeverity(&logSeverityFrequency, logMessage.severity)
+	}
+	return
+}
+
+func getMessageFrequencies(logMessages []LogMessage) (messageFrequencies map[string]int64) {
+	messageFrequencies = make(map[string]int64, len(logMessages))
+	for _, logMessage := range logMessages {
+		messageFrequencies[logMessage.messageKey()] += 1
+	}
+	return
+}
+
+func rankLogMessages(rankedLogMessages map[string]int64) (messages []string) {
+	messages = make([]string, 0, len(rankedLogMessages))
+	for message := range rankedLogMessages {
+		messages = append(messages, message)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if rankedLogMessages[messages[i]] != rankedLogMessages[messages[j]] {
+			return rankedLogMessages[messages[i]] > rankedLogMessages[messages[j]]
+		}
+		// Break ties alphabetically so output does not depend on map order
+		return messages[i] < messages[j]
+	})
+	return
+}
+
+func getTopFiveLogMessages(logMessages []LogMessage) (topFiveLogMessages []string, topFiveLogMessageFrequencies []int64) {
+	return getTopFiveMessageFrequencies(getMessageFrequencies(logMessages))
+}
+
+func getTopFiveMessageFrequencies(rankedLogMessages map[string]int64) (topFiveLogMessages []string, topFiveLogMessageFrequencies []int64) {
+	topFiveLogMessages = make([]string, 5)
+	topFiveLogMessageFrequencies = make([]int64, 5)
+	messages := rankLogMessages(rankedLogMessages)
+	if len(messages) == 0 {
+		return
+	}
+	var maxMessages int
+	if len(messages) >= 5 {
+		maxMessages = 5
+	} else {
+		maxMessages = len(messages)
+	}
+	for index := 0; index < maxMessages; index++ {
+		topFiveLogMessages[index] = messages[index]
+		topFiveLogMessageFrequencies[index] = rankedLogMessages[messages[index]]
+	}
+	return
+}
+
+// getTimeRange returns the earliest and latest timestamps, wherever they
+// are in the file. Entries whose timestamp doesn't parse are skipped, and
+// counted in the returned error.
+func getTimeRange(logMessages []LogMessage) (startTime time.Time, endTime time.Time, err error) {
+	var unparsed int
+	for _, logMessage := range logMessages {
+		timestamp, parseErr := time.Parse(layout, logMessage.timestamp)
+		if parseErr != nil {
+			unparsed += 1
+			continue
+		}
+		if startTime.IsZero() || timestamp.Before(startTime) {
+			startTime = timestamp
+		}
+		if endTime.IsZero() || timestamp.After(endTime) {
+			endTime = timestamp
+		}
+	}
+	if unparsed > 0 {
+		err = newUnparsedTimestampsError(unparsed, len(logMessages))
+	}
+	return
+}
+
+func newUnparsedTimestampsError(unparsed int, entries int) error {
+	return errors.New(strconv.Itoa(unparsed) + " of " + strconv.Itoa(entries) + " entries have no parseable timestamp")
+}
+
+// newLogAnalysis computes the analysis of one file's parsed entries. Follow
+// mode also uses it for each batch of newly appended lines.
+func newLogAnalysis(logPath string, logMessages []LogMessage, unlabeledMessages []LogMessage, stats parseStats, options analysisOptions) (logAnalysis LogAnalysis) {
+	quality := qualityTracker{now: time.Now()}
+	quality.observe(logMessages)
+	logAnalysis = aggregateLogMessages(logMessages, unlabeledMessages, options, newFileCursor())
+	addWholeFileAnalyses(&logAnalysis, logPath, logMessages, options)
+	finishLogAnalysis(&logAnalysis, logPath, stats, &quality, options)
+	return
+}
+
+// fileCursor carries what counting a file a chunk at a time needs from one
+// chunk to the next.
+type fileCursor struct {
+	currentVersion string
+}
+
+func newFileCursor() *fileCursor {
+	return &fileCursor{currentVersion: unknownVersion}
+}
+
+// aggregateLogMessages counts a run of entries into an analysis that merges
+// with the analyses of the runs before and after it, so a file can be
+// counted a chunk at a time. Everything that needs the whole file is left to
+// finishLogAnalysis.
+func aggregateLogMessages(logMessages []LogMessage, unlabeledMessages []LogMessage, options analysisOptions, cursor *fileCursor) (logAnalysis LogAnalysis) {
+	if options.inferSeverity {
+		logAnalysis.severityModel = trainSeverityModel(logMessages)
+		logAnalysis.unlabeledMessages = unlabeledMessages
+	}
+	if options.messageKeyer != nil {
+		keyLogMessages(logMessages, options.messageKeyer)
+		logAnalysis.messageExampleCounts = getMessageExampleCounts(logMessages)
+	} else {
+		keyTraceMessages(logMessages)
+	}
+	logAnalysis.numEntries = getNumEntries(logMessages)
+	logAnalysis.logSeverityFrequency = getLogSeverityFrequency(logMessages)
+	logAnalysis.facilitySeverityFrequencies = getFacilitySeverityFrequencies(logMessages)
+	logAnalysis.messageFrequencies = getMessageFrequencies(logMessages)
+	logAnalysis.startTime, logAnalysis.endTime, _ = getTimeRange(logMessages)
+	logAnalysis.moduleMonthCounts = getModuleMonthCounts(logMessages)
+	logAnalysis.timeBucketCounts = getTimeBucketCounts(logMessages)
+	logAnalysis.messageBucketCounts = getMessageBucketCounts(logMessages)
+	logAnalysis.messageTraceIDs = getMessageTraceIDs(logMessages)
+	if options.versionPattern != nil {
+		logAnalysis.versionCounts, cursor.currentVersion = continueVersionCounts(logMessages, options.versionPattern, cursor.currentVersion)
+	}
+	if options.threadPattern != nil {
+		logAnalysis.threadCounts = getThreadCounts(logMessages, options.threadPattern)
+	}
+	if options.groupByModule {
+		logAnalysis.moduleStats = getModuleStats(logMessages)
+	}
+	if options.groupByClientIP {
+		logAnalysis.clientIPCounts = getClientIPCounts(logMessages, options.clientIPGrouping)
+	}
+	if options.queryProfile {
+		logAnalysis.queryProfile = getQueryProfile(logMessages)
+	}
+	if options.httpProfile {
+		logAnalysis.httpProfile = getHTTPProfile(logMessages)
+	}
+	if len(options.windows) > 0 {
+		logAnalysis.windowStats = getWindowStats(logMessages, options.windows)
+	}
+	return
+}
+
+// needsWholeFile reports whether options follow entries across the whole
+// file, which can't be counted a chunk at a time.
+func (options analysisOptions) needsWholeFile() bool {
+	return options.detectRestarts || options.bootSessions || options.severityTransitions || options.gcProfile
+}
+
+func addWholeFileAnalyses(logAnalysis *LogAnalysis, logPath string, logMessages []LogMessage, options analysisOptions) {
+	if options.detectRestarts {
+		logAnalysis.processRestarts = getProcessRestarts(logPath, logMessages)
+	}
+	if options.bootSessions {
+		logAnalysis.bootSessions = getBootSessions(logPath, logMessages)
+	}
+	if options.severityTransitions {
+		logAnalysis.severityTransitions = getSeverityTransitions(logMessages)
+	}
+	if options.gcProfile {
+		logAnalysis.gcProfile = getGCProfile(logMessages)
+	}
+}
+
+// finishLogAnalysis completes a file's analysis once all its entries are
+// counted: top messages, options, data quality and parse stats.
+func finishLogAnalysis(logAnalysis *LogAnalysis, logPath string, stats parseStats, quality *qualityTracker, options analysisOptions) {
+	logAnalysis.bytesRead = stats.bytesRead
+	logAnalysis.topFiveLogMessages, logAnalysis.topFiveLogMessageFrequencies = getTopFiveMessageFrequencies(logAnalysis.messageFrequencies)
+	logAnalysis.messageExamples = getMessageExamples(logAnalysis.messageExampleCounts)
+	addNoFacilitySeverities(logAnalysis.facilitySeverityFrequencies, logAnalysis.logSeverityFrequency)
+	if options.ageTiers != nil {
+		logAnalysis.ageTierCounts = getAgeTierCounts(logAnalysis.logSeverityFrequency, logAnalysis.endTime, *options.ageTiers)
+	}
+	if options.groupByClientIP {
+		logAnalysis.clientIPBaseline = options.clientIPBaseline
+	}
+	logAnalysis.histogramBucket = options.histogramBucket
+	logAnalysis.topMessageBaseline = options.topMessageBaseline
+	logAnalysis.spikeOptions = options.spikeOptions
+	for _, window := range options.windows {
+		logAnalysis.windowNames = append(logAnalysis.windowNames, window.name)
+	}
+	logAnalysis.dataQualityWarnings = quality.warnings(logPath, stats)
+	if quality.unparsed > 0 {
+		logAnalysis.dataQualityWarnings = append(logAnalysis.dataQualityWarnings, logPath+": "+newUnparsedTimestampsError(quality.unparsed, quality.entries).Error())
+	}
+	logAnalysis.parseErrorCounts = make(map[fileParseError]int64)
+	for reason, count := range stats.parseErrorCounts {
+		logAnalysis.parseErrorCounts[fileParseError{logPath: logPath, reason: reason}] = count
+	}
+	logAnalysis.lines = stats.lines
+	logAnalysis.malformedLines = stats.malformedLines
+	logAnalysis.badLines = stats.badLines
+	logAnalysis.logPath = logPath
+	logAnalysis.interrupted = stats.interrupted
+	if stats.readErr != nil {
+		logAnalysis.fileErrors = []error{stats.readErr}
+	}
+	if options.aggregatesOnly {
+		scrubLogAnalysis(logAnalysis)
+	}
+}
+
+// analyzeLogReader streams a file's entries into its analysis a chunk at a
+// time, so memory is bounded by the chunk and the distinct values counted
+// rather than by the size of the file. Restarts, boot sessions, severity
+// transitions and GC profiles follow entries across the whole file, so any
+// of them makes it read the file whole, as parseLogReader does.
+func analyzeLogReader(ctx context.Context, logReader io.Reader, logPath string, options analysisOptions) (logAnalysis LogAnalysis) {
+	logAnalysis, logMessages, stats, quality := countLogReader(ctx, logReader, logPath, options)
+	addWholeFileAnalyses(&logAnalysis, logPath, logMessages, options)
+	finishLogAnalysis(&logAnalysis, logPath, stats, &quality, options)
+	return
+}
+
+// countLogReader streams a file's entries into an analysis that is not
+// finished yet, so the ranges of a split file can be merged first. It only
+// returns the entries themselves when options need the whole file.
+func countLogReader(ctx context.Context, logReader io.Reader, logPath string, options analysisOptions) (logAnalysis LogAnalysis, logMessages []LogMessage, stats parseStats, quality qualityTracker) {
+	chunkEntries := options.chunkEntries
+	if chunkEntries <= 0 {
+		chunkEntries = defaultChunkEntries
+	}
+	if options.needsWholeFile() {
+		chunkEntries = 0
+	}
+	logAnalysis = newMergedLogAnalysis()
+	quality = qualityTracker{now: time.Now()}
+	cursor := newFileCursor()
+	stats = streamLogReader(ctx, logReader, logPath, options, chunkEntries, func(chunk []LogMessage, unlabeledMessages []LogMessage) {
+		quality.observe(chunk)
+		if options.needsWholeFile() {
+			logMessages = chunk
+		}
+		mergeLogAnalysis(&logAnalysis, aggregateLogMessages(chunk, unlabeledMessages, options, cursor))
+	})
+	return
+}
+
+func analyzeLogFile(ctx context.Context, logPath string, options analysisOptions, logAnalysisChan chan LogAnalysis, waitGroup *sync.WaitGroup) {
+	var logAnalysis LogAnalysis
+	if logPath == stdinPath {
+		logAnalysis = analyzeLogReader(ctx, os.Stdin, logPath, options)
+	} else if logFile, err := os.Open(logPath); err != nil {
+		metrics.counter("files_failed").Add(1)
+		logAnalysis = newLogAnalysis(logPath, nil, nil, parseStats{readErr: newFileError(logPath, 0, err)}, options)
+	} else if isFileOutsideTimeRange(logFile, options) {
+		metrics.counter("files_pruned").Add(1)
+		logAnalysis = newLogAnalysis(logPath, nil, nil, parseStats{}, options)
+		logFile.Close()
+	} else {
+		if options.lineFilter != nil {
+			if index, err := loadLineIndex(logPath); err == nil {
+				options.lineIndex = index
+				defer index.close()
+			}
+		}
+		var split bool
+		if logAnalysis, split = analyzeFileRanges(ctx, logFile, logPath, options); !split {
+			logAnalysis = analyzeLogReader(ctx, logFile, logPath, options)
+		}
+		logFile.Close()
+	}
+	metrics.counter("files_in_flight").Add(-1)
+	metrics.counter("files_analyzed").Add(1)
+	logAnalysisChan <- logAnalysis
+	waitGroup.Done()
+}
+
+func getSortedFileParseErrors(parseErrorCounts map[fileParseError]int64) (keys []fileParseError) {
+	for key := range parseErrorCounts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].logPath != keys[j].logPath {
+			return keys[i].logPath < keys[j].logPath
+		}
+		return keys[i].reason < keys[j].reason
+	})
+	return
+}
+
+func printLogAnalysis(logAnalysis LogAnalysis, options reportOptions) {
+	fmt.Println("Number of Entries: " + humanizeCount(int64(logAnalysis.numEntries), options))
+	fmt.Println("Data Processed: " + humanizeBytes(logAnalysis.bytesRead, options))
+	fmt.Println("Log Severity Frequency: ")
+	for _, severity := range getSortedSeverities(logAnalysis.logSeverityFrequency) {
+		fmt.Println("   " + severity + ": " + humanizeCount(logAnalysis.logSeverityFrequency[severity], options))
+	}
+	if len(logAnalysis.unlabeledMessages) > 0 {
+		fmt.Println("Inferred Severity Frequency (" + humanizeCount(int64(len(logAnalysis.unlabeledMessages)), options) + " unlabeled entries): ")
+		for _, severity := range getSortedSeverities(logAnalysis.inferredSeverityFrequency) {
+			fmt.Println("   " + severity + ": " + humanizeCount(logAnalysis.inferredSeverityFrequency[severity], options))
+		}
+	}
+	if len(logAnalysis.facilitySeverityFrequencies) > 0 {
+		fmt.Println("Severity by Facility: ")
+		for _, line := range formatFacilitySeverityFrequencies(logAnalysis.facilitySeverityFrequencies, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if len(logAnalysis.ageTierCounts) > 0 {
+		fmt.Println("Severity by File Age: ")
+		for _, line := range formatAgeTierCounts(logAnalysis.ageTierCounts, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	fmt.Println("Top Five Log Messages: ")
+	var maxMessages int
+	if len(logAnalysis.topFiveLogMessages) >= 5 {
+		maxMessages = 5
+	} else {
+		maxMessages = len(logAnalysis.topFiveLogMessages)
+	}
+	for index := 0; index < maxMessages; index++ {
+		frequency := logAnalysis.topFiveLogMessageFrequencies[index]
+		var share float64
+		if logAnalysis.numEntries > 0 {
+			share = float64(frequency) / float64(logAnalysis.numEntries)
+		}
+		line := "   " + strconv.Itoa(index+1) + ". " + formatFrequencyBar(frequency, logAnalysis.topFiveLogMessageFrequencies[0]) +
+			" " + humanizeCount(frequency, options) + " (" + formatPercent(share) + ") " + logAnalysis.topFiveLogMessages[index]
+		if index < len(logAnalysis.topFiveLogMessageTrends) {
+			line += " (" + formatMessageTrend(logAnalysis.topFiveLogMessageTrends[index]) + ")"
+		}
+		fmt.Println(line)
+		for _, example := range logAnalysis.messageExamples[logAnalysis.topFiveLogMessages[index]] {
+			fmt.Println("      e.g. " + example)
+		}
+		for _, traceID := range logAnalysis.messageTraceIDs[logAnalysis.topFiveLogMessages[index]] {
+			fmt.Println("      trace: " + formatTraceLink(traceID, options.traceURLTemplate))
+		}
+	}
+	if logAnalysis.topMessageBaseline != nil {
+		if stability, ok := getTopMessageStability(logAnalysis.topFiveLogMessages, logAnalysis.topMessageBaseline.messages); ok {
+			fmt.Println("Top Message Stability: ")
+			for _, line := range formatTopMessageStability(stability, *logAnalysis.topMessageBaseline) {
+				fmt.Println("   " + line)
+			}
+		}
+	}
+	if logAnalysis.startTime.IsZero() {
+		fmt.Println("Start Date/Time: unknown, no entry has a parseable timestamp")
+	} else {
+		fmt.Println("Start Date/Time: " + options.formatTime(logAnalysis.startTime, layout))
+		fmt.Println("End Date/Time: " + options.formatTime(logAnalysis.endTime, layout))
+		fmt.Println("Time Span: " + humanizeDuration(logAnalysis.endTime.Sub(logAnalysis.startTime), options))
+	}
+	if logAnalysis.histogramBucket > 0 {
+		fmt.Println("Volume per " + humanizeDuration(logAnalysis.histogramBucket, reportOptions{}) + ": ")
+		for _, line := range formatHistogram(getHistogramBuckets(logAnalysis.timeBucketCounts, logAnalysis.histogramBucket), options.histogramBySeverity, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if logAnalysis.spikeOptions.interval > 0 {
+		spikes := getErrorSpikes(logAnalysis.timeBucketCounts, logAnalysis.messageBucketCounts, logAnalysis.spikeOptions)
+		fmt.Println("Error Spikes (" + humanizeCount(int64(len(spikes)), options) + "): ")
+		for _, spike := range spikes {
+			for _, line := range formatErrorSpike(spike, options) {
+				fmt.Println("   " + line)
+			}
+		}
+	}
+	if logAnalysis.queryProfile.queries != nil {
+		fmt.Println("Slow Queries (" + humanizeCount(int64(len(logAnalysis.queryProfile.queries)), options) + " distinct): ")
+		for _, line := range formatQueryProfile(logAnalysis.queryProfile, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if logAnalysis.gcProfile.pauseTypes != nil {
+		fmt.Println("Garbage Collection: ")
+		for _, line := range formatGCProfile(logAnalysis.gcProfile, logAnalysis.endTime.Sub(logAnalysis.startTime), getFullGCInterval(logAnalysis), options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if logAnalysis.httpProfile.statusCounts != nil {
+		fmt.Println("HTTP Requests: ")
+		for _, line := range formatHTTPProfile(logAnalysis.httpProfile, logAnalysis.endTime.Sub(logAnalysis.startTime), getRequestRateInterval(logAnalysis), options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if len(logAnalysis.versionCounts) > 0 {
+		fmt.Println("Error Rate by Version: ")
+		for _, line := range formatVersionComparison(logAnalysis.versionCounts, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if len(logAnalysis.threadCounts) > 0 {
+		fmt.Println("Busiest Threads: ")
+		for _, line := range formatThreadCounts(logAnalysis.threadCounts, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if len(logAnalysis.moduleStats) > 0 {
+		fmt.Println("Modules: ")
+		for _, line := range formatModuleStats(logAnalysis.moduleStats, options) {
+			fmt.Println("   " + line)
+		}
+	}
+	if len(logAnalysis.clientIPCounts) > 0 {
+		fmt.Println("Top Client IPs: ")
+		for _, clientIP := range getTopClientIPs(logAnalysis.clientIPCounts, false) {
+			fmt.Println("   " + formatClientIPCount(clientIP, logAnalysis.clientIPCounts[clientIP], options))
+		}
+		if topErrorClientIPs := getTopClientIPs(logAnalysis.clientIPCounts, true); len(topErrorClientIPs) > 0 {
+			fmt.Println("Top Error-Producing Client IPs: ")
+			for _, clientIP := range topErrorClientIPs {
+				fmt.Println("   " + formatClientIPCount(clientIP, logAnalysis.clientIPCounts[clientIP], options))
+			}
+		}
+		if logAnalysis.clientIPBaseline != nil {
+			newClientIPs := getNewClientIPs(logAnalysis.clientIPCounts, logAnalysis.clientIPBaseline)
+			fmt.Println("Client IPs Not in Baseline (" + humanizeCount(int64(len(newClientIPs)), options) + "): ")
+			for index, clientIP := range newClientIPs {
+				if index == maxReportedClientIPs {
+					break
+				}
+				fmt.Println("   " + formatClientIPCount(clientIP, logAnalysis.clientIPCounts[clientIP], options))
+			}
+		}
+	}
+	if len(logAnalysis.processRestarts) > 0 {
+		fmt.Println("Process Restarts (" + humanizeCount(int64(len(logAnalysis.processRestarts)), options) + "): ")
+		for _, restart := range logAnalysis.processRestarts {
+			fmt.Println("   " + formatProcessRestart(restart, options))
+		}
+	}
+	if len(logAnalysis.bootSessions) > 0 {
+		fmt.Println("Boot Sessions (" + humanizeCount(int64(len(logAnalysis.bootSessions)), options) + "): ")
+		for _, session := range logAnalysis.bootSessions {
+			for _, line := range formatBootSession(session, options) {
+				fmt.Println("   " + line)
+			}
+		}
+	}
+	if len(logAnalysis.messageConcentrations) > 0 {
+		fmt.Println("Host-Local Messages: ")
+		for _, concentration := range logAnalysis.messageConcentrations {
+			fmt.Println("   " + formatMessageConcentration(concentration, options))
+		}
+	}
+	if len(logAnalysis.severityTransitions) > 0 {
+		fmt.Println("Severity Transitions by Module: ")
+		writeSeverityTransitions(os.Stdout, logAnalysis.severityTransitions, options)
+	}
+	if len(logAnalysis.windowNames) > 0 {
+		fmt.Println("Window Comparison: ")
+		writeWindowComparison(os.Stdout, logAnalysis.windowNames, logAnalysis.windowStats, options)
+	}
+	if len(logAnalysis.parseErrorCounts) > 0 {
+		fmt.Println("Malformed Lines (" + humanizeCount(logAnalysis.malformedLines, options) + " of " + humanizeCount(logAnalysis.lines, options) +
+			", " + formatPercent(getMalformedRatio(logAnalysis)) + "): ")
+		for _, key := range getSortedFileParseErrors(logAnalysis.parseErrorCounts) {
+			fmt.Println("   " + key.logPath + ": " + key.reason + ": " + humanizeCount(logAnalysis.parseErrorCounts[key], options))
+		}
+	}
+	if len(logAnalysis.badLines) > 0 {
+		fmt.Println("Bad Lines: ")
+		for _, line := range logAnalysis.badLines {
+			fmt.Println("   " + formatBadLine(line))
+		}
+	}
+	if len(logAnalysis.dataQualityWarnings) > 0 {
+		fmt.Println("Data Quality Warnings: ")
+		for _, warning := range logAnalysis.dataQualityWarnings {
+			fmt.Println("   " + warning)
+		}
+	}
+	if logAnalysis.interrupted {
+		fmt.Println("Note: the analysis was interrupted, so these results are partial.")
+	}
+}
+
+// analyzeTopFiveLogMessages ranks messages over every file's full frequency
+// map, so a message that is common everywhere but never in any single file's
+// top five still makes the merged ranking.
+func analyzeTopFiveLogMessages(logAnalyses []LogAnalysis) (topFiveLogMessages []string, topFiveLogMessageFrequencies []int64) {
+	rankedLogMessages := make(map[string]int64)
+	for _, logAnalysis := range logAnalyses {
+		for message, frequency := range logAnalysis.messageFrequencies {
+			rankedLogMessages[message] += frequency
+		}
+	}
+
+	messages := rankLogMessages(rankedLogMessages)
+	var maxMessages int
+	if len(messages) >= 5 {
+		maxMessages = 5
+	} else {
+		maxMessages = len(messages)
+	}
+	for index := 0; index < maxMessages; index++ {
+		topFiveLogMessages = append(topFiveLogMessages, messages[index])
+		topFiveLogMessageFrequencies = append(topFiveLogMessageFrequencies, rankedLogMessages[messages[index]])
+	}
+	return
+}
+
+// newMergedLogAnalysis returns an empty analysis for mergeLogAnalysis to add
+// to.
+func newMergedLogAnalysis() (finalLogAnalysis LogAnalysis) {
+	finalLogAnalysis.moduleMonthCounts = make(map[moduleMonth]moduleMonthCount)
+	finalLogAnalysis.timeBucketCounts = make(map[timeBucketKey]int64)
+	finalLogAnalysis.messageBucketCounts = make(map[messageBucketKey]int64)
+	finalLogAnalysis.parseErrorCounts = make(map[fileParseError]int64)
+	finalLogAnalysis.messageTraceIDs = make(map[string][]string)
+	finalLogAnalysis.versionCounts = make(map[string]versionCount)
+	finalLogAnalysis.threadCounts = make(map[string]threadCount)
+	finalLogAnalysis.moduleStats = make(map[string]moduleStats)
+	finalLogAnalysis.clientIPCounts = make(map[string]clientIPCount)
+	finalLogAnalysis.messageFrequencies = make(map[string]int64)
+	finalLogAnalysis.messageExampleCounts = make(messageExampleCounts)
+	finalLogAnalysis.windowStats = make(map[string]windowStats)
+	finalLogAnalysis.severityTransitions = make(map[severityTransition]int64)
+	return
+}
+
+// mergeLogAnalysis adds the counts of a file, or of a chunk of one, to
+// finalLogAnalysis.
+func mergeLogAnalysis(finalLogAnalysis *LogAnalysis, logAnalysis LogAnalysis) {
+	finalLogAnalysis.numEntries += logAnalysis.numEntries
+	finalLogAnalysis.bytesRead += logAnalysis.bytesRead
+	if logAnalysis.severityModel != nil {
+		if finalLogAnalysis.severityModel == nil {
+			finalLogAnalysis.severityModel = newSeverityModel()
+		}
+		finalLogAnalysis.severityModel.merge(logAnalysis.severityModel)
+		finalLogAnalysis.unlabeledMessages = append(finalLogAnalysis.unlabeledMessages, logAnalysis.unlabeledMessages...)
+	}
+	finalLogAnalysis.dataQualityWarnings = append(finalLogAnalysis.dataQualityWarnings, logAnalysis.dataQualityWarnings...)
+	mergeLogSeverityFrequency(&finalLogAnalysis.logSeverityFrequency, logAnalysis.logSeverityFrequency)
+	mergeModuleMonthCounts(finalLogAnalysis.moduleMonthCounts, logAnalysis.moduleMonthCounts)
+	mergeTimeBucketCounts(finalLogAnalysis.timeBucketCounts, logAnalysis.timeBucketCounts)
+	for key, count := range logAnalysis.messageBucketCounts {
+		finalLogAnalysis.messageBucketCounts[key] += count
+	}
+	for key, count := range logAnalysis.parseErrorCounts {
+		finalLogAnalysis.parseErrorCounts[key] += count
+	}
+	finalLogAnalysis.lines += logAnalysis.lines
+	finalLogAnalysis.malformedLines += logAnalysis.malformedLines
+	finalLogAnalysis.badLines = append(finalLogAnalysis.badLines, logAnalysis.badLines...)
+	mergeMessageTraceIDs(finalLogAnalysis.messageTraceIDs, logAnalysis.messageTraceIDs)
+	for version, count := range logAnalysis.versionCounts {
+		merged := finalLogAnalysis.versionCounts[version]
+		merged.entries += count.entries
+		merged.errors += count.errors
+		finalLogAnalysis.versionCounts[version] = merged
+	}
+	mergeThreadCounts(finalLogAnalysis.threadCounts, logAnalysis.threadCounts)
+	mergeModuleStats(finalLogAnalysis.moduleStats, logAnalysis.moduleStats)
+	if logAnalysis.facilitySeverityFrequencies != nil {
+		if finalLogAnalysis.facilitySeverityFrequencies == nil {
+			finalLogAnalysis.facilitySeverityFrequencies = make(map[string]LogSeverityFrequency)
+		}
+		mergeFacilitySeverityFrequencies(finalLogAnalysis.facilitySeverityFrequencies, logAnalysis.facilitySeverityFrequencies)
+	}
+	if logAnalysis.ageTierCounts != nil {
+		if finalLogAnalysis.ageTierCounts == nil {
+			finalLogAnalysis.ageTierCounts = make(map[string]ageTierCount)
+		}
+		mergeAgeTierCounts(finalLogAnalysis.ageTierCounts, logAnalysis.ageTierCounts)
+	}
+	mergeClientIPCounts(finalLogAnalysis.clientIPCounts, logAnalysis.clientIPCounts)
+	if finalLogAnalysis.clientIPBaseline == nil {
+		finalLogAnalysis.clientIPBaseline = logAnalysis.clientIPBaseline
+	}
+	if finalLogAnalysis.topMessageBaseline == nil {
+		finalLogAnalysis.topMessageBaseline = logAnalysis.topMessageBaseline
+	}
+	if finalLogAnalysis.histogramBucket == 0 {
+		finalLogAnalysis.histogramBucket = logAnalysis.histogramBucket
+	}
+	if finalLogAnalysis.spikeOptions.interval == 0 {
+		finalLogAnalysis.spikeOptions = logAnalysis.spikeOptions
+	}
+	mergeQueryProfiles(&finalLogAnalysis.queryProfile, logAnalysis.queryProfile)
+	mergeGCProfiles(&finalLogAnalysis.gcProfile, logAnalysis.gcProfile)
+	mergeHTTPProfiles(&finalLogAnalysis.httpProfile, logAnalysis.httpProfile)
+	for message, frequency := range logAnalysis.messageFrequencies {
+		finalLogAnalysis.messageFrequencies[message] += frequency
+	}
+	finalLogAnalysis.messageExampleCounts.merge(logAnalysis.messageExampleCounts)
+	finalLogAnalysis.processRestarts = append(finalLogAnalysis.processRestarts, logAnalysis.processRestarts...)
+	finalLogAnalysis.bootSessions = append(finalLogAnalysis.bootSessions, logAnalysis.bootSessions...)
+	if finalLogAnalysis.windowNames == nil {
+		finalLogAnalysis.windowNames = logAnalysis.windowNames
+	}
+	mergeWindowStats(finalLogAnalysis.windowStats, logAnalysis.windowStats)
+	finalLogAnalysis.interrupted = finalLogAnalysis.interrupted || logAnalysis.interrupted
+	finalLogAnalysis.fileErrors = append(finalLogAnalysis.fileErrors, logAnalysis.fileErrors...)
+	for transition, count := range logAnalysis.severityTransitions {
+		finalLogAnalysis.severityTransitions[transition] += count
+	}
+	// Files without entries or timestamps, such as those read with a
+	// -pattern that has none, have no time range to contribute
+	if logAnalysis.numEntries == 0 || logAnalysis.startTime.IsZero() {
+		return
+	}
+	if finalLogAnalysis.startTime.IsZero() || finalLogAnalysis.startTime.After(logAnalysis.startTime) {
+		finalLogAnalysis.startTime = logAnalysis.startTime
+	}
+	if finalLogAnalysis.endTime.IsZero() || finalLogAnalysis.endTime.Before(logAnalysis.endTime) {
+		finalLogAnalysis.endTime = logAnalysis.endTime
+	}
+}
+
+func analyzelogAnalyses(logAnalyses []LogAnalysis) (finalLogAnalysis LogAnalysis) {
+	finalLogAnalysis = newMergedLogAnalysis()
+
+	topFiveLogMessages, topFiveLogMessageFrequencies := analyzeTopFiveLogMessages(logAnalyses)
+	var maxMessages int
+	if len(topFiveLogMessages) >= 5 {
+		maxMessages = 5
+	} else {
+		maxMessages = len(topFiveLogMessages)
+	}
+	for index := 0; index < maxMessages; index++ {
+		finalLogAnalysis.topFiveLogMessages = append(finalLogAnalysis.topFiveLogMessages, topFiveLogMessages[index])
+		finalLogAnalysis.topFiveLogMessageFrequencies = append(finalLogAnalysis.topFiveLogMessageFrequencies, topFiveLogMessageFrequencies[index])
+	}
+
+	for _, logAnalysis := range logAnalyses {
+		mergeLogAnalysis(&finalLogAnalysis, logAnalysis)
+	}
+
+	sortProcessRestarts(finalLogAnalysis.processRestarts)
+	sortFileErrors(finalLogAnalysis.fileErrors)
+	sortBootSessions(finalLogAnalysis.bootSessions)
+	finalLogAnalysis.messageConcentrations = getMessageConcentrations(logAnalyses)
+	finalLogAnalysis.messageExamples = getMessageExamples(finalLogAnalysis.messageExampleCounts)
+	addNoFacilitySeverities(finalLogAnalysis.facilitySeverityFrequencies, finalLogAnalysis.logSeverityFrequency)
+
+	if finalLogAnalysis.severityModel != nil {
+		finalLogAnalysis.inferredSeverityFrequency = getLogSeverityFrequency(
+			inferSeverities(finalLogAnalysis.severityModel, finalLogAnalysis.unlabeledMessages))
+	}
+
+	for _, message := range finalLogAnalysis.topFiveLogMessages {
+		trend := getMessageTrend(message, finalLogAnalysis.messageBucketCounts, finalLogAnalysis.startTime, finalLogAnalysis.endTime)
+		finalLogAnalysis.topFiveLogMessageTrends = append(finalLogAnalysis.topFiveLogMessageTrends, trend)
+	}
+
+	return
+}
+
+// collectLogAnalyses analyzes the files on options.workers goroutines, or
+// GOMAXPROCS of them by default, so open files and memory stay bounded no
+// matter how many paths are given. Once ctx is done no more files are started
+// and the ones in progress stop early, so the result may be partial; the
+// affected analyses are marked interrupted and files never started are left
+// out.
+func collectLogAnalyses(ctx context.Context, logPaths []string, options analysisOptions) (logAnalyses []LogAnalysis) {
+	var logAnalysisChan chan LogAnalysis = make(chan LogAnalysis)
+	logPathChan := make(chan string)
+	workers := options.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if options.interner == nil {
+		options.interner = newStringInterner(metrics)
+	}
+	if workers > len(logPaths) {
+		workers = len(logPaths)
+	}
+	// Each call has its own, so concurrent analyses don't wait on each other
+	var waitGroup sync.WaitGroup
+	var workersDone sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		workersDone.Add(1)
+		go func() {
+			defer workersDone.Done()
+			for logPath := range logPathChan {
+				waitGroup.Add(1)
+				metrics.counter("files_in_flight").Add(1)
+				analyzeLogFile(ctx, logPath, options, logAnalysisChan, &waitGroup)
+			}
+		}()
+	}
+	go func() {
+		defer close(logPathChan)
+		for _, logPath := range logPaths {
+			select {
+			case logPathChan <- logPath:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workersDone.Wait()
+		close(logAnalysisChan)
+	}()
+
+	for logAnalysis := range logAnalysisChan {
+		logAnalyses = append(logAnalyses, logAnalysis)
+		if options.fileAnalyzed != nil {
+			options.fileAnalyzed(logAnalysis)
+		}
+	}
+	waitGroup.Wait()
+
+	// Files finish in any order; merge them in input order so the result is reproducible
+	inputOrder := make(map[string]int, len(logPaths))
+	for index, logPath := range logPaths {
+		inputOrder[logPath] = index
+	}
+	sort.SliceStable(logAnalyses, func(i, j int) bool {
+		return inputOrder[logAnalyses[i].logPath] < inputOrder[logAnalyses[j].logPath]
+	})
+
+	return
+}
+
+// getFileLogAnalyses finalizes each file's analysis on its own, in the order
+// the files were given, so it can be reported next to the merged one.
+func getFileLogAnalyses(logPaths []string, logAnalyses []LogAnalysis) (fileLogAnalyses []LogAnalysis) {
+	inputOrder := make(map[string]int, len(logPaths))
+	for index, logPath := range logPaths {
+		inputOrder[logPath] = index
+	}
+	for _, logAnalysis := range logAnalyses {
+		fileLogAnalysis := analyzelogAnalyses([]LogAnalysis{logAnalysis})
+		fileLogAnalysis.logPath = logAnalysis.logPath
+		fileLogAnalyses = append(fileLogAnalyses, fileLogAnalysis)
+	}
+	sort.SliceStable(fileLogAnalyses, func(i, j int) bool {
+		return inputOrder[fileLogAnalyses[i].logPath] < inputOrder[fileLogAnalyses[j].logPath]
+	})
+	return
+}
+
+func analyzeLogFiles(ctx context.Context, logPaths []string, options analysisOptions) (logAnalysis LogAnalysis) {
+	logAnalysis = analyzelogAnalyses(collectLogAnalyses(ctx, logPaths, options))
+	return
+}
@@ -0,0 +1,401 @@
+// analyzer_test.go
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLogMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    LogMessage
+		wantErr bool
+	}{
+		{
+			name:  "valid log message",
+			input: "2024-01-02 15:04:05.999 | INFO | app.module: function: 123 - User logged in",
+			want: LogMessage{
+				timestamp:  "2024-01-02 15:04:05.999",
+				severity:   "INFO",
+				module:     "app.module",
+				function:   "function",
+				lineNumber: 123,
+				message:    "User logged in",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "empty message",
+			input:   "",
+			want:    LogMessage{},
+			wantErr: true,
+		},
+		{
+			name:    "malformed message - missing severity",
+			input:   "2024-01-02 15:04:05.999 | | app.module: function: 123 - User logged in",
+			want:    LogMessage{},
+			wantErr: true,
+		},
+		{
+			name:    "malformed message - missing line number",
+			input:   "2024-01-02 15:04:05.999 | INFO | app.module: function: - User logged in",
+			want:    LogMessage{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLogMessage(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLogMessage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLogMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLogMessageErrorReasons(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{input: "no delimiters here", want: errMissingDelimiter},
+		{input: "2024-01-02 15:04:05.999 | INFO | app.module function 123 - msg", want: errMissingDelimiter},
+		{input: "2024-01-02 15:04:05.999 | INFO | app.module: function: 123 - msg | not fields", want: errMissingDelimiter},
+		{input: "yesterday | INFO | app.module: function: 123 - msg", want: errBadTimestamp},
+		{input: "2024-01-02 15:04:05.999 | INFO | app.module: function: abc - msg", want: errBadLineNumber},
+		{input: "2024-01-02 15:04:05.999 |  | app.module: function: 123 - msg", want: errMissingSeverity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.Error(), func(t *testing.T) {
+			_, err := parseLogMessage(tt.input)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("parseLogMessage(%q) error = %v, want %v", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLogSeverityFrequency(t *testing.T) {
+	testLogs := []LogMessage{
+		{severity: "DEBUG"},
+		{severity: "INFO"},
+		{severity: "INFO"},
+		{severity: "WARNING"},
+		{severity: "ERROR"},
+		{severity: "ERROR"},
+		{severity: "INVALID"},
+	}
+
+	want := LogSeverityFrequency{
+		"DEBUG":   1,
+		"INFO":    2,
+		"WARNING": 1,
+		"ERROR":   2,
+		// Custom levels are counted under their own name
+		"INVALID": 1,
+	}
+
+	got := getLogSeverityFrequency(testLogs)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getLogSeverityFrequency() = %v, want %v", got, want)
+	}
+}
+
+func TestGetSortedSeverities(t *testing.T) {
+	logSeverityFrequency := LogSeverityFrequency{"FATAL": 1, "AUDIT": 4, "TRACE": 2, "ERROR": 3, "CRITICAL": 0}
+	want := []string{"TRACE", "DEBUG", "INFO", "WARNING", "ERROR", "FATAL", "AUDIT"}
+	if got := getSortedSeverities(logSeverityFrequency); !reflect.DeepEqual(got, want) {
+		t.Errorf("getSortedSeverities() = %v, want %v", got, want)
+	}
+	if got := logSeverityFrequency.errors(); got != 4 {
+		t.Errorf("Expected ERROR and FATAL to count as 4 errors, got %d", got)
+	}
+	if !isBelowSeverity("NOTICE", "WARNING") || isBelowSeverity("CRITICAL", "ERROR") || isBelowSeverity("AUDIT", "FATAL") {
+		t.Error("Expected NOTICE below WARNING, CRITICAL above ERROR and custom levels never filtered")
+	}
+}
+
+func TestGetTopFiveLogMessages(t *testing.T) {
+	testLogs := []LogMessage{
+		{message: "Error 1"},
+		{message: "Error 1"},
+		{message: "Error 2"},
+		{message: "Error 3"},
+		{message: "Error 3"},
+		{message: "Error 3"},
+		{message: "Error 4"},
+		{message: "Error 5"},
+		{message: "Error 6"},
+	}
+
+	wantMessages := []string{"Error 3", "Error 1", "Error 2", "Error 4", "Error 5"}
+	wantFrequencies := []int64{3, 2, 1, 1, 1}
+
+	gotMessages, gotFrequencies := getTopFiveLogMessages(testLogs)
+
+	if !reflect.DeepEqual(gotMessages, wantMessages) {
+		t.Errorf("getTopFiveLogMessages() messages = %v, want %v", gotMessages, wantMessages)
+	}
+	if !reflect.DeepEqual(gotFrequencies, wantFrequencies) {
+		t.Errorf("getTopFiveLogMessages() frequencies = %v, want %v", gotFrequencies, wantFrequencies)
+	}
+}
+
+func TestGetTimeRange(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:00:00.000"},
+		{timestamp: "2024-01-01 12:00:00.000"},
+		{timestamp: "2024-01-02 00:00:00.000"},
+	}
+
+	expectedStart, _ := time.Parse(layout, "2024-01-01 00:00:00.000")
+	expectedEnd, _ := time.Parse(layout, "2024-01-02 00:00:00.000")
+
+	gotStart, gotEnd, err := getTimeRange(testLogs)
+
+	if err != nil {
+		t.Errorf("getTimeRange() error = %v", err)
+	}
+	if !gotStart.Equal(expectedStart) {
+		t.Errorf("getTimeRange() start = %v, want %v", gotStart, expectedStart)
+	}
+	if !gotEnd.Equal(expectedEnd) {
+		t.Errorf("getTimeRange() end = %v, want %v", gotEnd, expectedEnd)
+	}
+}
+
+func TestGetTimeRangeUnordered(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 12:00:00.000"},
+		{timestamp: "not a time"},
+		{timestamp: "2024-01-02 00:00:00.000"},
+		{timestamp: "2024-01-01 00:00:00.000"},
+	}
+
+	gotStart, gotEnd, err := getTimeRange(testLogs)
+
+	if err == nil || err.Error() != "1 of 4 entries have no parseable timestamp" {
+		t.Errorf("getTimeRange() error = %v, want 1 unparseable entry", err)
+	}
+	if !gotStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !gotEnd.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("getTimeRange() = %v to %v, want the earliest and latest entries", gotStart, gotEnd)
+	}
+}
+
+// Helper function to create temporary test log file
+func createTestLogFile(t *testing.T, content string) string {
+	tmpfile, err := os.CreateTemp("", "test-log-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpfile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return tmpfile.Name()
+}
+
+func TestAnalyzeLogFile(t *testing.T) {
+	logContent := `2024-01-01 00:00:00.000 | INFO | app.module: function: 123 - User logged in
+2024-01-01 00:01:00.000 | ERROR | app.module: function: 124 - Database connection failed
+2024-01-01 00:02:00.000 | ERROR | app.module: function: 125 - Database connection failed`
+
+	tmpFileName := createTestLogFile(t, logContent)
+	defer os.Remove(tmpFileName)
+
+	logAnalysisChan := make(chan LogAnalysis)
+	var waitGroup sync.WaitGroup
+	waitGroup.Add(1)
+
+	go analyzeLogFile(context.Background(), tmpFileName, analysisOptions{}, logAnalysisChan, &waitGroup)
+
+	logAnalysis := <-logAnalysisChan
+	waitGroup.Wait()
+
+	if logAnalysis.numEntries != 3 {
+		t.Errorf("Expected 3 entries, got %d", logAnalysis.numEntries)
+	}
+
+	if logAnalysis.logSeverityFrequency["INFO"] != 1 || logAnalysis.logSeverityFrequency["ERROR"] != 2 {
+		t.Errorf("Incorrect severity frequencies: got info=%d, error=%d, want info=1, error=2",
+			logAnalysis.logSeverityFrequency["INFO"], logAnalysis.logSeverityFrequency["ERROR"])
+	}
+
+	expectedMessage := "Database connection failed"
+	if logAnalysis.topFiveLogMessages[0] != expectedMessage {
+		t.Errorf("Expected top message to be '%s', got '%s'",
+			expectedMessage, logAnalysis.topFiveLogMessages[0])
+	}
+}
+
+func TestAnalyzeLogFiles(t *testing.T) {
+	log1Content := `2024-01-01 00:00:00.000 | INFO | app.module: function: 123 - User logged in
+2024-01-01 00:01:00.000 | ERROR | app.module: function: 124 - Database error`
+
+	log2Content := `2024-01-01 00:02:00.000 | WARNING | app.module: function: 125 - Low memory
+2024-01-01 00:03:00.000 | ERROR | app.module: function: 126 - Database error`
+
+	tmpFile1 := createTestLogFile(t, log1Content)
+	tmpFile2 := createTestLogFile(t, log2Content)
+	defer os.Remove(tmpFile1)
+	defer os.Remove(tmpFile2)
+
+	logPaths := []string{tmpFile1, tmpFile2}
+	analysis := analyzeLogFiles(context.Background(), logPaths, analysisOptions{})
+
+	// Test basic metrics
+	if analysis.numEntries != 4 {
+		t.Errorf("Expected 4 total entries, got %d", analysis.numEntries)
+	}
+
+	// Test severity frequencies
+	expectedFreq := LogSeverityFrequency{
+		"INFO":    1,
+		"WARNING": 1,
+		"ERROR":   2,
+	}
+	if !reflect.DeepEqual(analysis.logSeverityFrequency, expectedFreq) {
+		t.Errorf("Incorrect severity frequencies: got %+v, want %+v",
+			analysis.logSeverityFrequency, expectedFreq)
+	}
+
+	// Test top message
+	expectedTopMessage := "Database error"
+	if analysis.topFiveLogMessages[0] != expectedTopMessage {
+		t.Errorf("Expected top message to be '%s', got '%s'",
+			expectedTopMessage, analysis.topFiveLogMessages[0])
+	}
+}
+
+func TestAnalyzeTopFiveLogMessagesUsesFullFrequencies(t *testing.T) {
+	// "Slow query" is sixth in each file but first overall
+	var logAnalyses []LogAnalysis
+	for _, prefix := range []string{"a", "b"} {
+		var logMessages []LogMessage
+		for _, suffix := range []string{"1", "2", "3", "4", "5"} {
+			for count := 0; count < 5; count++ {
+				logMessages = append(logMessages, LogMessage{message: "Error " + prefix + suffix})
+			}
+		}
+		for count := 0; count < 4; count++ {
+			logMessages = append(logMessages, LogMessage{message: "Slow query"})
+		}
+		logAnalyses = append(logAnalyses, LogAnalysis{messageFrequencies: getMessageFrequencies(logMessages)})
+	}
+
+	gotMessages, gotFrequencies := analyzeTopFiveLogMessages(logAnalyses)
+	if gotMessages[0] != "Slow query" || gotFrequencies[0] != 8 {
+		t.Errorf("analyzeTopFiveLogMessages() = %v %v, want Slow query first with 8", gotMessages, gotFrequencies)
+	}
+}
+
+func TestCollectLogAnalysesWithWorkerPool(t *testing.T) {
+	var logPaths []string
+	for index := 0; index < 5; index++ {
+		tmpFileName := createTestLogFile(t, "2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error")
+		defer os.Remove(tmpFileName)
+		logPaths = append(logPaths, tmpFileName)
+	}
+
+	for _, workers := range []int{1, 2, 10} {
+		logAnalyses := collectLogAnalyses(context.Background(), logPaths, analysisOptions{workers: workers})
+		if len(logAnalyses) != len(logPaths) {
+			t.Fatalf("Expected %d analyses with %d workers, got %d", len(logPaths), workers, len(logAnalyses))
+		}
+		for index, logAnalysis := range logAnalyses {
+			if logAnalysis.logPath != logPaths[index] || logAnalysis.numEntries != 1 {
+				t.Errorf("Unexpected analysis %d with %d workers: %s with %d entries", index, workers, logAnalysis.logPath, logAnalysis.numEntries)
+			}
+		}
+	}
+}
+
+func TestParseLogReader(t *testing.T) {
+	input := strings.NewReader(`2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error
+not a log line
+2024-01-01 00:01:00.000 | INFO | app.module: function: 2 - User logged in
+`)
+	logMessages, _, stats := parseLogReader(context.Background(), input, stdinPath, analysisOptions{})
+	if len(logMessages) != 2 || stats.malformedLines != 1 {
+		t.Errorf("Expected 2 entries and 1 malformed line from the reader, got %d and %d", len(logMessages), stats.malformedLines)
+	}
+
+	uniqueLogPaths, duplicateLogPaths := dedupeLogPaths([]string{stdinPath, stdinPath})
+	if len(uniqueLogPaths) != 1 || len(duplicateLogPaths) != 1 {
+		t.Errorf("Expected standard input to be read once, got %v and duplicates %v", uniqueLogPaths, duplicateLogPaths)
+	}
+}
+
+func TestParseLogReaderCanceled(t *testing.T) {
+	line := "2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error\n"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	logMessages, _, stats := parseLogReader(ctx, strings.NewReader(strings.Repeat(line, 5000)), stdinPath, analysisOptions{})
+	if !stats.interrupted || len(logMessages) == 5000 {
+		t.Errorf("Expected a canceled read to stop early and be marked interrupted, got %d entries and interrupted %v", len(logMessages), stats.interrupted)
+	}
+}
+
+func TestAnalyzeLogFilesMinSeverity(t *testing.T) {
+	tmpFile1 := createTestLogFile(t, `2024-01-01 00:00:00.000 | DEBUG | app.module: function: 1 - Cache miss
+2024-01-01 00:01:00.000 | WARNING | app.module: function: 2 - Low memory
+2024-01-01 00:02:00.000 | INFO | app.module: function: 3 - User logged in`)
+	tmpFile2 := createTestLogFile(t, `2024-01-01 00:03:00.000 | ERROR | app.module: function: 4 - Database error
+2024-01-01 00:04:00.000 | INFO | app.module: function: 5 - User logged in`)
+	defer os.Remove(tmpFile1)
+	defer os.Remove(tmpFile2)
+
+	analysis := analyzeLogFiles(context.Background(), []string{tmpFile1, tmpFile2}, analysisOptions{minSeverity: "WARNING"})
+	if analysis.numEntries != 2 {
+		t.Errorf("Expected 2 entries at WARNING or above, got %d", analysis.numEntries)
+	}
+	expectedFreq := LogSeverityFrequency{"WARNING": 1, "ERROR": 1}
+	if !reflect.DeepEqual(analysis.logSeverityFrequency, expectedFreq) {
+		t.Errorf("Incorrect severity frequencies: got %+v, want %+v", analysis.logSeverityFrequency, expectedFreq)
+	}
+	if got := analysis.startTime.Format(layout); got != "2024-01-01 00:01:00" {
+		t.Errorf("Expected the time range to start at the first WARNING, got %s", got)
+	}
+	if got := analysis.endTime.Format(layout); got != "2024-01-01 00:03:00" {
+		t.Errorf("Expected the time range to end at the last ERROR, got %s", got)
+	}
+}
+
+func TestGetFileLogAnalyses(t *testing.T) {
+	tmpFile1 := createTestLogFile(t, `2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error`)
+	tmpFile2 := createTestLogFile(t, `2024-01-01 00:01:00.000 | INFO | app.module: function: 2 - User logged in
+2024-01-01 00:02:00.000 | INFO | app.module: function: 3 - User logged in`)
+	defer os.Remove(tmpFile1)
+	defer os.Remove(tmpFile2)
+
+	logPaths := []string{tmpFile1, tmpFile2}
+	fileLogAnalyses := getFileLogAnalyses(logPaths, collectLogAnalyses(context.Background(), logPaths, analysisOptions{}))
+
+	if len(fileLogAnalyses) != 2 {
+		t.Fatalf("Expected 2 file analyses, got %d", len(fileLogAnalyses))
+	}
+	if fileLogAnalyses[0].logPath != tmpFile1 || fileLogAnalyses[0].logSeverityFrequency["ERROR"] != 1 {
+		t.Errorf("Unexpected first file analysis: %s with %d errors", fileLogAnalyses[0].logPath, fileLogAnalyses[0].logSeverityFrequency["ERROR"])
+	}
+	if fileLogAnalyses[1].logPath != tmpFile2 || fileLogAnalyses[1].numEntries != 2 || fileLogAnalyses[1].topFiveLogMessages[0] != "User logged in" {
+		t.Errorf("Unexpected second file analysis: %s with %d entries", fileLogAnalyses[1].logPath, fileLogAnalyses[1].numEntries)
+	}
+}
@@ -0,0 +1,178 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// NewLogMessage builds an entry for a custom Parser. The timestamp is kept in
+// UTC at millisecond precision, like the built-in parsers do.
+func NewLogMessage(timestamp time.Time, severity string, module string, function string, lineNumber int64, message string) LogMessage {
+	return LogMessage{
+		timestamp:  timestamp.UTC().Format(layout),
+		severity:   normalizeSeverity(severity),
+		module:     module,
+		function:   function,
+		lineNumber: lineNumber,
+		message:    message,
+	}
+}
+
+// Timestamp returns the entry's time, or the zero time if it has none.
+func (logMessage LogMessage) Timestamp() time.Time {
+	timestamp, _ := time.Parse(layout, logMessage.timestamp)
+	return timestamp
+}
+
+func (logMessage LogMessage) Severity() string  { return logMessage.severity }
+func (logMessage LogMessage) Module() string    { return logMessage.module }
+func (logMessage LogMessage) Function() string  { return logMessage.function }
+func (logMessage LogMessage) LineNumber() int64 { return logMessage.lineNumber }
+func (logMessage LogMessage) Message() string   { return logMessage.message }
+
+// Attribute returns a key the format carries beyond the standard fields,
+// such as a CEF extension or LEEF attribute, or "" if the entry has none.
+func (logMessage LogMessage) Attribute(key string) string {
+	return logMessage.attributes[key]
+}
+
+// PresetParser returns the parser for a built-in format, named like the
+// -preset values: native, log4j, json, access and so on.
+// The w3c parser follows the #Fields directives of the input it reads, so
+// use it for one input at a time, or set Options.Preset to give every
+// reader its own.
+func PresetParser(preset string) (Parser, error) {
+	return getLineParser(preset)
+}
+
+// JSONParser returns a parser for JSON Lines with the entry fields under
+// the given keys, like "timestamp=ts,severity=level|lvl,message=msg". Fields
+// it doesn't name are looked up under the json preset's default keys.
+func JSONParser(fields string) (Parser, error) {
+	fieldKeys, err := parseJSONFieldKeys(fields)
+	if err != nil {
+		return nil, err
+	}
+	return newJSONLinesParser(fieldKeys), nil
+}
+
+// PatternParser returns a parser for a custom layout, given either as a
+// template like "{timestamp} [{severity}] {message}" or as a regex with
+// named groups.
+func PatternParser(pattern string) (Parser, error) {
+	return newPatternParser(pattern, timeFormat{})
+}
+
+// Options configures Analyze. The zero value parses the native format and
+// keeps every entry.
+type Options struct {
+	Parser Parser
+	// Preset names a built-in format to use when Parser is nil.
+	Preset string
+	// MinSeverity drops entries below a known severity, such as WARNING.
+	MinSeverity string
+	// Since and Until drop entries outside [Since, Until) when set.
+	Since time.Time
+	Until time.Time
+	// Grep keeps only entries whose message matches, and ExcludeGrep drops
+	// those whose message does, as -grep and -exclude-grep do.
+	Grep        *regexp.Regexp
+	ExcludeGrep *regexp.Regexp
+	// FuzzyGrep keeps only entries whose message has every word of it,
+	// allowing typos, as -fuzzy-grep does.
+	FuzzyGrep string
+	// LineFilter skips raw lines before they are parsed, as -line-filter
+	// does. It can't be used with the w3c or mysql-slow Preset.
+	LineFilter string
+	// MessageKeyer, when set, computes what messages are counted and ranked
+	// by, as -message-key does. See NewMessageKeyer.
+	MessageKeyer MessageKeyer
+	// Templates ranks messages by template, as -templates does, when no
+	// MessageKeyer is set.
+	Templates bool
+	// AggregatesOnly replaces all message text with message IDs, as
+	// -aggregates-only does.
+	AggregatesOnly bool
+}
+
+// Analyze analyzes readers with the default Options.
+func Analyze(ctx context.Context, readers ...io.Reader) (Analysis, error) {
+	return Options{}.Analyze(ctx, readers...)
+}
+
+// Analyze reads every reader concurrently and returns their merged analysis,
+// with one entry per reader in Files when there is more than one. Readers
+// that are files are named after them, others as input-1, input-2 and so on.
+// When a reader fails, the analysis of what was read is returned with a
+// *FileError for it, joined with those of any others that failed.
+func (options Options) Analyze(ctx context.Context, readers ...io.Reader) (analysis Analysis, err error) {
+	analysisOptions := analysisOptions{
+		parser:         options.Parser,
+		minSeverity:    normalizeSeverity(options.MinSeverity),
+		since:          options.Since,
+		until:          options.Until,
+		grep:           options.Grep,
+		excludeGrep:    options.ExcludeGrep,
+		messageKeyer:   options.MessageKeyer,
+		aggregatesOnly: options.AggregatesOnly,
+	}
+	if options.FuzzyGrep != "" {
+		query := newFuzzyQuery(options.FuzzyGrep)
+		analysisOptions.fuzzyGrep = &query
+	}
+	if options.LineFilter != "" {
+		if analysisOptions.lineFilter, err = parseLineFilter(options.LineFilter); err != nil {
+			return analysis, err
+		}
+	}
+	if options.MessageKeyer == nil && options.Templates {
+		analysisOptions.messageKeyer = templateMessageKeyer{}
+	}
+	if options.Parser == nil && options.Preset != "" {
+		if newParser, ok := parserFactories[options.Preset]; ok {
+			if analysisOptions.lineFilter != nil {
+				return analysis, errors.New("a line filter can't be used with the " + options.Preset + " preset, which has to read every line")
+			}
+			analysisOptions.newParser = newParser
+		} else if analysisOptions.parser, err = getLineParser(options.Preset); err != nil {
+			return analysis, err
+		}
+	}
+	if len(readers) == 0 {
+		return analysis, errors.New("nothing to analyze")
+	}
+	analysisOptions.interner = newStringInterner(metrics)
+	logPaths := make([]string, len(readers))
+	logAnalyses := make([]LogAnalysis, len(readers))
+	finished := make(chan int, len(readers))
+	for index, reader := range readers {
+		logPaths[index] = "input-" + strconv.Itoa(index+1)
+		if file, ok := reader.(*os.File); ok {
+			logPaths[index] = file.Name()
+		}
+		go func(index int, reader io.Reader) {
+			logAnalyses[index] = analyzeLogReader(ctx, reader, logPaths[index], analysisOptions)
+			finished <- index
+		}(index, reader)
+	}
+	for range readers {
+		select {
+		case <-ctx.Done():
+			return analysis, ctx.Err()
+		case <-finished:
+		}
+	}
+	logAnalysis := analyzelogAnalyses(logAnalyses)
+	analysis = newJSONLogAnalysis(logAnalysis, nil)
+	if len(readers) > 1 {
+		for _, fileLogAnalysis := range getFileLogAnalyses(logPaths, logAnalyses) {
+			analysis.Files = append(analysis.Files, newJSONLogAnalysis(fileLogAnalysis, nil))
+		}
+	}
+	return analysis, errors.Join(logAnalysis.fileErrors...)
+}
@@ -0,0 +1,52 @@
+package analyzer_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"concurrent_log_analyzer/analyzer"
+)
+
+func TestAnalyze(t *testing.T) {
+	first := strings.NewReader(`2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error
+2024-01-01 00:01:00.000 | INFO | app.module: function: 2 - User logged in`)
+	second := strings.NewReader(`2024-01-01 00:02:00.000 | ERROR | app.module: function: 3 - Database error`)
+
+	analysis, err := analyzer.Analyze(context.Background(), first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Entries != 3 || analysis.SeverityFrequency.Error != 2 {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+	if analysis.TopMessages[0].Message != "Database error" || analysis.TopMessages[0].Frequency != 2 {
+		t.Errorf("Unexpected top messages: %+v", analysis.TopMessages)
+	}
+	if len(analysis.Files) != 2 || analysis.Files[0].LogPath != "input-1" || analysis.Files[1].Entries != 1 {
+		t.Errorf("Unexpected per-reader analyses: %+v", analysis.Files)
+	}
+}
+
+func TestAnalyzeWithCustomParser(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	parser := analyzer.Parser(func(line string) (analyzer.LogMessage, error) {
+		severity, message, _ := strings.Cut(line, " ")
+		return analyzer.NewLogMessage(start, severity, "app", "", 0, message), nil
+	})
+	options := analyzer.Options{Parser: parser, MinSeverity: "warn"}
+
+	analysis, err := options.Analyze(context.Background(), strings.NewReader("INFO started\nWARN disk almost full\nERROR disk full\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Entries != 2 || analysis.SeverityFrequency.Warning != 1 || !analysis.StartTime.Equal(start) {
+		t.Errorf("Unexpected analysis: %+v", analysis)
+	}
+
+	logMessage, err := parser("ERROR disk full")
+	if err != nil || logMessage.Severity() != "ERROR" || logMessage.Message() != "disk full" || !logMessage.Timestamp().Equal(start) {
+		t.Errorf("Unexpected log message: %+v", logMessage)
+	}
+}
@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+)
+
+const (
+	// auditLockTimeout is how long to wait for another run appending to the
+	// same audit log before giving up.
+	auditLockTimeout = 10 * time.Second
+	auditLockRetry   = 50 * time.Millisecond
+	auditTailChunk   = 4096
+)
+
+// auditRecord is one line of the -audit-log file. Previous is the SHA-256
+// of the line before it, so editing, inserting or removing a line breaks
+// the chain from there on.
+type auditRecord struct {
+	Time      time.Time         `json:"time"`
+	User      string            `json:"user"`
+	SudoUser  string            `json:"sudoUser,omitempty"`
+	Host      string            `json:"host"`
+	PID       int               `json:"pid"`
+	Mode      string            `json:"mode"`
+	Arguments []string          `json:"arguments"`
+	Flags     map[string]string `json:"flags"`
+	Inputs    []auditInput      `json:"inputs"`
+	Previous  string            `json:"previous"`
+}
+
+type auditInput struct {
+	Path    string     `json:"path"`
+	Size    int64      `json:"size"`
+	ModTime *time.Time `json:"modTime,omitempty"`
+}
+
+func getAuditUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// newAuditRecord describes this run: who started it, the flags in effect,
+// whether set on the command line or by -config, and the inputs with their
+// size and modification time at the start.
+func newAuditRecord(flags *flag.FlagSet, arguments []string, mode string, logPaths []string, now time.Time) auditRecord {
+	record := auditRecord{
+		Time:      now.UTC(),
+		User:      getAuditUser(),
+		SudoUser:  os.Getenv("SUDO_USER"),
+		PID:       os.Getpid(),
+		Mode:      mode,
+		Arguments: arguments,
+		Flags:     make(map[string]string),
+		Inputs:    []auditInput{},
+	}
+	record.Host, _ = os.Hostname()
+	flags.Visit(func(setFlag *flag.Flag) {
+		record.Flags[setFlag.Name] = setFlag.Value.String()
+	})
+	for _, logPath := range logPaths {
+		input := auditInput{Path: logPath}
+		if info, err := os.Stat(logPath); err == nil && logPath != stdinPath {
+			input.Size = info.Size()
+			modTime := info.ModTime().UTC()
+			input.ModTime = &modTime
+		}
+		record.Inputs = append(record.Inputs, input)
+	}
+	return record
+}
+
+func hashAuditLine(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// readLastAuditLine reads the file backwards from its end to the start of
+// the last line, so appending stays cheap however long the log grows.
+func readLastAuditLine(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		chunkSize := min(int64(auditTailChunk), offset)
+		offset -= chunkSize
+		chunk := make([]byte, chunkSize)
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		tail = append(chunk, tail...)
+		trimmed := bytes.TrimRight(tail, "\n")
+		if newline := bytes.LastIndexByte(trimmed, '\n'); newline >= 0 {
+			return trimmed[newline+1:], nil
+		}
+		if offset == 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+// lockAuditLog takes a lock file next to the audit log so concurrent runs
+// append one after the other instead of forking the chain.
+func lockAuditLog(auditPath string) (unlock func(), err error) {
+	lockPath := auditPath + ".lock"
+	deadline := time.Now().Add(auditLockTimeout)
+	for {
+		lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			lockFile.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("audit log is locked by " + lockPath + "; remove it if no other run is writing")
+		}
+		time.Sleep(auditLockRetry)
+	}
+}
+
+// appendAuditRecord chains record to the last line of the audit log and
+// appends it.
+func appendAuditRecord(auditPath string, record auditRecord) error {
+	unlock, err := lockAuditLog(auditPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	auditFile, err := os.OpenFile(auditPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer auditFile.Close()
+	lastLine, err := readLastAuditLine(auditFile)
+	if err != nil {
+		return err
+	}
+	if len(lastLine) > 0 {
+		record.Previous = hashAuditLine(lastLine)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := auditFile.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return auditFile.Sync()
+}
+
+// verifyAuditLog checks that every record names the hash of the line before
+// it, returning the number of records and the hash of the last line. That
+// hash, kept elsewhere and passed back as anchor later, also protects the
+// records up to it against being removed from the end.
+func verifyAuditLog(reader io.Reader, anchor string) (records int, head string, err error) {
+	anchored := anchor == ""
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		records += 1
+		var record auditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return records, head, errors.New("record " + strconv.Itoa(records) + " is not valid JSON: " + err.Error())
+		}
+		if record.Previous != head {
+			return records, head, errors.New("record " + strconv.Itoa(records) + " does not follow the record before it")
+		}
+		head = hashAuditLine(line)
+		anchored = anchored || head == anchor
+	}
+	if err := scanner.Err(); err != nil {
+		return records, head, err
+	}
+	if !anchored {
+		return records, head, errors.New("no record has the hash " + anchor + "; records were removed from the end")
+	}
+	return records, head, nil
+}
+
+func runVerifyAudit(args []string) {
+	flagSet := flag.NewFlagSet("verify-audit", flag.ExitOnError)
+	anchor := flagSet.String("head", "", "also require a record with this hash, the head printed by an earlier verification")
+	flagSet.Parse(args)
+	if flagSet.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: verify-audit [-head hash] audit.log")
+		os.Exit(exitUsage)
+	}
+	auditFile, err := os.Open(flagSet.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
+	defer auditFile.Close()
+	records, head, err := verifyAuditLog(auditFile, *anchor)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Audit log tampered:", err)
+		os.Exit(exitFailure)
+	}
+	fmt.Println("Audit log intact: " + strconv.Itoa(records) + " records, head " + head)
+}
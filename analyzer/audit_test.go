@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("min-severity", "", "")
+	flags.String("preset", "native", "")
+	if err := flags.Parse([]string{"-min-severity", "ERROR", "app.log"}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, time.January, 2, 15, 4, 5, 0, time.UTC)
+	for run := 0; run < 3; run++ {
+		record := newAuditRecord(flags, []string{"-min-severity", "ERROR", auditPath}, "batch", []string{auditPath}, now)
+		if record.Flags["min-severity"] != "ERROR" || len(record.Flags) != 1 || record.Inputs[0].Path != auditPath {
+			t.Fatalf("newAuditRecord() = %+v", record)
+		}
+		if err := appendAuditRecord(auditPath, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, head, err := verifyAuditLog(bytes.NewReader(data), "")
+	if err != nil || records != 3 {
+		t.Fatalf("verifyAuditLog() = %d, %v", records, err)
+	}
+	if _, err := os.Stat(auditPath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, got %v", err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if _, _, err := verifyAuditLog(strings.NewReader(lines[0]+lines[2]), ""); err == nil {
+		t.Errorf("verifyAuditLog() accepted a log with a removed record")
+	}
+	tampered := strings.Replace(string(data), `"min-severity":"ERROR"`, `"min-severity":"INFO"`, 1)
+	if _, _, err := verifyAuditLog(strings.NewReader(tampered), ""); err == nil {
+		t.Errorf("verifyAuditLog() accepted an edited record")
+	}
+	if _, _, err := verifyAuditLog(strings.NewReader(lines[0]+lines[1]), head); err == nil {
+		t.Errorf("verifyAuditLog() accepted a log truncated before its anchor")
+	}
+}
+
+func TestReadLastAuditLine(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	long := strings.Repeat("x", 3*auditTailChunk)
+	if err := os.WriteFile(auditPath, []byte("first\n"+long+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auditFile, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditFile.Close()
+	if line, err := readLastAuditLine(auditFile); err != nil || string(line) != long {
+		t.Errorf("readLastAuditLine() = %d bytes, %v, want %d bytes", len(line), err, len(long))
+	}
+}
@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Bad lines longer than this are cut when shown.
+const maxBadLineLength = 200
+
+// badLine is a malformed line kept as an example for -show-bad-lines.
+type badLine struct {
+	logPath    string
+	lineNumber int64
+	reason     string
+	text       string
+}
+
+func getMalformedRatio(logAnalysis LogAnalysis) float64 {
+	if logAnalysis.lines == 0 {
+		return 0
+	}
+	return float64(logAnalysis.malformedLines) / float64(logAnalysis.lines)
+}
+
+func formatBadLine(line badLine) string {
+	text := line.text
+	if len([]rune(text)) > maxBadLineLength {
+		text = string([]rune(text)[:maxBadLineLength-3]) + "..."
+	}
+	return line.logPath + ":" + strconv.FormatInt(line.lineNumber, 10) + ": " + line.reason + ": " + text
+}
+
+// checkMalformedRatio fails -strict runs whose share of malformed lines,
+// over every input, is above maxRatio.
+func checkMalformedRatio(logAnalysis LogAnalysis, maxRatio float64) error {
+	if ratio := getMalformedRatio(logAnalysis); ratio > maxRatio {
+		return errors.New(strconv.FormatInt(logAnalysis.malformedLines, 10) + " of " + strconv.FormatInt(logAnalysis.lines, 10) +
+			" lines are malformed (" + formatPercent(ratio) + "), above the " + formatPercent(maxRatio) + " allowed by -strict")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBadLineSamples(t *testing.T) {
+	input := "2024-01-02 15:04:05.000 | INFO | app: f: 1 - ok\n\ngarbage\n2024-01-02 15:04:06.000 | INFO | app: f: 2 - ok\nmore garbage\nstill garbage\n"
+	logMessages, unlabeledMessages, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, analysisOptions{badLineSamples: 2})
+	if stats.lines != 5 || stats.malformedLines != 3 {
+		t.Fatalf("Expected 3 of 5 lines malformed, got %d of %d", stats.malformedLines, stats.lines)
+	}
+	// Line numbers count the blank line, and only two examples are kept
+	if len(stats.badLines) != 2 || stats.badLines[0].lineNumber != 3 || stats.badLines[0].text != "garbage" || stats.badLines[1].lineNumber != 5 {
+		t.Errorf("Unexpected bad lines %+v", stats.badLines)
+	}
+	if got, want := formatBadLine(stats.badLines[0]), stdinPath+":3: Missing delimiter: garbage"; got != want {
+		t.Errorf("formatBadLine() = %q, want %q", got, want)
+	}
+
+	logAnalysis := newLogAnalysis(stdinPath, logMessages, unlabeledMessages, stats, analysisOptions{})
+	if err := checkMalformedRatio(logAnalysis, 0.6); err != nil {
+		t.Errorf("Expected 60%% malformed to be allowed, got %v", err)
+	}
+	if err := checkMalformedRatio(logAnalysis, 0.5); err == nil {
+		t.Error("Expected 60% malformed to fail a 50% limit")
+	}
+	merged := analyzelogAnalyses([]LogAnalysis{logAnalysis, logAnalysis})
+	if merged.lines != 10 || merged.malformedLines != 6 || len(merged.badLines) != 4 {
+		t.Errorf("Expected merged counts to add up, got %d of %d with %d examples", merged.malformedLines, merged.lines, len(merged.badLines))
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// bootFinalMessages is how many of a boot's last entries are kept to show
+// what happened before an unclean shutdown.
+const bootFinalMessages = 5
+
+// The kernel's first line of every boot, as it appears in syslog, kern.log
+// and the journal: "[    0.000000] Linux version 6.1.0-18-amd64 ..."
+var bootMarkerPattern = regexp.MustCompile(`(?:^|\] )(?:Linux version \d|Booting Linux on )`)
+
+// What systemd logs on the way to a shutdown, reboot or halt, in the older
+// "Reached target Shutdown." and the newer
+// "Reached target shutdown.target - System Shutdown." form
+var shutdownMarkerPattern = regexp.MustCompile(`Reached target (?:\S+ - )?(?:System )?(?:Shutdown|Power-?Off|Reboot|Halt|Final Step)\b|System is (?:powering down|rebooting|halting)`)
+
+type bootSession struct {
+	logPath       string
+	bootID        string
+	start         time.Time
+	end           time.Time
+	entries       int64
+	errors        int64
+	cleanShutdown bool
+	// ended is set when a later boot follows, so a boot without a clean
+	// shutdown was cut short
+	ended         bool
+	finalMessages []string
+}
+
+func isShutdownEntry(logMessage LogMessage) bool {
+	return logMessage.module == "systemd-shutdown" || shutdownMarkerPattern.MatchString(logMessage.message)
+}
+
+// getBootSessions splits a file's entries into boots. A change of the
+// boot_id attribute starts a new boot; entries without one start a new boot
+// at the kernel's first line.
+func getBootSessions(logPath string, logMessages []LogMessage) (bootSessions []bootSession) {
+	var current *bootSession
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		bootID := logMessage.Attribute("boot_id")
+		var newBoot bool
+		switch {
+		case current == nil:
+			newBoot = true
+		case bootID != "":
+			newBoot = bootID != current.bootID
+		default:
+			newBoot = bootMarkerPattern.MatchString(logMessage.message)
+		}
+		if newBoot {
+			if current != nil {
+				current.ended = true
+			}
+			bootSessions = append(bootSessions, bootSession{logPath: logPath, bootID: bootID, start: timestamp})
+			current = &bootSessions[len(bootSessions)-1]
+		}
+		current.end = timestamp
+		current.entries += 1
+		if isErrorSeverity(logMessage.severity) {
+			current.errors += 1
+		}
+		if isShutdownEntry(logMessage) {
+			current.cleanShutdown = true
+		}
+		current.finalMessages = append(current.finalMessages, logMessage.timestamp+" "+logMessage.severity+" "+logMessage.module+": "+logMessage.message)
+		if len(current.finalMessages) > bootFinalMessages {
+			current.finalMessages = current.finalMessages[1:]
+		}
+	}
+	return
+}
+
+func sortBootSessions(bootSessions []bootSession) {
+	sort.SliceStable(bootSessions, func(i, j int) bool {
+		return bootSessions[i].start.Before(bootSessions[j].start)
+	})
+}
+
+func (session bootSession) getShutdown() string {
+	switch {
+	case session.cleanShutdown:
+		return "clean"
+	case session.ended:
+		return "unclean"
+	}
+	return ""
+}
+
+// formatBootSession describes a boot on one line, followed by its final
+// messages when it ended without a clean shutdown.
+func formatBootSession(session bootSession, options reportOptions) (lines []string) {
+	line := options.formatTime(session.start, layout) + " - " + options.formatTime(session.end, layout) + " (" + humanizeDuration(session.end.Sub(session.start), options) + ") " + session.logPath
+	if session.bootID != "" {
+		line += " boot " + session.bootID
+	}
+	line += ": " + humanizeCount(session.entries, options) + " entries, " + humanizeCount(session.errors, options) + " errors, "
+	switch session.getShutdown() {
+	case "clean":
+		line += "clean shutdown"
+	case "unclean":
+		line += "unclean shutdown, last " + strconv.Itoa(len(session.finalMessages)) + " messages:"
+	default:
+		line += "last boot in the log"
+	}
+	lines = append(lines, line)
+	if session.getShutdown() == "unclean" {
+		for _, message := range session.finalMessages {
+			lines = append(lines, "   "+message)
+		}
+	}
+	return
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJournalMessage(t *testing.T) {
+	got, err := parseJournalMessage(`{"__REALTIME_TIMESTAMP":"1704207845123456","PRIORITY":"3","_BOOT_ID":"3f2d9a","SYSLOG_IDENTIFIER":"sshd","_PID":"4242","_SYSTEMD_UNIT":"ssh.service","_HOSTNAME":"web1","MESSAGE":[66,97,100,255]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LogMessage{timestamp: "2024-01-02 15:04:05.123", severity: "ERROR", module: "sshd", pid: "4242", message: "Bad\xff",
+		attributes: map[string]string{"boot_id": "3f2d9a", "unit": "ssh.service", "host": "web1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJournalMessage() = %+v, want %+v", got, want)
+	}
+	if _, err := parseJournalMessage(`{"MESSAGE":"no time"}`); err == nil {
+		t.Errorf("parseJournalMessage() accepted an entry without a timestamp")
+	}
+}
+
+func TestGetBootSessions(t *testing.T) {
+	logMessages := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "INFO", module: "kernel", message: "[    0.000000] Linux version 6.1.0-18-amd64"},
+		{timestamp: "2024-01-01 09:00:00", severity: "INFO", module: "systemd", message: "Reached target shutdown.target - System Shutdown."},
+		{timestamp: "2024-01-01 09:05:00", severity: "INFO", module: "kernel", message: "[    0.000000] Linux version 6.1.0-18-amd64"},
+		{timestamp: "2024-01-01 09:30:00", severity: "ERROR", module: "kernel", message: "EXT4-fs error (device sda1)"},
+		{timestamp: "2024-01-01 10:00:00", severity: "INFO", module: "kernel", message: "Booting Linux on physical CPU 0x0"},
+		{timestamp: "2024-01-01 10:10:00", severity: "INFO", module: "sshd", message: "Server listening on 0.0.0.0 port 22."},
+	}
+	sessions := getBootSessions("syslog", logMessages)
+	if len(sessions) != 3 {
+		t.Fatalf("Expected 3 boots, got %d", len(sessions))
+	}
+	if sessions[0].getShutdown() != "clean" || sessions[1].getShutdown() != "unclean" || sessions[2].getShutdown() != "" {
+		t.Errorf("Shutdowns = %q, %q, %q", sessions[0].getShutdown(), sessions[1].getShutdown(), sessions[2].getShutdown())
+	}
+	if sessions[1].errors != 1 || sessions[1].end.Sub(sessions[1].start).Minutes() != 25 ||
+		sessions[1].finalMessages[1] != "2024-01-01 09:30:00 ERROR kernel: EXT4-fs error (device sda1)" {
+		t.Errorf("Second boot = %+v", sessions[1])
+	}
+
+	// Boot IDs take precedence over kernel lines
+	withIDs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", message: "Linux version 6.1.0", attributes: map[string]string{"boot_id": "a"}},
+		{timestamp: "2024-01-01 08:01:00", message: "Linux version 6.1.0", attributes: map[string]string{"boot_id": "a"}},
+		{timestamp: "2024-01-01 09:00:00", message: "Started", attributes: map[string]string{"boot_id": "b"}},
+	}
+	sessions = getBootSessions("journal", withIDs)
+	if len(sessions) != 2 || sessions[0].bootID != "a" || sessions[0].entries != 2 || sessions[1].bootID != "b" {
+		t.Errorf("getBootSessions() with boot IDs = %+v", sessions)
+	}
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+)
+
+// Entries are counted per minute so coarser buckets can be derived at
+// output time without re-reading the logs.
+const bucketResolution = time.Minute
+
+type timeBucketKey struct {
+	start    time.Time
+	module   string
+	severity string
+}
+
+func getTimeBucketCounts(logMessages []LogMessage) (timeBucketCounts map[timeBucketKey]int64) {
+	timeBucketCounts = make(map[timeBucketKey]int64)
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		key := timeBucketKey{
+			start:    timestamp.Truncate(bucketResolution),
+			module:   logMessage.module,
+			severity: logMessage.severity,
+		}
+		timeBucketCounts[key] += 1
+	}
+	return
+}
+
+func mergeTimeBucketCounts(into map[timeBucketKey]int64, from map[timeBucketKey]int64) {
+	for key, count := range from {
+		into[key] += count
+	}
+}
+
+func rebucketTimeBucketCounts(timeBucketCounts map[timeBucketKey]int64, interval time.Duration) (rebucketed map[timeBucketKey]int64) {
+	rebucketed = make(map[timeBucketKey]int64, len(timeBucketCounts))
+	if interval < bucketResolution {
+		interval = bucketResolution
+	}
+	for key, count := range timeBucketCounts {
+		key.start = key.start.Truncate(interval)
+		rebucketed[key] += count
+	}
+	return
+}
+
+func getSortedTimeBucketKeys(timeBucketCounts map[timeBucketKey]int64) (keys []timeBucketKey) {
+	keys = make([]timeBucketKey, 0, len(timeBucketCounts))
+	for key := range timeBucketCounts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].start.Equal(keys[j].start) {
+			return keys[i].start.Before(keys[j].start)
+		}
+		if keys[i].module != keys[j].module {
+			return keys[i].module < keys[j].module
+		}
+		return keys[i].severity < keys[j].severity
+	})
+	return
+}
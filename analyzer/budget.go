@@ -0,0 +1,283 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const monthLayout string = "2006-01"
+
+type moduleMonth struct {
+	module string
+	month  string
+}
+
+type moduleMonthCount struct {
+	entries  int64
+	errors   int64
+	lastSeen time.Time
+}
+
+// ErrorBudget caps the ERROR entries a module may produce in a calendar
+// month, either as an absolute count or as a fraction of its entries.
+type ErrorBudget struct {
+	MaxErrors    int64   `json:"maxErrors,omitempty"`
+	MaxErrorRate float64 `json:"maxErrorRate,omitempty"`
+}
+
+type budgetConsumption struct {
+	Entries  int64     `json:"entries"`
+	Errors   int64     `json:"errors"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// budgetState is keyed by month, then getBudgetFileKey, then module.
+// Keeping each file's contribution separate means re-running over a file
+// that has grown replaces its earlier numbers instead of counting them twice.
+type budgetState map[string]map[string]map[string]budgetConsumption
+
+type budgetReportRow struct {
+	month         string
+	module        string
+	budget        ErrorBudget
+	entries       int64
+	errors        int64
+	consumedRatio float64
+	elapsedRatio  float64
+}
+
+func getModuleMonthCounts(logMessages []LogMessage) (moduleMonthCounts map[moduleMonth]moduleMonthCount) {
+	moduleMonthCounts = make(map[moduleMonth]moduleMonthCount)
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		key := moduleMonth{module: logMessage.module, month: timestamp.Format(monthLayout)}
+		count := moduleMonthCounts[key]
+		count.entries += 1
+		if isErrorSeverity(logMessage.severity) {
+			count.errors += 1
+		}
+		if timestamp.After(count.lastSeen) {
+			count.lastSeen = timestamp
+		}
+		moduleMonthCounts[key] = count
+	}
+	return
+}
+
+func mergeModuleMonthCounts(into map[moduleMonth]moduleMonthCount, from map[moduleMonth]moduleMonthCount) {
+	for key, count := range from {
+		merged := into[key]
+		merged.entries += count.entries
+		merged.errors += count.errors
+		if count.lastSeen.After(merged.lastSeen) {
+			merged.lastSeen = count.lastSeen
+		}
+		into[key] = merged
+	}
+}
+
+func loadErrorBudgets(budgetPath string) (budgets map[string]ErrorBudget, err error) {
+	data, err := os.ReadFile(budgetPath)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &budgets); err != nil {
+		return
+	}
+	for module, budget := range budgets {
+		if budget.MaxErrors <= 0 && budget.MaxErrorRate <= 0 {
+			return nil, errors.New("budget for module " + module + " needs maxErrors or maxErrorRate")
+		}
+	}
+	return
+}
+
+func loadBudgetState(statePath string, key []byte) (state budgetState, err error) {
+	state = make(budgetState)
+	data, err := readInputFile(statePath, key)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &state)
+	return
+}
+
+func saveBudgetState(statePath string, state budgetState, key []byte) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutputFile(statePath, data, key)
+}
+
+// getBudgetFileKey names a file's contribution in the budget state by the
+// file and its first timestamp rather than by its path: by device and inode
+// where os.Stat has them, so a rotated file renamed to app.log.1 keeps its
+// key, and by absolute path otherwise. A file that grows keeps its key too,
+// while the new file rotation leaves at the same path gets a new one, so the
+// consumption of the old one isn't replaced.
+func getBudgetFileKey(logAnalysis LogAnalysis) string {
+	start := logAnalysis.startTime.UTC().Format(time.RFC3339Nano)
+	if logAnalysis.logPath != stdinPath {
+		if fileInfo, err := os.Stat(logAnalysis.logPath); err == nil {
+			if id, ok := getFileID(fileInfo); ok {
+				return "inode " + strconv.FormatUint(id.device, 10) + ":" + strconv.FormatUint(id.inode, 10) + " from " + start
+			}
+		}
+	}
+	logPath, err := filepath.Abs(logAnalysis.logPath)
+	if err != nil {
+		logPath = logAnalysis.logPath
+	}
+	return logPath + " from " + start
+}
+
+func updateBudgetState(state budgetState, logAnalyses []LogAnalysis) {
+	for _, logAnalysis := range logAnalyses {
+		fileKey := getBudgetFileKey(logAnalysis)
+		for _, files := range state {
+			delete(files, fileKey)
+		}
+		for key, count := range logAnalysis.moduleMonthCounts {
+			if state[key.month] == nil {
+				state[key.month] = make(map[string]map[string]budgetConsumption)
+			}
+			if state[key.month][fileKey] == nil {
+				state[key.month][fileKey] = make(map[string]budgetConsumption)
+			}
+			state[key.month][fileKey][key.module] = budgetConsumption{
+				Entries:  count.entries,
+				Errors:   count.errors,
+				LastSeen: count.lastSeen,
+			}
+		}
+	}
+}
+
+func getMonthElapsedRatio(month string, lastSeen time.Time) float64 {
+	monthStart, err := time.Parse(monthLayout, month)
+	if err != nil || lastSeen.IsZero() {
+		return 0
+	}
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	ratio := float64(lastSeen.Sub(monthStart)) / float64(monthEnd.Sub(monthStart))
+	if ratio > 1 {
+		ratio = 1
+	}
+	return ratio
+}
+
+func getBudgetReport(budgets map[string]ErrorBudget, state budgetState) (budgetReport []budgetReportRow) {
+	for month, files := range state {
+		for module, budget := range budgets {
+			var row budgetReportRow
+			row.month = month
+			row.module = module
+			row.budget = budget
+			var lastSeen time.Time
+			for _, modules := range files {
+				consumption, ok := modules[module]
+				if !ok {
+					continue
+				}
+				row.entries += consumption.Entries
+				row.errors += consumption.Errors
+				if consumption.LastSeen.After(lastSeen) {
+					lastSeen = consumption.LastSeen
+				}
+			}
+			if row.entries == 0 {
+				continue
+			}
+			if budget.MaxErrors > 0 {
+				row.consumedRatio = float64(row.errors) / float64(budget.MaxErrors)
+			} else {
+				row.consumedRatio = float64(row.errors) / float64(row.entries) / budget.MaxErrorRate
+			}
+			row.elapsedRatio = getMonthElapsedRatio(month, lastSeen)
+			budgetReport = append(budgetReport, row)
+		}
+	}
+	sort.Slice(budgetReport, func(i, j int) bool {
+		if budgetReport[i].month != budgetReport[j].month {
+			return budgetReport[i].month < budgetReport[j].month
+		}
+		return budgetReport[i].module < budgetReport[j].module
+	})
+	return
+}
+
+// trackErrorBudgets adds logAnalyses to the budget state at statePath, which
+// is kept encrypted with key when it is set, and reports every budget.
+func trackErrorBudgets(budgetPath string, statePath string, logAnalyses []LogAnalysis, key []byte) (budgetReport []budgetReportRow, err error) {
+	budgets, err := loadErrorBudgets(budgetPath)
+	if err != nil {
+		return
+	}
+	state, err := loadBudgetState(statePath, key)
+	if err != nil {
+		return
+	}
+	updateBudgetState(state, logAnalyses)
+	if err = saveBudgetState(statePath, state, key); err != nil {
+		return
+	}
+	budgetReport = getBudgetReport(budgets, state)
+	return
+}
+
+func formatPercent(ratio float64) string {
+	return strconv.FormatFloat(ratio*100, 'f', 1, 64) + "%"
+}
+
+func (row budgetReportRow) isExhausted() bool {
+	return row.consumedRatio >= 1
+}
+
+// countExhaustedBudgets counts the budgets used up in budgetReport.
+func countExhaustedBudgets(budgetReport []budgetReportRow) (exhausted int) {
+	for _, row := range budgetReport {
+		if row.isExhausted() {
+			exhausted += 1
+		}
+	}
+	return
+}
+
+func printBudgetReport(budgetReport []budgetReportRow) {
+	fmt.Println("Error Budgets: ")
+	for _, row := range budgetReport {
+		var usage string
+		if row.budget.MaxErrors > 0 {
+			remaining := row.budget.MaxErrors - row.errors
+			if remaining < 0 {
+				remaining = 0
+			}
+			usage = strconv.FormatInt(row.errors, 10) + " of " + strconv.FormatInt(row.budget.MaxErrors, 10) +
+				" errors, " + strconv.FormatInt(remaining, 10) + " remaining"
+		} else {
+			errorRate := float64(row.errors) / float64(row.entries)
+			usage = "error rate " + formatPercent(errorRate) + " of " + formatPercent(row.budget.MaxErrorRate)
+		}
+		status := ""
+		if row.isExhausted() {
+			status = " EXHAUSTED"
+		} else if row.elapsedRatio > 0 && row.consumedRatio > row.elapsedRatio {
+			status = " BURNING FAST"
+		}
+		fmt.Println("   " + row.month + " " + row.module + ": " + usage + " (" + formatPercent(row.consumedRatio) +
+			" consumed, " + formatPercent(row.elapsedRatio) + " of month elapsed)" + status)
+	}
+}
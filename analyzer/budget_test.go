@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackErrorBudgets(t *testing.T) {
+	logContent := `2024-01-10 00:00:00.000 | ERROR | payments: charge: 10 - Card declined
+2024-01-10 00:01:00.000 | ERROR | payments: charge: 10 - Card declined
+2024-01-10 00:02:00.000 | INFO | batch: run: 20 - Job started
+2024-01-10 00:03:00.000 | ERROR | batch: run: 21 - Job failed`
+
+	tmpFileName := createTestLogFile(t, logContent)
+	defer os.Remove(tmpFileName)
+
+	budgetPath := createTestLogFile(t, `{"payments": {"maxErrors": 4}, "batch": {"maxErrorRate": 0.25}}`)
+	defer os.Remove(budgetPath)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	logAnalyses := collectLogAnalyses(context.Background(), []string{tmpFileName}, analysisOptions{})
+	budgetReport, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(budgetReport) != 2 {
+		t.Fatalf("Expected 2 budget rows, got %d", len(budgetReport))
+	}
+	if budgetReport[0].module != "batch" || budgetReport[0].consumedRatio != 2 {
+		t.Errorf("Unexpected batch budget row: %+v", budgetReport[0])
+	}
+	if budgetReport[1].module != "payments" || budgetReport[1].consumedRatio != 0.5 {
+		t.Errorf("Unexpected payments budget row: %+v", budgetReport[1])
+	}
+	if exhausted := countExhaustedBudgets(budgetReport); exhausted != 1 {
+		t.Errorf("countExhaustedBudgets() = %d, want 1", exhausted)
+	}
+
+	// Re-running over the same file must not double count
+	budgetReport, err = trackErrorBudgets(budgetPath, statePath, logAnalyses, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budgetReport[1].errors != 2 {
+		t.Errorf("Expected re-run to keep 2 payments errors, got %d", budgetReport[1].errors)
+	}
+}
+
+func TestTrackErrorBudgetsEncryptsState(t *testing.T) {
+	tmpFileName := createTestLogFile(t, "2024-01-10 00:00:00.000 | ERROR | payments: charge: 10 - Card declined")
+	defer os.Remove(tmpFileName)
+	budgetPath := createTestLogFile(t, `{"payments": {"maxErrors": 4}}`)
+	defer os.Remove(budgetPath)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	key := bytes.Repeat([]byte{7}, 32)
+
+	logAnalyses := collectLogAnalyses(context.Background(), []string{tmpFileName}, analysisOptions{})
+	if _, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, key); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := os.ReadFile(statePath); bytes.Contains(state, []byte("payments")) {
+		t.Errorf("Budget state is not encrypted: %q", state)
+	}
+	budgetReport, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, key)
+	if err != nil || len(budgetReport) != 1 || budgetReport[0].errors != 1 {
+		t.Errorf("Expected the encrypted state to be read back with 1 error, got %+v, %v", budgetReport, err)
+	}
+	if _, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, nil); err == nil {
+		t.Error("Expected an encrypted state to need its key")
+	}
+}
+
+func TestTrackErrorBudgetsAcrossRotation(t *testing.T) {
+	directory := t.TempDir()
+	logPath := filepath.Join(directory, "app.log")
+	budgetPath := filepath.Join(directory, "budgets.json")
+	statePath := filepath.Join(directory, "state.json")
+	if err := os.WriteFile(budgetPath, []byte(`{"payments": {"maxErrors": 10}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	track := func() []budgetReportRow {
+		logAnalyses := collectLogAnalyses(context.Background(), []string{logPath}, analysisOptions{})
+		budgetReport, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return budgetReport
+	}
+
+	if err := os.WriteFile(logPath, []byte("2024-01-10 00:00:00.000 | ERROR | payments: charge: 10 - Card declined\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	track()
+	// Rotation renames the file and starts a new one at the same path
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("2024-01-11 00:00:00.000 | ERROR | payments: charge: 10 - Card declined\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if budgetReport := track(); len(budgetReport) != 1 || budgetReport[0].errors != 2 {
+		t.Errorf("Expected the errors before the rotation to be kept, got %+v", budgetReport)
+	}
+
+	// The rotated file is the same file under a new name, where its inode
+	// tells so
+	if fileInfo, err := os.Stat(logPath); err != nil {
+		t.Fatal(err)
+	} else if _, ok := getFileID(fileInfo); !ok {
+		return
+	}
+	logAnalyses := collectLogAnalyses(context.Background(), []string{logPath, logPath + ".1"}, analysisOptions{})
+	budgetReport, err := trackErrorBudgets(budgetPath, statePath, logAnalyses, nil)
+	if err != nil || len(budgetReport) != 1 || budgetReport[0].errors != 2 {
+		t.Errorf("Expected the renamed file not to be counted twice, got %+v, %v", budgetReport, err)
+	}
+}
@@ -0,0 +1,229 @@
+package analyzer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth        = 800
+	chartMarginLeft   = 60
+	chartMarginRight  = 20
+	chartTimelineTop  = 40
+	chartTimelineSize = 200
+	chartRowHeight    = 24
+	chartMessageWidth = 60
+)
+
+// Severities are stacked bottom up in this order, anything else goes last.
+var chartSeverities = []string{"ERROR", "WARNING", "INFO", "DEBUG"}
+
+var chartColors = map[string]color.RGBA{
+	"ERROR":   {0xd6, 0x27, 0x28, 0xff},
+	"WARNING": {0xff, 0x7f, 0x0e, 0xff},
+	"INFO":    {0x1f, 0x77, 0xb4, 0xff},
+	"DEBUG":   {0x7f, 0x7f, 0x7f, 0xff},
+	"":        {0xbc, 0xbd, 0x22, 0xff},
+}
+
+var chartTextColor = color.RGBA{0x33, 0x33, 0x33, 0xff}
+
+type chartRect struct {
+	x, y, width, height float64
+	fill                color.RGBA
+}
+
+type chartLabel struct {
+	x, y float64
+	text string
+}
+
+// chart is laid out once and then rendered as SVG or PNG, so both images
+// show the same geometry.
+type chart struct {
+	width, height float64
+	rects         []chartRect
+	labels        []chartLabel
+}
+
+func getChartColor(severity string) color.RGBA {
+	if fill, ok := chartColors[severity]; ok {
+		return fill
+	}
+	return chartColors[""]
+}
+
+// buildChart lays out a stacked severity timeline over interval wide buckets,
+// with empty buckets kept so gaps show, followed by horizontal bars for the
+// top messages.
+func buildChart(logAnalysis LogAnalysis, interval time.Duration) (result chart) {
+	if interval < bucketResolution {
+		interval = bucketResolution
+	}
+	result.width = chartWidth
+	plotWidth := float64(chartWidth - chartMarginLeft - chartMarginRight)
+
+	severityCounts := make(map[time.Time]map[string]int64)
+	for key, count := range rebucketTimeBucketCounts(logAnalysis.timeBucketCounts, interval) {
+		if severityCounts[key.start] == nil {
+			severityCounts[key.start] = make(map[string]int64)
+		}
+		severity := key.severity
+		if getChartColor(severity) == chartColors[""] {
+			severity = ""
+		}
+		severityCounts[key.start][severity] += count
+	}
+	starts := make([]time.Time, 0, len(severityCounts))
+	for start := range severityCounts {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	result.labels = append(result.labels, chartLabel{x: 10, y: 20, text: "Entries per " + humanizeDuration(interval, reportOptions{}) + " by severity"})
+	timelineBottom := float64(chartTimelineTop + chartTimelineSize)
+	if len(starts) > 0 {
+		first, last := starts[0], starts[len(starts)-1]
+		numBuckets := int(last.Sub(first)/interval) + 1
+		var maxTotal int64
+		for _, counts := range severityCounts {
+			var total int64
+			for _, count := range counts {
+				total += count
+			}
+			if total > maxTotal {
+				maxTotal = total
+			}
+		}
+		barWidth := plotWidth / float64(numBuckets)
+		stackOrder := append(append([]string{}, chartSeverities...), "")
+		for bucket := 0; bucket < numBuckets; bucket++ {
+			counts := severityCounts[first.Add(time.Duration(bucket)*interval)]
+			y := timelineBottom
+			for _, severity := range stackOrder {
+				if counts[severity] == 0 {
+					continue
+				}
+				height := float64(counts[severity]) / float64(maxTotal) * chartTimelineSize
+				y -= height
+				result.rects = append(result.rects, chartRect{
+					x:      chartMarginLeft + float64(bucket)*barWidth,
+					y:      y,
+					width:  barWidth,
+					height: height,
+					fill:   getChartColor(severity),
+				})
+			}
+		}
+		result.labels = append(result.labels,
+			chartLabel{x: 10, y: chartTimelineTop + 12, text: strconv.FormatInt(maxTotal, 10)},
+			chartLabel{x: 10, y: timelineBottom, text: "0"},
+			chartLabel{x: chartMarginLeft, y: timelineBottom + 16, text: first.UTC().Format("2006-01-02 15:04")},
+			chartLabel{x: chartWidth - chartMarginRight - 100, y: timelineBottom + 16, text: last.UTC().Format("2006-01-02 15:04")},
+		)
+	}
+	result.rects = append(result.rects, chartRect{x: chartMarginLeft, y: timelineBottom, width: plotWidth, height: 1, fill: chartTextColor})
+
+	legendY := timelineBottom + 30
+	for index, severity := range chartSeverities {
+		x := float64(chartMarginLeft + index*100)
+		result.rects = append(result.rects, chartRect{x: x, y: legendY - 10, width: 10, height: 10, fill: getChartColor(severity)})
+		result.labels = append(result.labels, chartLabel{x: x + 14, y: legendY, text: severity})
+	}
+
+	messagesTop := legendY + 40
+	result.labels = append(result.labels, chartLabel{x: 10, y: messagesTop, text: "Top messages"})
+	var maxFrequency int64
+	for _, frequency := range logAnalysis.topFiveLogMessageFrequencies {
+		if frequency > maxFrequency {
+			maxFrequency = frequency
+		}
+	}
+	barSpace := plotWidth / 2
+	for index, message := range logAnalysis.topFiveLogMessages {
+		frequency := logAnalysis.topFiveLogMessageFrequencies[index]
+		y := messagesTop + 10 + float64(index*chartRowHeight)
+		width := float64(frequency) / float64(maxFrequency) * barSpace
+		result.rects = append(result.rects, chartRect{x: chartMarginLeft, y: y, width: width, height: chartRowHeight - 6, fill: chartColors["INFO"]})
+		if len([]rune(message)) > chartMessageWidth {
+			message = string([]rune(message)[:chartMessageWidth-3]) + "..."
+		}
+		result.labels = append(result.labels, chartLabel{
+			x:    chartMarginLeft + width + 6,
+			y:    y + chartRowHeight - 10,
+			text: strconv.FormatInt(frequency, 10) + "  " + message,
+		})
+	}
+	result.height = messagesTop + 20 + float64(len(logAnalysis.topFiveLogMessages)*chartRowHeight)
+	return
+}
+
+func formatChartColor(fill color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", fill.R, fill.G, fill.B)
+}
+
+// formatSVGChart renders a standalone SVG with no scripts or external
+// references, so it can be embedded in email and wiki pages.
+func formatSVGChart(result chart) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.0f %.0f\">\n", result.width, result.height, result.width, result.height)
+	fmt.Fprintf(&builder, "<rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n")
+	for _, rect := range result.rects {
+		fmt.Fprintf(&builder, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%s\"/>\n", rect.x, rect.y, rect.width, rect.height, formatChartColor(rect.fill))
+	}
+	for _, label := range result.labels {
+		fmt.Fprintf(&builder, "<text x=\"%.2f\" y=\"%.2f\" font-family=\"sans-serif\" font-size=\"12\" fill=\"%s\">", label.x, label.y, formatChartColor(chartTextColor))
+		xml.EscapeText(&builder, []byte(label.text))
+		builder.WriteString("</text>\n")
+	}
+	builder.WriteString("</svg>\n")
+	return builder.String()
+}
+
+// renderPNGChart rasterizes the chart, drawing its labels in the built-in
+// bitmap font.
+func renderPNGChart(result chart) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(result.width), int(result.height)))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for _, rect := range result.rects {
+		x0, y0 := int(rect.x), int(rect.y)
+		x1, y1 := int(rect.x+rect.width+0.5), int(rect.y+rect.height+0.5)
+		if x1 == x0 {
+			x1++
+		}
+		if y1 == y0 {
+			y1++
+		}
+		draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(rect.fill), image.Point{}, draw.Src)
+	}
+	for _, label := range result.labels {
+		drawChartText(img, int(label.x), int(label.y), label.text, chartTextColor)
+	}
+	return img
+}
+
+// writeChartFile writes PNG for a .png path and SVG otherwise.
+func writeChartFile(chartPath string, logAnalysis LogAnalysis, interval time.Duration, key []byte) error {
+	result := buildChart(logAnalysis, interval)
+	if strings.EqualFold(filepath.Ext(chartPath), ".png") {
+		chartFile, err := createOutputFile(chartPath, key)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(chartFile, renderPNGChart(result)); err != nil {
+			chartFile.Close()
+			return err
+		}
+		return chartFile.Close()
+	}
+	return writeOutputFile(chartPath, []byte(formatSVGChart(result)), key)
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildChart(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:10:00.000", severity: "ERROR", message: "a < b"},
+		{timestamp: "2024-01-01 00:20:00.000", severity: "INFO", message: "a < b"},
+		{timestamp: "2024-01-01 02:05:00.000", severity: "INFO", message: "Started"},
+	}
+	logAnalysis := LogAnalysis{
+		timeBucketCounts:             getTimeBucketCounts(testLogs),
+		topFiveLogMessages:           []string{"a < b", "Started"},
+		topFiveLogMessageFrequencies: []int64{2, 1},
+	}
+
+	result := buildChart(logAnalysis, time.Hour)
+	// 3 stacked segments, the axis, 4 legend swatches and 2 message bars
+	if len(result.rects) != 10 {
+		t.Errorf("Expected 10 shapes in the chart, got %d", len(result.rects))
+	}
+	// Three hour buckets including the empty one, so each bar is a third wide
+	if width := result.rects[0].width; width != float64(chartWidth-chartMarginLeft-chartMarginRight)/3 {
+		t.Errorf("Expected bars a third of the plot wide, got %v", width)
+	}
+
+	svg := formatSVGChart(result)
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, "2  a &lt; b") {
+		t.Errorf("Expected a standalone SVG with escaped labels, got %q", svg)
+	}
+	img := renderPNGChart(result)
+	if bounds := img.Bounds(); bounds.Dx() != chartWidth || bounds.Dy() != int(result.height) {
+		t.Errorf("Expected a %vx%v PNG, got %v", chartWidth, result.height, bounds)
+	}
+	// The title is drawn above the timeline, where there are no shapes
+	var textPixels int
+	for y := 0; y < chartTimelineTop; y++ {
+		for x := 0; x < chartWidth; x++ {
+			if img.RGBAAt(x, y) == chartTextColor {
+				textPixels++
+			}
+		}
+	}
+	if textPixels == 0 {
+		t.Error("Expected the PNG to have labels")
+	}
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	// checkUnknown is for a check that couldn't tell, because the logs
+	// couldn't be read or the plugin is misconfigured
+	checkUnknown = 3
+)
+
+type checkResult struct {
+	status   int
+	errors   int64
+	warnings int64
+	entries  int64
+	// err is why the status is UNKNOWN
+	err error
+}
+
+// getCheckResult counts the errors logged from window before now, or in the
+// whole logs without a window, so a log that stops logging errors recovers
+// once they age out. A log that couldn't be read in full is UNKNOWN, since
+// its count may be missing errors.
+func getCheckResult(logAnalysis LogAnalysis, window time.Duration, warnErrors int64, critErrors int64, now time.Time) (result checkResult) {
+	var windowStart time.Time
+	if window > 0 {
+		windowStart = now.Add(-window)
+	}
+	for key, count := range logAnalysis.timeBucketCounts {
+		if !key.start.Add(bucketResolution).After(windowStart) {
+			continue
+		}
+		result.entries += count
+		switch {
+		case isErrorSeverity(key.severity):
+			result.errors += count
+		case key.severity == "WARNING":
+			result.warnings += count
+		}
+	}
+	switch {
+	case len(logAnalysis.fileErrors) > 0:
+		result.status = checkUnknown
+		result.err = errors.Join(logAnalysis.fileErrors...)
+	case critErrors > 0 && result.errors >= critErrors:
+		result.status = checkCritical
+	case warnErrors > 0 && result.errors >= warnErrors:
+		result.status = checkWarning
+	default:
+		result.status = checkOK
+	}
+	return
+}
+
+func formatCheckResult(result checkResult, window time.Duration, warnErrors int64, critErrors int64) string {
+	if result.status == checkUnknown {
+		return formatCheckUnknown(result.err)
+	}
+	statusNames := map[int]string{checkOK: "OK", checkWarning: "WARNING", checkCritical: "CRITICAL"}
+	scope := "in analyzed logs"
+	if window > 0 {
+		scope = "in last " + window.String()
+	}
+	thresholds := ";"
+	if warnErrors > 0 {
+		thresholds = strconv.FormatInt(warnErrors, 10) + ";"
+	}
+	if critErrors > 0 {
+		thresholds += strconv.FormatInt(critErrors, 10)
+	}
+	return "LOG " + statusNames[result.status] + " - " + strconv.FormatInt(result.errors, 10) + " errors " + scope +
+		" | errors=" + strconv.FormatInt(result.errors, 10) + ";" + thresholds + ";0" +
+		" warnings=" + strconv.FormatInt(result.warnings, 10) + ";;;0" +
+		" entries=" + strconv.FormatInt(result.entries, 10) + ";;;0"
+}
+
+// formatCheckUnknown is the status line of a check that couldn't tell, with
+// the first line of err.
+func formatCheckUnknown(err error) string {
+	message := "see the plugin's stderr"
+	if err != nil {
+		message, _, _ = strings.Cut(err.Error(), "\n")
+	}
+	return "LOG UNKNOWN - " + message
+}
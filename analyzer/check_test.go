@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestGetCheckResult(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:00:00.000", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:00:30.000", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:10:00.000", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:10:30.000", severity: "WARNING"},
+	}
+	var logAnalysis LogAnalysis
+	logAnalysis.timeBucketCounts = getTimeBucketCounts(testLogs)
+	lastEntry := time.Date(2024, 1, 1, 0, 10, 30, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		window     time.Duration
+		now        time.Time
+		wantStatus int
+		wantErrors int64
+	}{
+		{name: "whole log is critical", window: 0, now: lastEntry.Add(24 * time.Hour), wantStatus: checkCritical, wantErrors: 3},
+		{name: "recent window is ok", window: 5 * time.Minute, now: lastEntry, wantStatus: checkOK, wantErrors: 1},
+		{name: "wider window is critical", window: 15 * time.Minute, now: lastEntry, wantStatus: checkCritical, wantErrors: 3},
+		// Errors age out of the window even when nothing is logged after them
+		{name: "stale log recovers", window: 15 * time.Minute, now: lastEntry.Add(time.Hour), wantStatus: checkOK, wantErrors: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getCheckResult(logAnalysis, tt.window, 2, 3, tt.now)
+			if got.status != tt.wantStatus || got.errors != tt.wantErrors {
+				t.Errorf("getCheckResult() = %+v, want status %d with %d errors", got, tt.wantStatus, tt.wantErrors)
+			}
+		})
+	}
+
+	logAnalysis.fileErrors = []error{newFileError("app.log", 0, os.ErrPermission)}
+	if got := getCheckResult(logAnalysis, 0, 2, 3, lastEntry); got.status != checkUnknown {
+		t.Errorf("getCheckResult() with a read error = %+v, want UNKNOWN", got)
+	}
+	if line, want := formatCheckResult(checkResult{status: checkUnknown, err: logAnalysis.fileErrors[0]}, 0, 2, 3), "LOG UNKNOWN - app.log: permission denied"; line != want {
+		t.Errorf("formatCheckResult() = %q, want %q", line, want)
+	}
+
+	line := formatCheckResult(checkResult{status: checkWarning, errors: 2, warnings: 1, entries: 4}, 0, 2, 3)
+	want := "LOG WARNING - 2 errors in analyzed logs | errors=2;2;3;0 warnings=1;;;0 entries=4;;;0"
+	if line != want {
+		t.Errorf("formatCheckResult() = %q, want %q", line, want)
+	}
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeLogReaderChunksMatchWholeFile(t *testing.T) {
+	input := "2024-01-01 08:00:00.000 | INFO | api:serve:10 - Started version 1.2\n" +
+		"2024-01-01 08:00:05.000 | ERROR | db:query:12 - Timeout after 30s\n" +
+		"  at db.query(db.go:12)\n" +
+		"2024-01-01 08:00:03.000 | WARNING | db:query:12 - Slow query from 10.0.0.1\n" +
+		"garbage\n" +
+		"2024-01-01 08:01:00.000 | ERROR | db:query:12 - Timeout after 31s\n" +
+		"2024-01-01 08:02:00.000 | INFO | api:serve:11 - Request from 10.0.0.2 [thread-1]\n" +
+		"2024-01-01 09:00:00.000 | ERROR | api:serve:12 - Handler failed [thread-2]\n" +
+		"2024-01-01 09:30:00.000 | DEBUG | api:serve:13 - Cache warm\n" +
+		"2024-01-01 09:40:00.000 |  | db:query:12 - Timeout after 32s\n"
+	options := analysisOptions{
+		histogramBucket:   time.Hour,
+		messageKeyer:      templateMessageKeyer{},
+		versionPattern:    regexp.MustCompile(`version (\S+)`),
+		threadPattern:     defaultThreadPattern,
+		groupByModule:     true,
+		groupByClientIP:   true,
+		entryStartPattern: defaultEntryStartPattern,
+		badLineSamples:    5,
+		inferSeverity:     true,
+	}
+	report := func(logAnalysis LogAnalysis) string {
+		var output bytes.Buffer
+		if err := writeLogAnalysisJSON(&output, analyzelogAnalyses([]LogAnalysis{logAnalysis}), nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		return output.String()
+	}
+
+	logMessages, unlabeledMessages, stats := parseLogReader(context.Background(), strings.NewReader(input), "app.log", options)
+	want := report(newLogAnalysis("app.log", logMessages, unlabeledMessages, stats, options))
+	for _, chunkEntries := range []int{1, 2, 3} {
+		options.chunkEntries = chunkEntries
+		if got := report(analyzeLogReader(context.Background(), strings.NewReader(input), "app.log", options)); got != want {
+			t.Errorf("analyzeLogReader() with %d entries per chunk = %s, want %s", chunkEntries, got, want)
+		}
+	}
+}
+
+func TestStreamLogReaderFlushesChunks(t *testing.T) {
+	input := strings.Repeat("2024-01-01 08:00:00.000 | INFO | api:serve:10 - Started\n", 5)
+	var chunkSizes []int
+	stats := streamLogReader(context.Background(), strings.NewReader(input), "app.log", analysisOptions{}, 2, func(logMessages []LogMessage, _ []LogMessage) {
+		chunkSizes = append(chunkSizes, len(logMessages))
+	})
+	if len(chunkSizes) != 3 || chunkSizes[0] != 2 || chunkSizes[1] != 2 || chunkSizes[2] != 1 || stats.lines != 5 {
+		t.Errorf("streamLogReader() flushed %v of %d lines, want [2 2 1] of 5", chunkSizes, stats.lines)
+	}
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// severityModel is a multinomial naive Bayes model over message tokens. Its
+// counts are additive, so models trained per file merge into one for the
+// whole corpus.
+type severityModel struct {
+	classCounts map[string]int64
+	tokenCounts map[string]map[string]int64
+	tokenTotals map[string]int64
+	vocabulary  map[string]struct{}
+}
+
+func newSeverityModel() *severityModel {
+	return &severityModel{
+		classCounts: make(map[string]int64),
+		tokenCounts: make(map[string]map[string]int64),
+		tokenTotals: make(map[string]int64),
+		vocabulary:  make(map[string]struct{}),
+	}
+}
+
+func tokenizeMessage(logMessage LogMessage) []string {
+	text := strings.ToLower(logMessage.module + " " + logMessage.message)
+	return strings.FieldsFunc(text, func(character rune) bool {
+		return !unicode.IsLetter(character)
+	})
+}
+
+func trainSeverityModel(logMessages []LogMessage) (model *severityModel) {
+	model = newSeverityModel()
+	for _, logMessage := range logMessages {
+		severity := logMessage.severity
+		model.classCounts[severity] += 1
+		if model.tokenCounts[severity] == nil {
+			model.tokenCounts[severity] = make(map[string]int64)
+		}
+		for _, token := range tokenizeMessage(logMessage) {
+			model.tokenCounts[severity][token] += 1
+			model.tokenTotals[severity] += 1
+			model.vocabulary[token] = struct{}{}
+		}
+	}
+	return
+}
+
+func (model *severityModel) merge(other *severityModel) {
+	for severity, count := range other.classCounts {
+		model.classCounts[severity] += count
+	}
+	for severity, tokens := range other.tokenCounts {
+		if model.tokenCounts[severity] == nil {
+			model.tokenCounts[severity] = make(map[string]int64)
+		}
+		for token, count := range tokens {
+			model.tokenCounts[severity][token] += count
+		}
+	}
+	for severity, total := range other.tokenTotals {
+		model.tokenTotals[severity] += total
+	}
+	for token := range other.vocabulary {
+		model.vocabulary[token] = struct{}{}
+	}
+}
+
+func (model *severityModel) predict(logMessage LogMessage) string {
+	var totalClasses int64
+	severities := make([]string, 0, len(model.classCounts))
+	for severity, count := range model.classCounts {
+		totalClasses += count
+		severities = append(severities, severity)
+	}
+	// Sorted so ties resolve the same way on every run
+	sort.Strings(severities)
+	tokens := tokenizeMessage(logMessage)
+	vocabularySize := float64(len(model.vocabulary))
+	bestSeverity := ""
+	bestScore := math.Inf(-1)
+	for _, severity := range severities {
+		score := math.Log(float64(model.classCounts[severity]) / float64(totalClasses))
+		for _, token := range tokens {
+			// Laplace smoothing keeps unseen tokens from zeroing a class out
+			score += math.Log((float64(model.tokenCounts[severity][token]) + 1) / (float64(model.tokenTotals[severity]) + vocabularySize))
+		}
+		if score > bestScore {
+			bestScore = score
+			bestSeverity = severity
+		}
+	}
+	return bestSeverity
+}
+
+func inferSeverities(model *severityModel, unlabeledMessages []LogMessage) (inferredMessages []LogMessage) {
+	if len(model.classCounts) == 0 {
+		return
+	}
+	for _, logMessage := range unlabeledMessages {
+		logMessage.severity = model.predict(logMessage)
+		inferredMessages = append(inferredMessages, logMessage)
+	}
+	return
+}
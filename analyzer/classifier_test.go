@@ -0,0 +1,32 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestInferSeverities(t *testing.T) {
+	firstFile := []LogMessage{
+		{severity: "ERROR", module: "db", message: "Connection refused by database"},
+		{severity: "ERROR", module: "db", message: "Query failed with timeout"},
+		{severity: "INFO", module: "web", message: "Request served successfully"},
+	}
+	secondFile := []LogMessage{
+		{severity: "INFO", module: "web", message: "User logged in successfully"},
+		{severity: "WARNING", module: "cache", message: "Cache nearly full"},
+	}
+	model := trainSeverityModel(firstFile)
+	model.merge(trainSeverityModel(secondFile))
+
+	unlabeled := []LogMessage{
+		{module: "db", message: "Connection timeout"},
+		{module: "web", message: "Request served"},
+	}
+	got := inferSeverities(model, unlabeled)
+	if len(got) != 2 || got[0].severity != "ERROR" || got[1].severity != "INFO" {
+		t.Errorf("inferSeverities() = %+v, want ERROR then INFO", got)
+	}
+
+	if got := inferSeverities(newSeverityModel(), unlabeled); len(got) != 0 {
+		t.Errorf("inferSeverities() with an untrained model = %+v, want none", got)
+	}
+}
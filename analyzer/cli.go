@@ -0,0 +1,594 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Main runs the command line tool on os.Args, exiting with its status code.
+// reportFormats are the values -format takes.
+var reportFormats = []string{"text", "json", "csv", "ndjson"}
+
+func Main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "pretty":
+			runPretty(os.Args[2:])
+			return
+		case "convert":
+			runConvert(os.Args[2:])
+			return
+		case "verify-audit":
+			runVerifyAudit(os.Args[2:])
+			return
+		case "decrypt":
+			runDecrypt(os.Args[2:])
+			return
+		case "index":
+			runIndex(os.Args[2:])
+			return
+		case "lines":
+			runLines(os.Args[2:])
+			return
+		}
+	}
+
+	budgetPath := flag.String("budgets", "", "JSON file defining monthly error budgets per module")
+	budgetStatePath := flag.String("budget-state", "budget_state.json", "file tracking error budget consumption across runs")
+	statsdAddress := flag.String("statsd", "", "statsd host:port to push severity gauges to")
+	graphiteAddress := flag.String("graphite", "", "graphite host:port to push severity gauges to")
+	metricPrefix := flag.String("metric-prefix", "log_analyzer", "prefix for statsd/graphite metric names")
+	influxDestination := flag.String("influx-out", "", "file path or HTTP write URL for InfluxDB line protocol output")
+	influxInterval := flag.Duration("influx-interval", time.Minute, "bucket width for InfluxDB line protocol output")
+	check := flag.Bool("check", false, "run as a Nagios/Icinga plugin and print a single status line")
+	checkWindow := flag.Duration("check-window", 0, "only count entries logged within this long before now in check mode")
+	warnErrors := flag.Int64("warn-errors", 0, "error count at which check mode reports WARNING")
+	critErrors := flag.Int64("crit-errors", 0, "error count at which check mode reports CRITICAL")
+	timeSeriesPath := flag.String("timeseries-csv", "", "write entry and severity counts per period to this CSV file")
+	timeSeriesPeriod := flag.String("timeseries-period", "day", "period for -timeseries-csv: hour, day, week, month or a duration like 5m")
+	histogramBucket := flag.Duration("bucket", 0, "report entry counts per bucket of this width, like 5m or 1h, as a histogram")
+	spikeInterval := flag.Duration("spikes", 0, "flag intervals of this width, like 5m, whose error rate spikes")
+	spikeRate := flag.Float64("spike-rate", 0, "with -spikes, flag intervals whose error rate is above this fraction, like 0.2")
+	spikeFactor := flag.Float64("spike-factor", 3, "with -spikes, flag intervals whose error rate is this many times the baseline's")
+	spikeBaseline := flag.Int("spike-baseline", 6, "with -spikes, number of preceding intervals the baseline error rate covers")
+	queries := flag.Bool("queries", false, "report slow queries by normalized statement, their time distribution and lock waits; on for the mysql-slow and postgres presets")
+	httpRequests := flag.Bool("http", false, "report status codes, top URLs, top clients and the request rate over time; on for the access, w3c and iis presets")
+	gc := flag.Bool("gc", false, "report JVM GC pause percentiles, allocation rate and full GCs over time; on for the jvm-gc preset")
+	histogramBySeverity := flag.Bool("bucket-severity", false, "also show per-severity counts in the -bucket histogram")
+	inferSeverity := flag.Bool("infer-severity", false, "predict severities for entries missing one from the labeled entries")
+	grep := flag.String("grep", "", "keep only entries whose message matches this regex, like 'timeout|refused'")
+	fuzzyGrep := flag.String("fuzzy-grep", "", "keep only entries whose message has every word of this text, allowing a typo or two per word, like 'databse conection'")
+	excludeGrep := flag.String("exclude-grep", "", "drop entries whose message matches this regex")
+	lineFilterText := flag.String("line-filter", "", "skip raw lines before parsing unless they have every word or \"quoted text\" and match every /regex/; ! negates a term")
+	minSeverity := flag.String("min-severity", "", "drop entries below this severity (TRACE, DEBUG, INFO, NOTICE, WARNING, ERROR, CRITICAL or FATAL) before analysis")
+	since := flag.String("since", "", "drop entries before this time: a timestamp or a duration back from now such as 1h")
+	until := flag.String("until", "", "drop entries at or after this time: a timestamp or a duration back from now")
+	internMessages := flag.Bool("intern-messages", false, "also intern message text, for corpora with few distinct messages")
+	showStats := flag.Bool("stats", false, "print internal processing counters to stderr when done")
+	showProgress := flag.Bool("progress", false, "show the bytes and files analyzed so far and an ETA on stderr while running")
+	traceURLTemplate := flag.String("trace-url-template", "", "tracing backend URL for top message traces, with {traceId} as placeholder")
+	versionPattern := flag.String("version-pattern", "", "regex whose last capture group extracts the application version from messages")
+	threads := flag.Bool("threads", false, "report entry and error counts per thread, goroutine or process ID")
+	groupBy := flag.String("group-by", "", "break the report down by module, client ip, or both as module,ip")
+	ipv4Prefix := flag.Int("ip-prefix", 32, "aggregate IPv4 clients by this CIDR prefix length with -group-by ip")
+	ipv6Prefix := flag.Int("ip6-prefix", 128, "aggregate IPv6 clients by this CIDR prefix length with -group-by ip")
+	baselinePath := flag.String("baseline", "", "previous -format json report to compare the top messages with, reported as a stability score")
+	ipBaselinePath := flag.String("ip-baseline", "", "file of known client IPs or CIDRs, one per line; clients outside it are reported as new")
+	threadPattern := flag.String("thread-pattern", "", "regex whose last capture group extracts the thread ID from messages; implies -threads")
+	restarts := flag.Bool("restarts", false, "detect process restarts from changing PIDs and report the errors just before them")
+	boots := flag.Bool("boots", false, "report each boot's duration, error count and how it ended, with the last messages before unclean shutdowns; on for the journal preset")
+	multiline := flag.Bool("multiline", false, "append lines that fail to parse and don't start with a timestamp, like stack traces, to the entry before them")
+	multilineStart := flag.String("multiline-start", "", "regex matching the first line of every entry, for -multiline; implies -multiline")
+	transitions := flag.Bool("transitions", false, "report how often each severity follows another within a module")
+	alertRulesPath := flag.String("alert-rules", "", "JSON file of per-module alert rules")
+	pattern := flag.String("pattern", "", "custom input format: a regex with named groups or a template like '{timestamp} [{severity}] {message}'")
+	perFile := flag.Bool("per-file", false, "also report each file's analysis next to the merged one")
+	ageTiers := flag.Bool("age-tiers", false, "break severities down by file age: today, the last 7 days or older, by each file's latest entry")
+	format := flag.String("format", "text", "report format: text, json, csv or ndjson, which also writes each file's analysis as it finishes")
+	progressInterval := flag.Duration("progress-interval", 30*time.Second, "with -format ndjson, how often to also write the merged analysis of the files finished so far; 0 turns it off")
+	preset := flag.String("preset", "native", "input log format: native, log4j, python, slog-text, slog-json, zap, access, auth, cef, leef, w3c, iis, gelf, mysql-slow, postgres, jvm-gc, logcat, ios, journal, json or syslog")
+	timeFormatName := flag.String("time-format", "", "timestamp layout of the native format or -pattern: auto to detect ISO 8601, epoch seconds or millis, zone offsets and more; iso8601; epoch; or a Go layout like 02.01.2006 15:04:05")
+	timezone := flag.String("timezone", "", "IANA zone, like Europe/Berlin or Local, that report times are shown in and that native and -pattern timestamps without a zone are in; UTC by default")
+	jsonFields := flag.String("json-fields", "", "with -preset json, the keys of entry fields, like timestamp=ts,severity=level|lvl,message=msg")
+	messageKey := flag.String("message-key", "", "what messages are counted and ranked by: raw, template, or fields: with a comma-separated list of message, template, module, function, line and severity to combine; raw examples are shown for keys that aren't the message")
+	messageTemplates := flag.Bool("templates", false, "short for -message-key template: rank messages with numbers, IPs, UUIDs and hex IDs replaced by placeholders like <num>")
+	aggregatesOnly := flag.Bool("aggregates-only", false, "replace message text, query fingerprints, request paths and malformed lines in every output with IDs hashed from them, to share statistics without log content")
+	exact := flag.Bool("exact", false, "print exact counts, sizes and durations instead of humanized values")
+	foldedPath := flag.String("folded-out", "", "write per-module volume over time as collapsed stacks for flame graph viewers")
+	foldedInterval := flag.Duration("folded-interval", time.Hour, "time bucket width for -folded-out")
+	chartPath := flag.String("chart-out", "", "write a severity timeline and top message chart as SVG, or PNG for a .png path")
+	chartInterval := flag.Duration("chart-interval", time.Hour, "time bucket width for -chart-out")
+	uploadDestination := flag.String("upload", "", "upload the JSON analysis to an s3://, gs:// or HTTP(S) destination; {date}, {time} and {label} are filled in")
+	uploadLabel := flag.String("upload-label", "", "value for the {label} placeholder of -upload")
+	encryptKeyPath := flag.String("encrypt-key", "", "encrypt the json, csv or ndjson report, every file the analysis writes and the -upload payload with the 32 byte key in this file; read them back with decrypt")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "number of files to analyze concurrently")
+	follow := flag.Bool("follow", false, "keep reading appended lines, like tail -F, and re-render the report as they arrive")
+	followInterval := flag.Duration("follow-interval", 2*time.Second, "how often -follow polls for new lines")
+	gelfAddress := flag.String("gelf-udp", "", "receive GELF messages on this UDP address, like :12201, and re-render the report as they arrive")
+	mtimeSince := flag.Duration("mtime-since", 0, "skip files not modified within this duration")
+	timeout := flag.Duration("timeout", 0, "stop the analysis after this long; 0 means no limit")
+	strict := flag.Bool("strict", false, "exit 3 after reporting when the share of malformed lines is above -max-malformed")
+	maxMalformed := flag.Float64("max-malformed", 0, "with -strict, the largest allowed fraction of malformed lines, like 0.01")
+	showBadLines := flag.Int("show-bad-lines", 0, "show up to this many malformed lines per file with their line numbers")
+	partial := flag.Bool("partial", false, "report what was analyzed so far when interrupted or timed out instead of failing")
+	var excludes stringListFlag
+	flag.Var(&excludes, "exclude", "skip inputs matching this glob, by full path or base name; may be repeated")
+	var windows stringListFlag
+	flag.Var(&windows, "window", "named daily UTC window to compare, like incident=02:00-03:00; may be repeated")
+	auditPath := flag.String("audit-log", "", "append who ran this analysis, with which flags over which inputs, to this hash-chained file")
+	configPath := flag.String("config", "", "YAML or TOML file of flag values and inputs; flags given on the command line take precedence")
+	flag.Parse()
+	// Nagios reads a plugin's other statuses as a verdict on the logs, so in
+	// check mode a run that fails before it can count is UNKNOWN
+	exit := func(status int) {
+		if *check && status != exitOK {
+			fmt.Println(formatCheckUnknown(nil))
+			status = checkUnknown
+		}
+		os.Exit(status)
+	}
+
+	inputs := flag.Args()
+	if *configPath != "" {
+		settings, err := loadConfigFile(*configPath)
+		if err == nil {
+			var configInputs []string
+			configInputs, err = applyConfig(flag.CommandLine, settings)
+			if len(inputs) == 0 {
+				inputs = configInputs
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading config:", err)
+			exit(exitUsage)
+		}
+	}
+	// Checked before anything is analyzed, since alerts, budget state and
+	// other outputs are written before the report
+	if !slices.Contains(reportFormats, *format) {
+		fmt.Fprintln(os.Stderr, "Unknown format:", *format)
+		exit(exitUsage)
+	}
+	if *showProgress && (*follow || *gelfAddress != "") {
+		fmt.Fprintln(os.Stderr, "-progress can't be used with -follow or -gelf-udp")
+		exit(exitUsage)
+	}
+	var encryptionKey []byte
+	if *encryptKeyPath != "" {
+		if err := checkEncryptedReport(*format, *follow || *gelfAddress != ""); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+		key, err := loadEncryptionKey(*encryptKeyPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+		encryptionKey = key
+		// Appending to an encrypted file would need its key on every run, so
+		// alerts can only go where they aren't stored
+		if *alertRulesPath != "" {
+			alertRules, err := loadAlertRules(*alertRulesPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error loading alert rules:", err)
+				exit(exitUsage)
+			}
+			if alertFiles := alertRules.getAlertFiles(); len(alertFiles) > 0 {
+				fmt.Fprintln(os.Stderr, "-encrypt-key can't encrypt alerts appended to "+strings.Join(alertFiles, ", ")+"; send them to stderr, stdout or a URL")
+				exit(exitUsage)
+			}
+		}
+	}
+	expandedLogPaths, err := expandLogPaths(inputs, excludes)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error expanding inputs:", err)
+		exit(exitUsage)
+	}
+	logPaths, duplicateLogPaths := dedupeLogPaths(expandedLogPaths)
+	for _, duplicateLogPath := range duplicateLogPaths {
+		fmt.Fprintln(os.Stderr, "Skipping duplicate input:", duplicateLogPath)
+	}
+	if *mtimeSince > 0 {
+		var staleLogPaths []string
+		logPaths, staleLogPaths = filterLogPathsByModTime(logPaths, *mtimeSince, time.Now())
+		if len(staleLogPaths) > 0 {
+			fmt.Fprintln(os.Stderr, "Skipped "+strconv.Itoa(len(staleLogPaths))+" files not modified in the last "+mtimeSince.String())
+		}
+	}
+	timestampFormat, err := newTimeFormat(*timeFormatName, *timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(exitUsage)
+	}
+	parser, err := resolveLineParser(*preset, *pattern, timestampFormat)
+	if err == nil && *pattern == "" && *preset == "json" && *jsonFields != "" {
+		parser, err = JSONParser(*jsonFields)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(exitUsage)
+	}
+	if *messageTemplates {
+		if *messageKey != "" && *messageKey != "template" {
+			fmt.Fprintln(os.Stderr, "-templates conflicts with -message-key "+*messageKey)
+			exit(exitUsage)
+		}
+		*messageKey = "template"
+	}
+	var messageKeyer MessageKeyer
+	if *messageKey != "" {
+		messageKeyer, err = NewMessageKeyer(*messageKey)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+	}
+	options := analysisOptions{
+		inferSeverity:       *inferSeverity,
+		internMessages:      *internMessages,
+		parser:              parser,
+		detectRestarts:      *restarts,
+		bootSessions:        *boots || (*pattern == "" && *preset == "journal"),
+		severityTransitions: *transitions,
+		workers:             *workers,
+		histogramBucket:     *histogramBucket,
+		badLineSamples:      *showBadLines,
+		messageKeyer:        messageKeyer,
+		aggregatesOnly:      *aggregatesOnly,
+		queryProfile:        *queries || (*pattern == "" && (*preset == "mysql-slow" || *preset == "postgres")),
+		gcProfile:           *gc || (*pattern == "" && *preset == "jvm-gc"),
+		httpProfile:         *httpRequests || (*pattern == "" && (*preset == "access" || *preset == "w3c" || *preset == "iis")),
+		spikeOptions: spikeOptions{
+			interval:        *spikeInterval,
+			maxErrorRate:    *spikeRate,
+			baselineFactor:  *spikeFactor,
+			baselineBuckets: *spikeBaseline,
+		},
+	}
+	if newParser, ok := parserFactories[*preset]; ok && *pattern == "" {
+		options.newParser = newParser
+	}
+	if *multilineStart != "" {
+		compiledMultilineStart, err := regexp.Compile(*multilineStart)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid multiline start pattern:", err)
+			exit(exitUsage)
+		}
+		options.entryStartPattern = compiledMultilineStart
+	} else if *multiline {
+		options.entryStartPattern = defaultEntryStartPattern
+	}
+	if *minSeverity != "" {
+		options.minSeverity = normalizeSeverity(*minSeverity)
+		if _, ok := severityRanks[options.minSeverity]; !ok {
+			fmt.Fprintln(os.Stderr, "Unknown minimum severity:", *minSeverity)
+			exit(exitUsage)
+		}
+	}
+	if *grep != "" {
+		if options.grep, err = regexp.Compile(*grep); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid grep pattern:", err)
+			exit(exitUsage)
+		}
+	}
+	if *lineFilterText != "" {
+		if options.newParser != nil {
+			fmt.Fprintln(os.Stderr, "-line-filter can't be used with -preset "+*preset+", which has to read every line")
+			exit(exitUsage)
+		}
+		if options.lineFilter, err = parseLineFilter(*lineFilterText); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid line filter:", err)
+			exit(exitUsage)
+		}
+	}
+	if *fuzzyGrep != "" {
+		query := newFuzzyQuery(*fuzzyGrep)
+		options.fuzzyGrep = &query
+	}
+	if *excludeGrep != "" {
+		if options.excludeGrep, err = regexp.Compile(*excludeGrep); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid exclude-grep pattern:", err)
+			exit(exitUsage)
+		}
+	}
+	if *since != "" {
+		if options.since, err = parseTimeBound(*since, time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+	}
+	if *until != "" {
+		if options.until, err = parseTimeBound(*until, time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+	}
+	for _, window := range windows {
+		namedWindow, err := parseNamedWindow(window)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(exitUsage)
+		}
+		options.windows = append(options.windows, namedWindow)
+	}
+	if *versionPattern != "" {
+		compiledVersionPattern, err := regexp.Compile(*versionPattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid version pattern:", err)
+			exit(exitUsage)
+		}
+		options.versionPattern = compiledVersionPattern
+	}
+	for _, field := range strings.Split(*groupBy, ",") {
+		switch strings.TrimSpace(field) {
+		case "":
+		case "module":
+			options.groupByModule = true
+		case "ip":
+			options.groupByClientIP = true
+		default:
+			fmt.Fprintln(os.Stderr, "Unknown -group-by field:", field)
+			exit(exitUsage)
+		}
+	}
+	if *ipv4Prefix < 0 || *ipv4Prefix > 32 || *ipv6Prefix < 0 || *ipv6Prefix > 128 {
+		fmt.Fprintln(os.Stderr, "Invalid -ip-prefix or -ip6-prefix, expected 0-32 and 0-128")
+		exit(exitUsage)
+	}
+	if *ageTiers {
+		options.ageTiers = &ageTierOptions{now: time.Now(), location: timestampFormat.location}
+	}
+	options.clientIPGrouping = clientIPGrouping{ipv4Bits: *ipv4Prefix, ipv6Bits: *ipv6Prefix}
+	if *baselinePath != "" {
+		baseline, err := loadTopMessageBaseline(*baselinePath, encryptionKey)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading baseline:", err)
+			exit(exitUsage)
+		}
+		options.topMessageBaseline = &baseline
+	}
+	if *ipBaselinePath != "" {
+		baseline, err := loadClientIPBaseline(*ipBaselinePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading IP baseline:", err)
+			exit(exitUsage)
+		}
+		options.clientIPBaseline = baseline
+	}
+	if *threadPattern != "" {
+		compiledThreadPattern, err := regexp.Compile(*threadPattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid thread pattern:", err)
+			exit(exitUsage)
+		}
+		options.threadPattern = compiledThreadPattern
+	} else if *threads {
+		options.threadPattern = defaultThreadPattern
+	}
+	reporting := reportOptions{exact: *exact, traceURLTemplate: *traceURLTemplate, histogramBySeverity: *histogramBySeverity, location: timestampFormat.location}
+	if *auditPath != "" {
+		mode := "batch"
+		if *gelfAddress != "" {
+			mode = "gelf-udp"
+		} else if *follow {
+			mode = "follow"
+		}
+		// An analysis that can't be audited doesn't run
+		if err := appendAuditRecord(*auditPath, newAuditRecord(flag.CommandLine, os.Args[1:], mode, logPaths, time.Now())); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing audit log:", err)
+			exit(exitFailure)
+		}
+	}
+	if *gelfAddress != "" {
+		runLive(reporting, *alertRulesPath, func(ctx context.Context, update func(LogAnalysis)) error {
+			return listenGELF(ctx, *gelfAddress, options, *followInterval, update)
+		})
+		return
+	}
+	if *follow {
+		runFollow(logPaths, options, reporting, *followInterval, *alertRulesPath)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Once the first signal has cancelled the analysis, restore the default
+	// handling so that a second one kills a run that is slow to stop
+	context.AfterFunc(ctx, stop)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	var report io.Writer = os.Stdout
+	var encryptingReport io.WriteCloser
+	if encryptionKey != nil && !*check {
+		if encryptingReport, err = newEncryptingWriter(os.Stdout, encryptionKey); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encrypting report:", err)
+			exit(exitFailure)
+		}
+		report = encryptingReport
+	}
+	var ndjson *ndjsonWriter
+	if *format == "ndjson" {
+		ndjson = newNDJSONWriter(report, len(logPaths), *progressInterval)
+		options.fileAnalyzed = ndjson.addFile
+	}
+	var progress *progressReporter
+	if *showProgress {
+		progress = newProgressReporter(os.Stderr, isTerminal(os.Stderr), logPaths)
+		progress.start()
+	}
+	logAnalyses := collectLogAnalyses(ctx, logPaths, options)
+	if progress != nil {
+		progress.stop()
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Analysis stopped:", context.Cause(ctx))
+		if !*partial || len(logAnalyses) == 0 {
+			exit(exitFailure)
+		}
+	}
+	logAnalysis := analyzelogAnalyses(logAnalyses)
+	for _, err := range logAnalysis.fileErrors {
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
+	}
+	for _, warning := range logAnalysis.dataQualityWarnings {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+	if *showStats {
+		printStats(os.Stderr, metrics.snapshot())
+	}
+	if *check {
+		result := getCheckResult(logAnalysis, *checkWindow, *warnErrors, *critErrors, time.Now())
+		fmt.Println(formatCheckResult(result, *checkWindow, *warnErrors, *critErrors))
+		os.Exit(result.status)
+	}
+
+	var firedAlerts int
+	if *alertRulesPath != "" {
+		alertRules, err := loadAlertRules(*alertRulesPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading alert rules:", err)
+			exit(exitUsage)
+		}
+		var suppressedAlerts int
+		firedAlerts, suppressedAlerts, err = evaluateAlertRules(alertRules, logAnalysis.timeBucketCounts, time.Now())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error sending alerts:", err)
+		}
+		if suppressedAlerts > 0 {
+			fmt.Fprintln(os.Stderr, "Suppressed "+strconv.Itoa(suppressedAlerts)+" alerts during suppression windows")
+		}
+	}
+
+	var budgetReport []budgetReportRow
+	if *budgetPath != "" {
+		budgetReport, err = trackErrorBudgets(*budgetPath, *budgetStatePath, logAnalyses, encryptionKey)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error tracking error budgets:", err)
+			exit(exitFailure)
+		}
+	}
+	var fileLogAnalyses []LogAnalysis
+	if *perFile {
+		fileLogAnalyses = getFileLogAnalyses(logPaths, logAnalyses)
+	}
+	switch *format {
+	case "json":
+		if err := writeLogAnalysisJSON(report, logAnalysis, fileLogAnalyses, budgetReport); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing JSON report:", err)
+			exit(exitFailure)
+		}
+	case "csv":
+		if err := writeLogAnalysisCSV(report, logAnalysis, fileLogAnalyses); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing CSV report:", err)
+			exit(exitFailure)
+		}
+	case "ndjson":
+		if err := ndjson.writeSummary(logAnalysis, fileLogAnalyses, budgetReport); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing NDJSON report:", err)
+			exit(exitFailure)
+		}
+	case "text":
+		for _, fileLogAnalysis := range fileLogAnalyses {
+			fmt.Println("=== " + fileLogAnalysis.logPath + " ===")
+			printLogAnalysis(fileLogAnalysis, reporting)
+			fmt.Println()
+		}
+		if *perFile {
+			fmt.Println("=== All files ===")
+		}
+		printLogAnalysis(logAnalysis, reporting)
+		if *budgetPath != "" {
+			printBudgetReport(budgetReport)
+		}
+	}
+	if encryptingReport != nil {
+		if err := encryptingReport.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error encrypting report:", err)
+			exit(exitFailure)
+		}
+	}
+
+	if *statsdAddress != "" {
+		if err := sendStatsdGauges(*statsdAddress, *metricPrefix, getSeverityGauges(logAnalysis)); err != nil {
+			fmt.Fprintln(os.Stderr, "Error sending statsd gauges:", err)
+		}
+	}
+	if *graphiteAddress != "" {
+		if err := sendGraphiteMetrics(*graphiteAddress, *metricPrefix, getSeverityGauges(logAnalysis), time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, "Error sending graphite metrics:", err)
+		}
+	}
+	if *influxDestination != "" {
+		if err := writeInfluxLineProtocol(*influxDestination, logAnalysis.timeBucketCounts, *influxInterval, encryptionKey); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing InfluxDB line protocol:", err)
+		}
+	}
+	if *timeSeriesPath != "" {
+		if err := writeTimeSeriesCSVFile(*timeSeriesPath, logAnalysis.timeBucketCounts, *timeSeriesPeriod, encryptionKey); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing time series CSV:", err)
+		}
+	}
+	if *foldedPath != "" {
+		if err := writeFoldedStacksFile(*foldedPath, logAnalysis.timeBucketCounts, *foldedInterval, encryptionKey); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing folded stacks:", err)
+		}
+	}
+	if *chartPath != "" {
+		if err := writeChartFile(*chartPath, logAnalysis, *chartInterval, encryptionKey); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing chart:", err)
+		}
+	}
+	if *uploadDestination != "" {
+		var payload bytes.Buffer
+		err := writeLogAnalysisJSON(&payload, logAnalysis, fileLogAnalyses, budgetReport)
+		data, contentType := payload.Bytes(), "application/json"
+		if err == nil && encryptionKey != nil {
+			data, err = encryptBytes(data, encryptionKey)
+			contentType = "application/octet-stream"
+		}
+		if err == nil {
+			now := time.Now()
+			err = uploadAnalysis(formatUploadDestination(*uploadDestination, *uploadLabel, now), data, contentType, now)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error uploading analysis:", err)
+		}
+	}
+	exitStatus := exitOK
+	if *strict {
+		if err := checkMalformedRatio(logAnalysis, *maxMalformed); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitStatus = exitThreshold
+		}
+	}
+	if firedAlerts > 0 {
+		fmt.Fprintln(os.Stderr, "Fired "+strconv.Itoa(firedAlerts)+" alerts")
+		exitStatus = exitThreshold
+	}
+	if exhausted := countExhaustedBudgets(budgetReport); exhausted > 0 {
+		fmt.Fprintln(os.Stderr, "Exhausted "+strconv.Itoa(exhausted)+" error budgets")
+		exitStatus = exitThreshold
+	}
+	// A file that wasn't read in full makes any threshold unreliable
+	if len(logAnalysis.fileErrors) > 0 {
+		exitStatus = exitFailure
+	}
+	if exitStatus != exitOK {
+		os.Exit(exitStatus)
+	}
+}
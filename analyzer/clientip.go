@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"bufio"
+	"errors"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+const maxReportedClientIPs = 10
+
+type clientIPCount struct {
+	entries int64
+	errors  int64
+}
+
+// clientIPGrouping controls how client addresses are aggregated: addresses
+// are masked to ipv4Bits or ipv6Bits, so 24 groups IPv4 clients by /24.
+type clientIPGrouping struct {
+	ipv4Bits int
+	ipv6Bits int
+}
+
+// getClientIPKey returns the address itself when grouping keeps every bit and
+// the masked CIDR otherwise. Unparseable addresses, such as the "-" of a
+// proxied request, give "".
+func getClientIPKey(clientIP string, grouping clientIPGrouping) string {
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := grouping.ipv4Bits
+	if addr.Is6() {
+		bits = grouping.ipv6Bits
+	}
+	if bits >= addr.BitLen() {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+func getClientIPCounts(logMessages []LogMessage, grouping clientIPGrouping) (clientIPCounts map[string]clientIPCount) {
+	clientIPCounts = make(map[string]clientIPCount)
+	for _, logMessage := range logMessages {
+		key := getClientIPKey(logMessage.clientIP, grouping)
+		if key == "" {
+			continue
+		}
+		count := clientIPCounts[key]
+		count.entries += 1
+		if isErrorSeverity(logMessage.severity) {
+			count.errors += 1
+		}
+		clientIPCounts[key] = count
+	}
+	return
+}
+
+func mergeClientIPCounts(into map[string]clientIPCount, from map[string]clientIPCount) {
+	for clientIP, count := range from {
+		merged := into[clientIP]
+		merged.entries += count.entries
+		merged.errors += count.errors
+		into[clientIP] = merged
+	}
+}
+
+// getTopClientIPs ranks clients by entries, or by errors when byErrors is
+// set, leaving out clients with none, and keeps maxReportedClientIPs of them.
+func getTopClientIPs(clientIPCounts map[string]clientIPCount, byErrors bool) (clientIPs []string) {
+	value := func(clientIP string) int64 {
+		if byErrors {
+			return clientIPCounts[clientIP].errors
+		}
+		return clientIPCounts[clientIP].entries
+	}
+	for clientIP := range clientIPCounts {
+		if value(clientIP) > 0 {
+			clientIPs = append(clientIPs, clientIP)
+		}
+	}
+	sort.Slice(clientIPs, func(i, j int) bool {
+		if value(clientIPs[i]) != value(clientIPs[j]) {
+			return value(clientIPs[i]) > value(clientIPs[j])
+		}
+		return clientIPs[i] < clientIPs[j]
+	})
+	if len(clientIPs) > maxReportedClientIPs {
+		clientIPs = clientIPs[:maxReportedClientIPs]
+	}
+	return
+}
+
+// loadClientIPBaseline reads known addresses or CIDRs, one per line, with
+// blank lines and # comments ignored.
+func loadClientIPBaseline(baselinePath string) (baseline []netip.Prefix, err error) {
+	baselineFile, err := os.Open(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+	defer baselineFile.Close()
+	baseline = []netip.Prefix{}
+	scanner := bufio.NewScanner(baselineFile)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		prefix, err := parseClientIPPrefix(line)
+		if err != nil {
+			return nil, errors.New("bad baseline entry " + line)
+		}
+		baseline = append(baseline, prefix)
+	}
+	return baseline, scanner.Err()
+}
+
+func parseClientIPPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// getNewClientIPs returns the clients, busiest first, that no baseline entry
+// covers. An aggregated CIDR counts as known only when a baseline entry
+// contains all of it.
+func getNewClientIPs(clientIPCounts map[string]clientIPCount, baseline []netip.Prefix) (clientIPs []string) {
+	for clientIP := range clientIPCounts {
+		prefix, err := parseClientIPPrefix(clientIP)
+		if err != nil {
+			continue
+		}
+		known := false
+		for _, knownPrefix := range baseline {
+			if knownPrefix.Bits() <= prefix.Bits() && knownPrefix.Contains(prefix.Addr()) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			clientIPs = append(clientIPs, clientIP)
+		}
+	}
+	sort.Slice(clientIPs, func(i, j int) bool {
+		if clientIPCounts[clientIPs[i]].entries != clientIPCounts[clientIPs[j]].entries {
+			return clientIPCounts[clientIPs[i]].entries > clientIPCounts[clientIPs[j]].entries
+		}
+		return clientIPs[i] < clientIPs[j]
+	})
+	return
+}
+
+func formatClientIPCount(clientIP string, count clientIPCount, options reportOptions) string {
+	return clientIP + ": " + humanizeCount(count.entries, options) + " entries, " + humanizeCount(count.errors, options) + " errors"
+}
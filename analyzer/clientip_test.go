@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestClientIPCounts(t *testing.T) {
+	testLogs := []LogMessage{
+		{clientIP: "203.0.113.9", severity: "ERROR"},
+		{clientIP: "203.0.113.9", severity: "ERROR"},
+		{clientIP: "203.0.113.200", severity: "INFO"},
+		{clientIP: "198.51.100.7", severity: "INFO"},
+		{clientIP: "2001:db8::1", severity: "ERROR"},
+		{clientIP: "-", severity: "INFO"},
+	}
+
+	clientIPCounts := getClientIPCounts(testLogs, clientIPGrouping{ipv4Bits: 24, ipv6Bits: 64})
+	if got, want := getTopClientIPs(clientIPCounts, false), []string{"203.0.113.0/24", "198.51.100.0/24", "2001:db8::/64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getTopClientIPs() = %v, want %v", got, want)
+	}
+	if got, want := getTopClientIPs(clientIPCounts, true), []string{"203.0.113.0/24", "2001:db8::/64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getTopClientIPs(byErrors) = %v, want %v", got, want)
+	}
+
+	baseline := []netip.Prefix{netip.MustParsePrefix("198.51.100.0/23"), netip.MustParsePrefix("203.0.113.9/32")}
+	if got, want := getNewClientIPs(clientIPCounts, baseline), []string{"203.0.113.0/24", "2001:db8::/64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getNewClientIPs() = %v, want %v", got, want)
+	}
+
+	clientIPCounts = getClientIPCounts(testLogs, clientIPGrouping{ipv4Bits: 32, ipv6Bits: 128})
+	if got, want := getNewClientIPs(clientIPCounts, baseline), []string{"2001:db8::1", "203.0.113.200"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getNewClientIPs() without aggregation = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+)
+
+// A message is host-local when more than concentrationThreshold of its
+// occurrences come from one file. Rare messages are ignored since a handful
+// of occurrences in one file says little.
+const concentrationThreshold = 0.9
+const minConcentratedOccurrences = 10
+const maxConcentratedMessages = 10
+
+type messageConcentration struct {
+	message   string
+	total     int64
+	logPath   string
+	fileCount int64
+	files     int
+}
+
+// getMessageConcentrations finds messages that are concentrated in a single
+// file of a multi-file analysis, most frequent first.
+func getMessageConcentrations(logAnalyses []LogAnalysis) (concentrations []messageConcentration) {
+	if len(logAnalyses) < 2 {
+		return
+	}
+	byMessage := make(map[string]*messageConcentration)
+	for _, logAnalysis := range logAnalyses {
+		for message, frequency := range logAnalysis.messageFrequencies {
+			concentration, ok := byMessage[message]
+			if !ok {
+				concentration = &messageConcentration{message: message}
+				byMessage[message] = concentration
+			}
+			concentration.total += frequency
+			concentration.files += 1
+			if frequency > concentration.fileCount {
+				concentration.fileCount = frequency
+				concentration.logPath = logAnalysis.logPath
+			}
+		}
+	}
+	for _, concentration := range byMessage {
+		if concentration.total >= minConcentratedOccurrences &&
+			float64(concentration.fileCount) > concentrationThreshold*float64(concentration.total) {
+			concentrations = append(concentrations, *concentration)
+		}
+	}
+	sort.Slice(concentrations, func(i, j int) bool {
+		if concentrations[i].total != concentrations[j].total {
+			return concentrations[i].total > concentrations[j].total
+		}
+		return concentrations[i].message < concentrations[j].message
+	})
+	if len(concentrations) > maxConcentratedMessages {
+		concentrations = concentrations[:maxConcentratedMessages]
+	}
+	return
+}
+
+func formatMessageConcentration(concentration messageConcentration, options reportOptions) string {
+	share := float64(concentration.fileCount) / float64(concentration.total)
+	return concentration.message + ": " + formatPercent(share) + " of " + humanizeCount(concentration.total, options) +
+		" in " + concentration.logPath + " (seen in " + strconv.Itoa(concentration.files) + " files)"
+}
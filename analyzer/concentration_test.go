@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestGetMessageConcentrations(t *testing.T) {
+	logAnalyses := []LogAnalysis{
+		{logPath: "host-a.log", messageFrequencies: map[string]int64{"Disk full": 19, "Timeout": 10, "Rare": 5}},
+		{logPath: "host-b.log", messageFrequencies: map[string]int64{"Disk full": 1, "Timeout": 10}},
+		{logPath: "host-c.log", messageFrequencies: map[string]int64{"Timeout": 10}},
+	}
+
+	concentrations := getMessageConcentrations(logAnalyses)
+	if len(concentrations) != 1 {
+		t.Fatalf("Expected only Disk full to be host-local, got %+v", concentrations)
+	}
+	got := concentrations[0]
+	if got.message != "Disk full" || got.logPath != "host-a.log" || got.total != 20 || got.files != 2 {
+		t.Errorf("Unexpected concentration: %+v", got)
+	}
+	want := "Disk full: 95.0% of 20 in host-a.log (seen in 2 files)"
+	if line := formatMessageConcentration(got, reportOptions{}); line != want {
+		t.Errorf("formatMessageConcentration() = %q, want %q", line, want)
+	}
+
+	if concentrations := getMessageConcentrations(logAnalyses[:1]); concentrations != nil {
+		t.Errorf("Expected no concentrations for a single file, got %+v", concentrations)
+	}
+}
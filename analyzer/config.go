@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configSetting is one key of a -config file with its value, or the items
+// of a list value, and the line it starts on for error messages.
+type configSetting struct {
+	name   string
+	values []string
+	line   int
+}
+
+// loadConfigFile reads a YAML or TOML file, told apart by its extension.
+// Only the flat subset needed for flag values is understood: keys with a
+// scalar or a list of scalars, and comments.
+func loadConfigFile(configPath string) ([]configSetting, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		return parseConfig(string(data), ":")
+	case ".toml":
+		return parseConfig(string(data), "=")
+	}
+	return nil, errors.New("unknown config format " + configPath + ", expected .yaml, .yml or .toml")
+}
+
+func configError(line int, message string) error {
+	return errors.New("config line " + strconv.Itoa(line) + ": " + message)
+}
+
+// parseConfig reads key/value lines split at separator, ":" for YAML and
+// "=" for TOML. Values are bare, single or double quoted scalars, or
+// [a, "b"] lists, which may span lines. YAML block lists of "- item" lines
+// under an empty key are read too.
+func parseConfig(data string, separator string) (settings []configSetting, err error) {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	// listOpen is set while "- item" lines may follow a YAML key
+	listOpen := false
+	for index := 0; index < len(lines); index++ {
+		lineNumber := index + 1
+		line := stripConfigComment(lines[index])
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if separator == ":" && strings.HasPrefix(trimmed, "-") {
+			if !listOpen {
+				return nil, configError(lineNumber, "list item without a key")
+			}
+			value, err := parseConfigScalar(strings.TrimPrefix(trimmed, "-"))
+			if err != nil {
+				return nil, configError(lineNumber, err.Error())
+			}
+			settings[len(settings)-1].values = append(settings[len(settings)-1].values, value)
+			continue
+		}
+		listOpen = false
+		if line != strings.TrimLeft(line, " \t") {
+			return nil, configError(lineNumber, "nested keys are not supported")
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			return nil, configError(lineNumber, "tables are not supported")
+		}
+		key, value, found := strings.Cut(trimmed, separator)
+		if !found {
+			return nil, configError(lineNumber, "expected key"+separator+" value")
+		}
+		setting := configSetting{name: strings.ReplaceAll(strings.Trim(strings.TrimSpace(key), `"'`), "_", "-"), line: lineNumber}
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "" && separator == ":":
+			listOpen = true
+		case value == "":
+			return nil, configError(lineNumber, "missing value for "+setting.name)
+		case strings.HasPrefix(value, "["):
+			for !strings.HasSuffix(value, "]") && index+1 < len(lines) {
+				index++
+				value += " " + strings.TrimSpace(stripConfigComment(lines[index]))
+			}
+			if !strings.HasSuffix(value, "]") {
+				return nil, configError(lineNumber, "unterminated list for "+setting.name)
+			}
+			for _, item := range splitConfigList(value[1 : len(value)-1]) {
+				scalar, err := parseConfigScalar(item)
+				if err != nil {
+					return nil, configError(lineNumber, err.Error())
+				}
+				setting.values = append(setting.values, scalar)
+			}
+		default:
+			scalar, err := parseConfigScalar(value)
+			if err != nil {
+				return nil, configError(lineNumber, err.Error())
+			}
+			setting.values = []string{scalar}
+		}
+		settings = append(settings, setting)
+	}
+	return
+}
+
+// stripConfigComment cuts a # comment that starts a line or follows
+// whitespace, outside of quotes.
+func stripConfigComment(line string) string {
+	var quote byte
+	for index := 0; index < len(line); index++ {
+		switch character := line[index]; {
+		case quote != 0:
+			if character == '\\' && quote == '"' {
+				index++
+			} else if character == quote {
+				quote = 0
+			}
+		case character == '"' || character == '\'':
+			quote = character
+		case character == '#' && (index == 0 || line[index-1] == ' ' || line[index-1] == '\t'):
+			return line[:index]
+		}
+	}
+	return line
+}
+
+// splitConfigList splits the inside of a [...] list at commas outside of
+// quotes, dropping a trailing comma.
+func splitConfigList(list string) (items []string) {
+	var quote byte
+	start := 0
+	for index := 0; index < len(list); index++ {
+		switch character := list[index]; {
+		case quote != 0:
+			if character == '\\' && quote == '"' {
+				index++
+			} else if character == quote {
+				quote = 0
+			}
+		case character == '"' || character == '\'':
+			quote = character
+		case character == ',':
+			items = append(items, list[start:index])
+			start = index + 1
+		}
+	}
+	if strings.TrimSpace(list[start:]) != "" {
+		items = append(items, list[start:])
+	}
+	return
+}
+
+// parseConfigScalar unquotes a double quoted value with its escapes, or a
+// single quoted one where a doubled quote stands for one; bare values are
+// kept.
+func parseConfigScalar(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", errors.New("bad string " + value)
+		}
+		return unquoted, nil
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+		return "", errors.New("unterminated string " + value)
+	}
+	return value, nil
+}
+
+// applyConfig sets every flag named in settings that wasn't given on the
+// command line, so the command line overrides the file. The inputs key
+// lists the paths and globs to analyze when no arguments are given.
+func applyConfig(flags *flag.FlagSet, settings []configSetting) (inputs []string, err error) {
+	setOnCommandLine := make(map[string]bool)
+	flags.Visit(func(setFlag *flag.Flag) {
+		setOnCommandLine[setFlag.Name] = true
+	})
+	for _, setting := range settings {
+		if setting.name == "inputs" {
+			inputs = append(inputs, setting.values...)
+			continue
+		}
+		configFlag := flags.Lookup(setting.name)
+		if configFlag == nil || setting.name == "config" {
+			return nil, configError(setting.line, "unknown setting "+setting.name)
+		}
+		if setOnCommandLine[setting.name] {
+			continue
+		}
+		if _, repeatable := configFlag.Value.(*stringListFlag); !repeatable && len(setting.values) != 1 {
+			return nil, configError(setting.line, setting.name+" takes a single value")
+		}
+		for _, value := range setting.values {
+			if err := flags.Set(setting.name, value); err != nil {
+				return nil, configError(setting.line, err.Error())
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	yaml := `# nightly payments job
+preset: log4j
+min_severity: WARNING   # noise below this
+pattern: '{timestamp} [{severity}] {message} # not a comment'
+inputs:
+  - /var/log/payments/*.log
+  - "/var/log/legacy app.log"
+exclude: ["*.gz", '*.bak']
+`
+	toml := `# nightly payments job
+preset = "log4j"
+min_severity = "WARNING"   # noise below this
+pattern = '{timestamp} [{severity}] {message} # not a comment'
+inputs = [
+  "/var/log/payments/*.log",
+  "/var/log/legacy app.log",
+]
+exclude = ["*.gz", '*.bak']
+`
+	want := []configSetting{
+		{name: "preset", values: []string{"log4j"}, line: 2},
+		{name: "min-severity", values: []string{"WARNING"}, line: 3},
+		{name: "pattern", values: []string{"{timestamp} [{severity}] {message} # not a comment"}, line: 4},
+		{name: "inputs", values: []string{"/var/log/payments/*.log", "/var/log/legacy app.log"}, line: 5},
+		{name: "exclude", values: []string{"*.gz", "*.bak"}},
+	}
+	for _, tt := range []struct {
+		syntax      string
+		input       string
+		excludeLine int
+	}{
+		{syntax: ":", input: yaml, excludeLine: 8},
+		{syntax: "=", input: toml, excludeLine: 9},
+	} {
+		got, err := parseConfig(tt.input, tt.syntax)
+		if err != nil {
+			t.Fatalf("parseConfig(%q) error = %v", tt.syntax, err)
+		}
+		want[4].line = tt.excludeLine
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseConfig(%q) = %+v, want %+v", tt.syntax, got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		syntax string
+		input  string
+	}{
+		{syntax: ":", input: "filters:\n  module: db\n"},
+		{syntax: ":", input: "- orphan\n"},
+		{syntax: "=", input: "[filters]\nmodule = \"db\"\n"},
+		{syntax: "=", input: "pattern = \"unterminated\n"},
+		{syntax: "=", input: "exclude = [\"*.gz\",\n"},
+	} {
+		if _, err := parseConfig(tt.input, tt.syntax); err == nil {
+			t.Errorf("parseConfig(%q) accepted unsupported input", tt.input)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	var preset *string
+	var workers *int
+	var bucket *time.Duration
+	var excludes stringListFlag
+	newFlags := func() *flag.FlagSet {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		preset = flags.String("preset", "native", "")
+		workers = flags.Int("workers", 4, "")
+		bucket = flags.Duration("bucket", 0, "")
+		excludes = nil
+		flags.Var(&excludes, "exclude", "")
+		return flags
+	}
+	flags := newFlags()
+	if err := flags.Parse([]string{"-workers", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	settings := []configSetting{
+		{name: "preset", values: []string{"log4j"}, line: 1},
+		{name: "workers", values: []string{"16"}, line: 2},
+		{name: "bucket", values: []string{"5m"}, line: 3},
+		{name: "exclude", values: []string{"*.gz", "*.bak"}, line: 4},
+		{name: "inputs", values: []string{"/var/log/*.log"}, line: 5},
+	}
+	inputs, err := applyConfig(flags, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *preset != "log4j" || *workers != 2 || *bucket != 5*time.Minute || len(excludes) != 2 || !reflect.DeepEqual(inputs, []string{"/var/log/*.log"}) {
+		t.Errorf("applyConfig() set preset %q, workers %d, bucket %v, excludes %v, inputs %v", *preset, *workers, *bucket, excludes, inputs)
+	}
+
+	for _, setting := range []configSetting{
+		{name: "colour", values: []string{"red"}, line: 1},
+		{name: "preset", values: []string{"log4j", "python"}, line: 1},
+		{name: "bucket", values: []string{"soon"}, line: 1},
+	} {
+		if _, err := applyConfig(newFlags(), []configSetting{setting}); err == nil {
+			t.Errorf("applyConfig() accepted %+v", setting)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// jsonLogMessage names its fields after keys the json preset reads, so
+// converted entries can be analyzed again with -preset json.
+type jsonLogMessage struct {
+	Timestamp  string            `json:"timestamp"`
+	Severity   string            `json:"severity"`
+	Module     string            `json:"module"`
+	Function   string            `json:"function"`
+	LineNumber int64             `json:"line"`
+	Thread     string            `json:"thread,omitempty"`
+	PID        string            `json:"pid,omitempty"`
+	ClientIP   string            `json:"client_ip,omitempty"`
+	Message    string            `json:"message"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// attributeKeyPrefix marks attributes among the logfmt fields convert
+// writes, so that they can't be taken for an entry field of the same name.
+// The json preset reads the attributes of -to jsonl under the same keys.
+const attributeKeyPrefix = "attributes."
+
+// getNativeExtraFields lists the fields of logMessage that the native layout
+// has no place for, as key and value pairs in the order they are written.
+// Empty ones are left out.
+func getNativeExtraFields(logMessage LogMessage) (fields [][2]string) {
+	for _, field := range [][2]string{{"thread", logMessage.thread}, {"pid", logMessage.pid}, {"client_ip", logMessage.clientIP}} {
+		if field[1] != "" {
+			fields = append(fields, field)
+		}
+	}
+	return
+}
+
+// getAttributeFields lists the attributes of logMessage by key, without the
+// stack trace unless withStackTrace is set.
+func getAttributeFields(logMessage LogMessage, withStackTrace bool) (fields [][2]string) {
+	keys := make([]string, 0, len(logMessage.attributes))
+	for key := range logMessage.attributes {
+		if key != stackTraceAttribute || withStackTrace {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, [2]string{attributeKeyPrefix + formatLogfmtKey(key), logMessage.attributes[key]})
+	}
+	return
+}
+
+// formatLogfmtKey replaces the characters that would end a logfmt key.
+func formatLogfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '=' || r == '"' || unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func formatLogfmtFields(fields [][2]string) string {
+	pairs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		pairs = append(pairs, field[0]+"="+formatLogfmtValue(field[1]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// formatNativeLogMessage writes the fields the native layout has no place
+// for as logfmt after a fourth |, which the native parser reads back.
+func formatNativeLogMessage(logMessage LogMessage) string {
+	line := fmt.Sprintf("%s | %-8s | %s:%s:%d - %s", logMessage.timestamp, logMessage.severity,
+		logMessage.module, logMessage.function, logMessage.lineNumber, logMessage.message)
+	// The stack trace follows on lines of its own
+	if extraFields := append(getNativeExtraFields(logMessage), getAttributeFields(logMessage, false)...); len(extraFields) > 0 {
+		line += " | " + formatLogfmtFields(extraFields)
+	}
+	if trace, ok := logMessage.attributes[stackTraceAttribute]; ok {
+		line += "\n" + trace
+	}
+	return line
+}
+
+func formatJSONLogMessage(logMessage LogMessage) string {
+	data, _ := json.Marshal(jsonLogMessage{
+		Timestamp:  logMessage.timestamp,
+		Severity:   logMessage.severity,
+		Module:     logMessage.module,
+		Function:   logMessage.function,
+		LineNumber: logMessage.lineNumber,
+		Thread:     logMessage.thread,
+		PID:        logMessage.pid,
+		ClientIP:   logMessage.clientIP,
+		Message:    logMessage.message,
+		Attributes: logMessage.attributes,
+	})
+	return string(data)
+}
+
+func formatLogfmtValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " =\"\t\n") {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+func formatLogfmtLogMessage(logMessage LogMessage) string {
+	line := "timestamp=" + formatLogfmtValue(logMessage.timestamp) +
+		" severity=" + formatLogfmtValue(logMessage.severity) +
+		" module=" + formatLogfmtValue(logMessage.module) +
+		" function=" + formatLogfmtValue(logMessage.function) +
+		" line=" + strconv.FormatInt(logMessage.lineNumber, 10)
+	if extraFields := getNativeExtraFields(logMessage); len(extraFields) > 0 {
+		line += " " + formatLogfmtFields(extraFields)
+	}
+	line += " message=" + formatLogfmtValue(logMessage.message)
+	if attributeFields := getAttributeFields(logMessage, true); len(attributeFields) > 0 {
+		line += " " + formatLogfmtFields(attributeFields)
+	}
+	return line
+}
+
+func getLogMessageFormatter(format string) (func(LogMessage) string, error) {
+	switch format {
+	case "native":
+		return formatNativeLogMessage, nil
+	case "jsonl", "json":
+		return formatJSONLogMessage, nil
+	case "logfmt":
+		return formatLogfmtLogMessage, nil
+	default:
+		return nil, errors.New("unknown output format: " + format)
+	}
+}
+
+// convertLog writes every entry of reader in the output format. With an
+// entryStartPattern, continuation lines are kept as the stack trace of the
+// entry before them, so each entry is written once its last line is read.
+func convertLog(reader io.Reader, writer io.Writer, parser Parser, formatter func(LogMessage) string, entryStartPattern *regexp.Regexp) (converted int, skipped int, err error) {
+	scanner := bufio.NewScanner(reader)
+	var pending *LogMessage
+	flush := func() {
+		if pending != nil {
+			fmt.Fprintln(writer, formatter(*pending))
+			converted += 1
+			pending = nil
+		}
+	}
+	for scanner.Scan() {
+		logRow := scanner.Text()
+		logMessage, parseErr := parser(logRow)
+		if parseErr != nil {
+			if errors.Is(parseErr, errDirectiveLine) {
+				continue
+			}
+			if pending != nil && entryStartPattern != nil && isContinuationLine(logRow, entryStartPattern) {
+				appendContinuationLine(pending, logRow)
+				continue
+			}
+			flush()
+			if strings.TrimSpace(logRow) != "" {
+				skipped += 1
+			}
+			continue
+		}
+		flush()
+		pending = &logMessage
+	}
+	flush()
+	err = scanner.Err()
+	return
+}
+
+// reportConversion converts reader, reporting skipped lines and errors on
+// stderr. It returns false when reader couldn't be read in full.
+func reportConversion(name string, writer io.Writer, reader io.Reader, parser Parser, formatter func(LogMessage) string, entryStartPattern *regexp.Regexp) bool {
+	_, skipped, err := convertLog(reader, writer, parser, formatter, entryStartPattern)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading "+name+":", err)
+	}
+	if skipped > 0 {
+		fmt.Fprintln(os.Stderr, "Skipped "+strconv.Itoa(skipped)+" unparseable lines in "+name)
+	}
+	return err == nil
+}
+
+func runConvert(args []string) {
+	flagSet := flag.NewFlagSet("convert", flag.ExitOnError)
+	format := flagSet.String("to", "jsonl", "output format: native, jsonl or logfmt")
+	outputPath := flagSet.String("o", "", "write converted entries to this file instead of stdout")
+	preset := flagSet.String("preset", "native", "input log format")
+	pattern := flagSet.String("pattern", "", "custom input format as a named-group regex or {field} template")
+	multiline := flagSet.Bool("multiline", false, "keep lines that fail to parse and don't start with a timestamp, like stack traces, with the entry before them")
+	multilineStart := flagSet.String("multiline-start", "", "regex matching the first line of every entry, for -multiline; implies -multiline")
+	keyPath := flagSet.String("encrypt-key", "", "encrypt the output with the 32 byte key in this file; read it back with decrypt")
+	flagSet.Parse(args)
+
+	parser, err := resolveLineParser(*preset, *pattern, timeFormat{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	formatter, err := getLogMessageFormatter(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	var entryStartPattern *regexp.Regexp
+	if *multilineStart != "" {
+		if entryStartPattern, err = regexp.Compile(*multilineStart); err != nil {
+			fmt.Fprintln(os.Stderr, "Invalid multiline start pattern:", err)
+			os.Exit(exitUsage)
+		}
+	} else if *multiline {
+		entryStartPattern = defaultEntryStartPattern
+	}
+	var key []byte
+	if *keyPath != "" {
+		if key, err = loadEncryptionKey(*keyPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	exitStatus := exitOK
+	// Deferred first, so it runs once the output is flushed and closed
+	defer func() {
+		if exitStatus != exitOK {
+			os.Exit(exitStatus)
+		}
+	}()
+	var writer io.Writer = os.Stdout
+	if *outputPath != "" {
+		outputFile, err := os.Create(*outputPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating output file:", err)
+			os.Exit(exitFailure)
+		}
+		defer outputFile.Close()
+		bufferedWriter := bufio.NewWriter(outputFile)
+		defer bufferedWriter.Flush()
+		writer = bufferedWriter
+	}
+	if key != nil {
+		encryptingWriter, err := newEncryptingWriter(writer, key)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error encrypting output:", err)
+			os.Exit(exitFailure)
+		}
+		defer encryptingWriter.Close()
+		writer = encryptingWriter
+	}
+
+	if flagSet.NArg() == 0 {
+		if !reportConversion("stdin", writer, os.Stdin, parser, formatter, entryStartPattern) {
+			exitStatus = exitFailure
+		}
+		return
+	}
+	for _, logPath := range flagSet.Args() {
+		logFile, err := os.Open(logPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading file:", newFileError(logPath, 0, err))
+			exitStatus = exitFailure
+			continue
+		}
+		if !reportConversion(logPath, writer, logFile, parser, formatter, entryStartPattern) {
+			exitStatus = exitFailure
+		}
+		logFile.Close()
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestConvertLog(t *testing.T) {
+	logContent := `2024-01-01 00:00:00.000 | INFO | app.module: start: 12 - User logged in
+garbage`
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "native",
+			want:   "2024-01-01 00:00:00.000 | INFO     | app.module:start:12 - User logged in\n",
+		},
+		{
+			format: "jsonl",
+			want:   `{"timestamp":"2024-01-01 00:00:00.000","severity":"INFO","module":"app.module","function":"start","line":12,"message":"User logged in"}` + "\n",
+		},
+		{
+			format: "logfmt",
+			want:   `timestamp="2024-01-01 00:00:00.000" severity=INFO module=app.module function=start line=12 message="User logged in"` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			formatter, err := getLogMessageFormatter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			converted, skipped, err := convertLog(strings.NewReader(logContent), &output, parseLogMessage, formatter, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if converted != 1 || skipped != 1 {
+				t.Errorf("convertLog() converted %d, skipped %d, want 1 and 1", converted, skipped)
+			}
+			if output.String() != tt.want {
+				t.Errorf("convertLog() = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+// parseConvertedLogfmt reads a line of convert -to logfmt back.
+func parseConvertedLogfmt(line string) (LogMessage, error) {
+	fields := parseLogfmt(line)
+	lineNumber, err := strconv.ParseInt(fields["line"], 10, 64)
+	var attributes map[string]string
+	for key, value := range fields {
+		if attribute, ok := strings.CutPrefix(key, attributeKeyPrefix); ok {
+			if attributes == nil {
+				attributes = make(map[string]string)
+			}
+			attributes[attribute] = value
+		}
+	}
+	return LogMessage{
+		timestamp:  fields["timestamp"],
+		severity:   fields["severity"],
+		module:     fields["module"],
+		function:   fields["function"],
+		lineNumber: lineNumber,
+		thread:     fields["thread"],
+		pid:        fields["pid"],
+		clientIP:   fields["client_ip"],
+		message:    fields["message"],
+		attributes: attributes,
+	}, err
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	logMessage := LogMessage{
+		timestamp:  "2024-01-01 00:00:00.123",
+		severity:   "ERROR",
+		module:     "web",
+		function:   "serve",
+		lineNumber: 7,
+		thread:     "worker 1",
+		pid:        "42",
+		clientIP:   "203.0.113.9",
+		message:    "GET /a.gif 500",
+		attributes: map[string]string{"status": "500", "cs(User-Agent)": "curl/8.0 (x86_64)", "query": "a=1&b=2"},
+	}
+	tests := []struct {
+		format string
+		parser Parser
+	}{
+		{"native", parseLogMessage},
+		{"jsonl", newJSONLinesParser(defaultJSONFieldKeys)},
+		{"logfmt", parseConvertedLogfmt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			formatter, err := getLogMessageFormatter(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			line := formatter(logMessage)
+			got, err := tt.parser(line)
+			if err != nil || !reflect.DeepEqual(got, logMessage) {
+				t.Errorf("%s read back as %+v, %v, want %+v", line, got, err, logMessage)
+			}
+		})
+	}
+}
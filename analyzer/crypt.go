@@ -0,0 +1,314 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Encrypted files start with encryptionMagic and a random salt, followed by
+// chunks of up to encryptionChunkSize bytes, each sealed with AES-256-GCM.
+// The nonce is the chunk's index and a flag marking the last chunk, so
+// chunks can't be reordered, dropped or cut off at the end unnoticed. Every
+// file has its own key, derived from the user's key and the salt, so the
+// counter nonces never repeat under one key.
+const (
+	encryptionMagic     = "CLAENC1\n"
+	encryptionSaltSize  = 16
+	encryptionChunkSize = 64 * 1024
+	encryptionOverhead  = 16
+)
+
+var errNotEncrypted = errors.New("not an encrypted file")
+
+// checkEncryptedReport rejects report modes -encrypt-key can't encrypt: the
+// text report, and -follow and -gelf-udp, which re-render it as entries
+// arrive.
+func checkEncryptedReport(format string, live bool) error {
+	if live {
+		return errors.New("-encrypt-key can't be used with -follow or -gelf-udp, which print the text report")
+	}
+	if format == "text" {
+		return errors.New("-encrypt-key needs -format json, csv or ndjson")
+	}
+	return nil
+}
+
+// loadEncryptionKey reads a 32 byte key from a file, as 64 hex characters,
+// base64 or the raw bytes, like the output of openssl rand -hex 32.
+func loadEncryptionKey(keyPath string) ([]byte, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	return nil, errors.New("key file " + keyPath + " must hold 32 bytes, as 64 hex characters, base64 or raw")
+}
+
+func newFileCipher(key []byte, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encryptionMagic))
+	mac.Write(salt)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func getChunkNonce(index uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], index)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type encryptingWriter struct {
+	writer io.Writer
+	aead   cipher.AEAD
+	buffer []byte
+	index  uint64
+}
+
+// newEncryptingWriter encrypts everything written to it onto writer. Close
+// seals the last chunk and must be called for the output to be readable.
+func newEncryptingWriter(writer io.Writer, key []byte) (io.WriteCloser, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newFileCipher(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(append([]byte(encryptionMagic), salt...)); err != nil {
+		return nil, err
+	}
+	return &encryptingWriter{writer: writer, aead: aead, buffer: make([]byte, 0, encryptionChunkSize)}, nil
+}
+
+func (encrypting *encryptingWriter) sealChunk(last bool) error {
+	sealed := encrypting.aead.Seal(nil, getChunkNonce(encrypting.index, last), encrypting.buffer, nil)
+	encrypting.index++
+	encrypting.buffer = encrypting.buffer[:0]
+	_, err := encrypting.writer.Write(sealed)
+	return err
+}
+
+// Write seals a full chunk only once more data follows it, since the last
+// chunk is sealed differently.
+func (encrypting *encryptingWriter) Write(data []byte) (written int, err error) {
+	for len(data) > 0 {
+		if len(encrypting.buffer) == encryptionChunkSize {
+			if err = encrypting.sealChunk(false); err != nil {
+				return
+			}
+		}
+		count := copy(encrypting.buffer[len(encrypting.buffer):encryptionChunkSize], data)
+		encrypting.buffer = encrypting.buffer[:len(encrypting.buffer)+count]
+		data = data[count:]
+		written += count
+	}
+	return
+}
+
+func (encrypting *encryptingWriter) Close() error {
+	return encrypting.sealChunk(true)
+}
+
+// encryptBytes encrypts data in memory, as newEncryptingWriter would.
+func encryptBytes(data []byte, key []byte) ([]byte, error) {
+	var encrypted bytes.Buffer
+	encryptingWriter, err := newEncryptingWriter(&encrypted, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := encryptingWriter.Write(data); err != nil {
+		return nil, err
+	}
+	if err := encryptingWriter.Close(); err != nil {
+		return nil, err
+	}
+	return encrypted.Bytes(), nil
+}
+
+// encryptedFile is an output file whose contents are encrypted as they are
+// written.
+type encryptedFile struct {
+	io.WriteCloser
+	file *os.File
+}
+
+func (encrypted encryptedFile) Close() error {
+	err := encrypted.WriteCloser.Close()
+	if closeErr := encrypted.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// createOutputFile creates an output file that is encrypted with key when
+// it is set, so every file the analysis writes honors -encrypt-key.
+func createOutputFile(outputPath string, key []byte) (io.WriteCloser, error) {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return outputFile, nil
+	}
+	encryptingWriter, err := newEncryptingWriter(outputFile, key)
+	if err != nil {
+		outputFile.Close()
+		return nil, err
+	}
+	return encryptedFile{WriteCloser: encryptingWriter, file: outputFile}, nil
+}
+
+// writeOutputFile writes data to an output file as createOutputFile would.
+func writeOutputFile(outputPath string, data []byte, key []byte) error {
+	outputFile, err := createOutputFile(outputPath, key)
+	if err != nil {
+		return err
+	}
+	if _, err := outputFile.Write(data); err != nil {
+		outputFile.Close()
+		return err
+	}
+	return outputFile.Close()
+}
+
+// readInputFile reads a file the analysis wrote before, decrypting it with
+// key when it was written with -encrypt-key.
+func readInputFile(inputPath string, key []byte) ([]byte, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil || !bytes.HasPrefix(data, []byte(encryptionMagic)) {
+		return data, err
+	}
+	if key == nil {
+		return nil, errors.New(inputPath + " is encrypted; pass its -encrypt-key")
+	}
+	reader, err := newDecryptingReader(bytes.NewReader(data), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+type decryptingReader struct {
+	reader  *bufio.Reader
+	aead    cipher.AEAD
+	chunk   []byte
+	pending []byte
+	index   uint64
+	done    bool
+}
+
+// newDecryptingReader decrypts a file written by newEncryptingWriter. Reads
+// fail once a chunk doesn't authenticate or the file ends before its last
+// chunk.
+func newDecryptingReader(reader io.Reader, key []byte) (io.Reader, error) {
+	bufferedReader := bufio.NewReaderSize(reader, encryptionChunkSize+encryptionOverhead+1)
+	header := make([]byte, len(encryptionMagic)+encryptionSaltSize)
+	if _, err := io.ReadFull(bufferedReader, header); err != nil || !bytes.HasPrefix(header, []byte(encryptionMagic)) {
+		return nil, errNotEncrypted
+	}
+	aead, err := newFileCipher(key, header[len(encryptionMagic):])
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{reader: bufferedReader, aead: aead, chunk: make([]byte, encryptionChunkSize+encryptionOverhead)}, nil
+}
+
+func (decrypting *decryptingReader) Read(data []byte) (int, error) {
+	for len(decrypting.pending) == 0 {
+		if decrypting.done {
+			return 0, io.EOF
+		}
+		count, err := io.ReadFull(decrypting.reader, decrypting.chunk)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		// A chunk is the last one when nothing follows it
+		_, peekErr := decrypting.reader.Peek(1)
+		last := errors.Is(peekErr, io.EOF)
+		opened, openErr := decrypting.aead.Open(decrypting.chunk[:0:0], getChunkNonce(decrypting.index, last), decrypting.chunk[:count], nil)
+		if openErr != nil {
+			return 0, errors.New("encrypted file is corrupt, truncated or was encrypted with another key")
+		}
+		decrypting.index++
+		decrypting.pending = opened
+		decrypting.done = last
+	}
+	count := copy(data, decrypting.pending)
+	decrypting.pending = decrypting.pending[count:]
+	return count, nil
+}
+
+func runDecrypt(args []string) {
+	flagSet := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	keyPath := flagSet.String("key", "", "file holding the key the input was encrypted with")
+	outputPath := flagSet.String("o", "", "write the decrypted data to this file instead of stdout")
+	flagSet.Parse(args)
+	if *keyPath == "" || flagSet.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: decrypt -key key.txt [-o output] [encrypted file]")
+		os.Exit(exitUsage)
+	}
+	key, err := loadEncryptionKey(*keyPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	var input io.Reader = os.Stdin
+	if flagSet.NArg() == 1 {
+		inputFile, err := os.Open(flagSet.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitFailure)
+		}
+		defer inputFile.Close()
+		input = inputFile
+	}
+	var output io.Writer = os.Stdout
+	if *outputPath != "" {
+		outputFile, err := os.OpenFile(*outputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating output file:", err)
+			os.Exit(exitFailure)
+		}
+		defer outputFile.Close()
+		output = outputFile
+	}
+	reader, err := newDecryptingReader(input, key)
+	if err == nil {
+		_, err = io.Copy(output, reader)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error decrypting:", err)
+		os.Exit(exitFailure)
+	}
+}
@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	for _, size := range []int{0, 10, encryptionChunkSize, encryptionChunkSize + 1, 3*encryptionChunkSize - 5} {
+		plaintext := bytes.Repeat([]byte("2024-01-02 15:04:05 ERROR db: timeout\n"), size/38+1)[:size]
+		encrypted, err := encryptBytes(plaintext, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size > 0 && bytes.Contains(encrypted, plaintext[:min(size, 38)]) {
+			t.Fatalf("Encrypted data of %d bytes contains the plaintext", size)
+		}
+		reader, err := newDecryptingReader(bytes.NewReader(encrypted), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := io.ReadAll(reader)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("Round trip of %d bytes = %d bytes, %v", size, len(decrypted), err)
+		}
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plaintext := bytes.Repeat([]byte("x"), 2*encryptionChunkSize+100)
+	encrypted, err := encryptBytes(plaintext, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := len(encryptionMagic) + encryptionSaltSize
+	flipped := bytes.Clone(encrypted)
+	flipped[header+5] ^= 1
+	cases := []struct {
+		name string
+		data []byte
+		key  []byte
+	}{
+		{"flipped bit", flipped, key},
+		{"truncated at a chunk boundary", encrypted[:header+encryptionChunkSize+encryptionOverhead], key},
+		{"truncated mid chunk", encrypted[:len(encrypted)-10], key},
+		{"wrong key", encrypted, bytes.Repeat([]byte{8}, 32)},
+	}
+	for _, testCase := range cases {
+		reader, err := newDecryptingReader(bytes.NewReader(testCase.data), testCase.key)
+		if err == nil {
+			_, err = io.ReadAll(reader)
+		}
+		if err == nil {
+			t.Errorf("Decrypting with a %s succeeded", testCase.name)
+		}
+	}
+	if _, err := newDecryptingReader(strings.NewReader("2024-01-02 15:04:05 INFO plain\n"), key); err != errNotEncrypted {
+		t.Errorf("newDecryptingReader() of plain text = %v", err)
+	}
+}
+
+func TestLoadEncryptionKey(t *testing.T) {
+	directory := t.TempDir()
+	cases := map[string]bool{
+		strings.Repeat("ab", 32) + "\n":                  true,
+		"q83vEjRWeJCrze8SNFZ4kKvN7xI0VniQq83vEjRWeJA=\n": true,
+		strings.Repeat("k", 32):                          true,
+		"too short":                                      false,
+	}
+	for contents, valid := range cases {
+		keyPath := filepath.Join(directory, "key")
+		if err := os.WriteFile(keyPath, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		key, err := loadEncryptionKey(keyPath)
+		if valid && (err != nil || len(key) != 32) || !valid && err == nil {
+			t.Errorf("loadEncryptionKey(%q) = %d bytes, %v", contents, len(key), err)
+		}
+	}
+}
+
+func TestCheckEncryptedReport(t *testing.T) {
+	tests := []struct {
+		format string
+		live   bool
+		valid  bool
+	}{
+		{"json", false, true},
+		{"ndjson", false, true},
+		{"text", false, false},
+		// -follow and -gelf-udp print the plaintext text report whatever the format
+		{"json", true, false},
+		{"csv", true, false},
+	}
+	for _, test := range tests {
+		if err := checkEncryptedReport(test.format, test.live); (err == nil) != test.valid {
+			t.Errorf("checkEncryptedReport(%q, %v) = %v, want valid %v", test.format, test.live, err, test.valid)
+		}
+	}
+}
+
+func TestOutputFileEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	outputPath := filepath.Join(t.TempDir(), "series.csv")
+	plaintext := []byte("period,entries\n2024-01-02,12\n")
+	for _, outputKey := range [][]byte{nil, key} {
+		if err := writeOutputFile(outputPath, plaintext, outputKey); err != nil {
+			t.Fatal(err)
+		}
+		written, _ := os.ReadFile(outputPath)
+		if encrypted := !bytes.Equal(written, plaintext); encrypted != (outputKey != nil) {
+			t.Errorf("writeOutputFile() with key %v encrypted = %v", outputKey != nil, encrypted)
+		}
+		if data, err := readInputFile(outputPath, key); err != nil || !bytes.Equal(data, plaintext) {
+			t.Errorf("readInputFile() = %q, %v, want %q", data, err, plaintext)
+		}
+	}
+	if _, err := readInputFile(outputPath, nil); err == nil {
+		t.Error("readInputFile() of an encrypted file without a key succeeded")
+	}
+}
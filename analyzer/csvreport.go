@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvReportHeader = []string{"file", "section", "key", "severity", "value"}
+
+// csvReportRows flattens the summary, severity counts, top messages and,
+// with -bucket, the volume per bucket into one long table. Rows of the
+// merged analysis have an empty file; bucket rows without a severity are
+// the bucket's total.
+func csvReportRows(logAnalysis LogAnalysis, file string) (rows [][]string) {
+	row := func(section string, key string, severity string, value string) {
+		rows = append(rows, []string{file, section, key, severity, value})
+	}
+	row("summary", "entries", "", strconv.Itoa(logAnalysis.numEntries))
+	row("summary", "bytes", "", strconv.FormatInt(logAnalysis.bytesRead, 10))
+	row("summary", "lines", "", strconv.FormatInt(logAnalysis.lines, 10))
+	row("summary", "malformed_lines", "", strconv.FormatInt(logAnalysis.malformedLines, 10))
+	if !logAnalysis.startTime.IsZero() {
+		row("summary", "start", "", logAnalysis.startTime.Format(layout))
+		row("summary", "end", "", logAnalysis.endTime.Format(layout))
+	}
+	for _, severity := range getSortedSeverities(logAnalysis.logSeverityFrequency) {
+		row("severity", "", severity, strconv.FormatInt(logAnalysis.logSeverityFrequency[severity], 10))
+	}
+	for index, message := range logAnalysis.topFiveLogMessages {
+		if message == "" || index >= len(logAnalysis.topFiveLogMessageFrequencies) {
+			continue
+		}
+		row("message", message, "", strconv.FormatInt(logAnalysis.topFiveLogMessageFrequencies[index], 10))
+	}
+	if logAnalysis.histogramBucket > 0 {
+		for _, bucket := range getHistogramBuckets(logAnalysis.timeBucketCounts, logAnalysis.histogramBucket) {
+			start := bucket.start.Format(layout)
+			row("bucket", start, "", strconv.FormatInt(bucket.entries, 10))
+			for _, severity := range getSortedSeverities(bucket.severityFrequency) {
+				if count := bucket.severityFrequency[severity]; count > 0 {
+					row("bucket", start, severity, strconv.FormatInt(count, 10))
+				}
+			}
+		}
+	}
+	return
+}
+
+// writeLogAnalysisCSV writes the report as a single CSV table with a
+// file, section, key, severity and value column, which spreadsheets load
+// as is and pivot tables can split by section.
+func writeLogAnalysisCSV(writer io.Writer, logAnalysis LogAnalysis, fileLogAnalyses []LogAnalysis) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(csvReportHeader); err != nil {
+		return err
+	}
+	for _, fileLogAnalysis := range fileLogAnalyses {
+		if err := csvWriter.WriteAll(csvReportRows(fileLogAnalysis, fileLogAnalysis.logPath)); err != nil {
+			return err
+		}
+	}
+	return csvWriter.WriteAll(csvReportRows(logAnalysis, ""))
+}
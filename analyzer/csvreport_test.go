@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteLogAnalysisCSV(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "INFO", message: "Started"},
+		{timestamp: "2024-01-01 08:30:00", severity: "ERROR", message: "Timeout, retrying"},
+		{timestamp: "2024-01-01 09:10:00", severity: "ERROR", message: "Timeout, retrying"},
+	}
+	logAnalysis := newLogAnalysis("app.log", testLogs, nil, parseStats{bytesRead: 120, lines: 3}, analysisOptions{histogramBucket: time.Hour})
+
+	var output bytes.Buffer
+	if err := writeLogAnalysisCSV(&output, logAnalysis, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "file,section,key,severity,value\n" +
+		",summary,entries,,3\n" +
+		",summary,bytes,,120\n" +
+		",summary,lines,,3\n" +
+		",summary,malformed_lines,,0\n" +
+		",summary,start,,2024-01-01 08:00:00\n" +
+		",summary,end,,2024-01-01 09:10:00\n" +
+		",severity,,DEBUG,0\n" +
+		",severity,,INFO,1\n" +
+		",severity,,WARNING,0\n" +
+		",severity,,ERROR,2\n" +
+		",message,\"Timeout, retrying\",,2\n" +
+		",message,Started,,1\n" +
+		",bucket,2024-01-01 08:00:00,,2\n" +
+		",bucket,2024-01-01 08:00:00,INFO,1\n" +
+		",bucket,2024-01-01 08:00:00,ERROR,1\n" +
+		",bucket,2024-01-01 09:00:00,,1\n" +
+		",bucket,2024-01-01 09:00:00,ERROR,1\n"
+	if output.String() != want {
+		t.Errorf("writeLogAnalysisCSV() = %q, want %q", output.String(), want)
+	}
+}
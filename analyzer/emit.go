@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func getSeverityGauges(logAnalysis LogAnalysis) (gauges map[string]float64) {
+	gauges = make(map[string]float64)
+	spanSeconds := logAnalysis.endTime.Sub(logAnalysis.startTime).Seconds()
+	gauges["entries"] = float64(logAnalysis.numEntries)
+	for _, name := range getSortedSeverities(logAnalysis.logSeverityFrequency) {
+		count := logAnalysis.logSeverityFrequency[name]
+		severity := strings.ToLower(name)
+		gauges["severity."+severity+".count"] = float64(count)
+		if spanSeconds > 0 {
+			gauges["severity."+severity+".rate"] = float64(count) / spanSeconds
+		}
+	}
+	return
+}
+
+func formatGaugeLines(prefix string, gauges map[string]float64, format func(name string, value string) string) string {
+	names := make([]string, 0, len(gauges))
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var builder strings.Builder
+	for _, name := range names {
+		if prefix != "" {
+			builder.WriteString(format(prefix+"."+name, strconv.FormatFloat(gauges[name], 'f', -1, 64)))
+		} else {
+			builder.WriteString(format(name, strconv.FormatFloat(gauges[name], 'f', -1, 64)))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func sendStatsdGauges(address string, prefix string, gauges map[string]float64) error {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	payload := formatGaugeLines(prefix, gauges, func(name string, value string) string {
+		return name + ":" + value + "|g"
+	})
+	_, err = conn.Write([]byte(payload))
+	return err
+}
+
+func sendGraphiteMetrics(address string, prefix string, gauges map[string]float64, timestamp time.Time) error {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unixTime := strconv.FormatInt(timestamp.Unix(), 10)
+	payload := formatGaugeLines(prefix, gauges, func(name string, value string) string {
+		return name + " " + value + " " + unixTime
+	})
+	_, err = conn.Write([]byte(payload))
+	return err
+}
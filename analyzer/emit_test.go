@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendStatsdGauges(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	gauges := map[string]float64{"severity.error.count": 2, "entries": 4}
+	if err := sendStatsdGauges(conn.LocalAddr().String(), "app", gauges); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "app.entries:4|g\napp.severity.error.count:2|g\n"
+	if string(buffer[:n]) != want {
+		t.Errorf("sendStatsdGauges() sent %q, want %q", string(buffer[:n]), want)
+	}
+}
+
+func TestSendGraphiteMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan string)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+
+	gauges := map[string]float64{"severity.info.rate": 0.5}
+	if err := sendGraphiteMetrics(listener.Addr().String(), "app", gauges, time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	got := <-received
+	if strings.TrimSpace(got) != "app.severity.info.rate 0.5 1700000000" {
+		t.Errorf("sendGraphiteMetrics() sent %q", got)
+	}
+}
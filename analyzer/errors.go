@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Exit statuses of the command line tool. -check is the exception: a
+// monitoring system reads its status as checkOK, checkWarning, checkCritical
+// or checkUnknown, so there 2 means CRITICAL rather than exitUsage, and
+// usage errors and failures exit checkUnknown.
+const (
+	exitOK = 0
+	// exitFailure is for files that couldn't be read or parsed and outputs
+	// that couldn't be written
+	exitFailure = 1
+	// exitUsage is for invalid flags, arguments and configuration
+	exitUsage = 2
+	// exitThreshold is for a run that completed but failed a check it was
+	// asked for: -strict, an exhausted -budgets budget or a firing alert rule
+	exitThreshold = 3
+)
+
+// FileError is an error reading a log file, at a line when Line isn't 0.
+// Analyses return one for every file that couldn't be read in full, along
+// with what was read of it.
+type FileError struct {
+	Path string
+	Line int64
+	Err  error
+}
+
+// newFileError wraps err from reading logPath. The path is dropped from an
+// os.PathError for it, so it isn't repeated.
+func newFileError(logPath string, line int64, err error) *FileError {
+	var pathError *os.PathError
+	if errors.As(err, &pathError) && pathError.Path == logPath {
+		err = pathError.Err
+	}
+	return &FileError{Path: logPath, Line: line, Err: err}
+}
+
+func (err *FileError) Error() string {
+	location := err.Path
+	if err.Line > 0 {
+		location += ":" + strconv.FormatInt(err.Line, 10)
+	}
+	return location + ": " + err.Err.Error()
+}
+
+func (err *FileError) Unwrap() error {
+	return err.Err
+}
+
+// sortFileErrors orders errors by file, whatever order the files were read
+// in.
+func sortFileErrors(fileErrors []error) {
+	sort.Slice(fileErrors, func(i, j int) bool {
+		return fileErrors[i].Error() < fileErrors[j].Error()
+	})
+}
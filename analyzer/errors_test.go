@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFileErrorMessage(t *testing.T) {
+	missingPath := filepath.Join(t.TempDir(), "missing.log")
+	_, err := os.Open(missingPath)
+	fileError := newFileError(missingPath, 0, err)
+	// The path isn't repeated from the os.PathError
+	if message := fileError.Error(); !strings.HasPrefix(message, missingPath+": ") || strings.Count(message, missingPath) != 1 {
+		t.Errorf("Error() = %q, want the path once", message)
+	}
+	if !errors.Is(fileError, os.ErrNotExist) {
+		t.Errorf("%v doesn't wrap %v", fileError, os.ErrNotExist)
+	}
+	if got := newFileError("app.log", 12, io.ErrUnexpectedEOF).Error(); got != "app.log:12: unexpected EOF" {
+		t.Errorf("Error() = %q, want the path and line", got)
+	}
+}
+
+func TestAnalyzeReturnsFileErrors(t *testing.T) {
+	input := "2024-01-01 08:00:00.000 | ERROR | db:query:12 - Timeout after 30s\n\n" +
+		"2024-01-01 08:00:01.000 | INFO | db:query:12 - Query done\n"
+	failed := errors.New("disk failed")
+	reader := io.MultiReader(strings.NewReader(input), iotest.ErrReader(failed))
+	analysis, err := Analyze(context.Background(), reader, strings.NewReader(input))
+	var fileError *FileError
+	if !errors.As(err, &fileError) || !errors.Is(err, failed) {
+		t.Fatalf("Analyze() error = %v, want a FileError wrapping %v", err, failed)
+	}
+	if fileError.Path != "input-1" || fileError.Line != 4 {
+		t.Errorf("Analyze() failed at %s:%d, want input-1:4", fileError.Path, fileError.Line)
+	}
+	// What was read before the error is still counted
+	if analysis.Entries != 4 || len(analysis.FileErrors) != 1 {
+		t.Errorf("Analyze() = %d entries and file errors %+v, want 4 and one", analysis.Entries, analysis.FileErrors)
+	}
+}
+
+func TestAnalyzeLogAnalysesWithoutFiles(t *testing.T) {
+	if logAnalysis := analyzelogAnalyses(nil); logAnalysis.numEntries != 0 {
+		t.Errorf("analyzelogAnalyses(nil) counted %d entries", logAnalysis.numEntries)
+	}
+}
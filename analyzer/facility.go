@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"slices"
+	"sort"
+)
+
+// facilityAttribute holds the syslog facility decoded from the priority.
+const facilityAttribute = "facility"
+
+// noFacility labels entries without a priority in a file where others have
+// one, like lines written by rsyslog next to relayed ones.
+const noFacility = "(no facility)"
+
+// getFacilitySeverityFrequencies breaks severities down by syslog facility.
+// It returns nil when no entry has a facility, so the breakdown only shows
+// up for syslog input with priorities. Entries without one are added by
+// addNoFacilitySeverities once the whole file is counted, and again once
+// files are merged, for the files without any facility.
+func getFacilitySeverityFrequencies(logMessages []LogMessage) (facilitySeverityFrequencies map[string]LogSeverityFrequency) {
+	for _, logMessage := range logMessages {
+		facility, ok := logMessage.attributes[facilityAttribute]
+		if !ok {
+			continue
+		}
+		if facilitySeverityFrequencies == nil {
+			facilitySeverityFrequencies = make(map[string]LogSeverityFrequency)
+		}
+		countFacilitySeverity(facilitySeverityFrequencies, facility, logMessage.severity)
+	}
+	return
+}
+
+// addNoFacilitySeverities counts the entries of an analysis that has
+// facilities but aren't under any of them yet, from its severity counts.
+func addNoFacilitySeverities(facilitySeverityFrequencies map[string]LogSeverityFrequency, logSeverityFrequency LogSeverityFrequency) {
+	if facilitySeverityFrequencies == nil {
+		return
+	}
+	var withoutFacility LogSeverityFrequency
+	for severity, count := range logSeverityFrequency {
+		for _, facilitySeverityFrequency := range facilitySeverityFrequencies {
+			count -= facilitySeverityFrequency[severity]
+		}
+		if count > 0 {
+			addSeverityCount(&withoutFacility, severity, count)
+		}
+	}
+	if withoutFacility != nil {
+		merged := facilitySeverityFrequencies[noFacility]
+		mergeLogSeverityFrequency(&merged, withoutFacility)
+		facilitySeverityFrequencies[noFacility] = merged
+	}
+}
+
+func countFacilitySeverity(facilitySeverityFrequencies map[string]LogSeverityFrequency, facility string, severity string) {
+	logSeverityFrequency := facilitySeverityFrequencies[facility]
+	countSeverity(&logSeverityFrequency, severity)
+	facilitySeverityFrequencies[facility] = logSeverityFrequency
+}
+
+func mergeFacilitySeverityFrequencies(into map[string]LogSeverityFrequency, from map[string]LogSeverityFrequency) {
+	for facility, logSeverityFrequency := range from {
+		merged := into[facility]
+		mergeLogSeverityFrequency(&merged, logSeverityFrequency)
+		into[facility] = merged
+	}
+}
+
+// getSortedFacilities lists facilities in syslog code order, kern first and
+// local7 last, then any others alphabetically and entries without one.
+func getSortedFacilities(facilitySeverityFrequencies map[string]LogSeverityFrequency) (facilities []string) {
+	for facility := range facilitySeverityFrequencies {
+		facilities = append(facilities, facility)
+	}
+	rank := func(facility string) int {
+		if facility == noFacility {
+			return len(syslogFacilities) + 1
+		}
+		if code := slices.Index(syslogFacilities, facility); code >= 0 {
+			return code
+		}
+		return len(syslogFacilities)
+	}
+	sort.Slice(facilities, func(i, j int) bool {
+		if rank(facilities[i]) != rank(facilities[j]) {
+			return rank(facilities[i]) < rank(facilities[j])
+		}
+		return facilities[i] < facilities[j]
+	})
+	return
+}
+
+func getSeverityEntries(logSeverityFrequency LogSeverityFrequency) (entries int64) {
+	for _, count := range logSeverityFrequency {
+		entries += count
+	}
+	return
+}
+
+func formatFacilitySeverityFrequencies(facilitySeverityFrequencies map[string]LogSeverityFrequency, options reportOptions) (lines []string) {
+	for _, facility := range getSortedFacilities(facilitySeverityFrequencies) {
+		logSeverityFrequency := facilitySeverityFrequencies[facility]
+		lines = append(lines, facility+": "+humanizeCount(getSeverityEntries(logSeverityFrequency), options)+" entries ("+
+			formatSeverityCounts(logSeverityFrequency, getSortedSeverities(logSeverityFrequency), options)+")")
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFacilitySeverityFrequencies(t *testing.T) {
+	options := analysisOptions{parser: parseSyslogMessage}
+	inputs := []string{
+		"Jan  2 15:04:05 web1 systemd[1]: Started Session 42 of user root.\n" +
+			"<34>Jan  2 15:04:06 web1 su[123]: 'su root' failed\n" +
+			"<38>Jan  2 15:04:07 web1 sshd[99]: Accepted publickey for root\n",
+		"<3>Jan  2 15:04:08 web1 kernel: Out of memory\n" +
+			"<134>Jan  2 15:04:09 web1 app[7]: Request served\n",
+	}
+	var logAnalyses []LogAnalysis
+	for _, input := range inputs {
+		logMessages, unlabeledMessages, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, options)
+		logAnalyses = append(logAnalyses, newLogAnalysis(stdinPath, logMessages, unlabeledMessages, stats, options))
+	}
+	logAnalysis := analyzelogAnalyses(logAnalyses)
+	want := map[string]LogSeverityFrequency{
+		"kern":     {"ERROR": 1},
+		"auth":     {"CRITICAL": 1, "INFO": 1},
+		"local0":   {"INFO": 1},
+		noFacility: {"INFO": 1},
+	}
+	if !reflect.DeepEqual(logAnalysis.facilitySeverityFrequencies, want) {
+		t.Errorf("Expected %v, got %v", want, logAnalysis.facilitySeverityFrequencies)
+	}
+	if got := getSortedFacilities(want); !reflect.DeepEqual(got, []string{"kern", "auth", "local0", noFacility}) {
+		t.Errorf("Expected syslog code order, got %q", got)
+	}
+
+	// Formats without a facility get no breakdown
+	plain := newLogAnalysis("a.log", []LogMessage{{timestamp: "2024-01-01 08:00:00", severity: "INFO", message: "Started"}}, nil, parseStats{lines: 1}, analysisOptions{})
+	if plain.facilitySeverityFrequencies != nil {
+		t.Errorf("Expected no facilities, got %v", plain.facilitySeverityFrequencies)
+	}
+
+	// Merged with files that have facilities, its entries have none either way
+	merged := analyzelogAnalyses(append([]LogAnalysis{plain}, logAnalyses...))
+	if got := merged.facilitySeverityFrequencies[noFacility]; !reflect.DeepEqual(got, LogSeverityFrequency{"INFO": 2}) {
+		t.Errorf("Expected 2 INFO entries without a facility after merging, got %v", got)
+	}
+}
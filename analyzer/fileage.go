@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"strconv"
+	"time"
+)
+
+// Files are put in an age tier by their latest entry rather than their
+// modification time, so a rotation directory copied or restored from backup
+// still sorts its files by what they hold.
+const (
+	ageTierToday   = "today"
+	ageTierWeek    = "last 7 days"
+	ageTierOlder   = "older"
+	ageTierUndated = "(no timestamps)"
+)
+
+var ageTiers = []string{ageTierToday, ageTierWeek, ageTierOlder, ageTierUndated}
+
+// ageTierOptions fixes the time every file is aged against, and the time
+// zone whose midnight starts today: UTC unless -timezone is given.
+type ageTierOptions struct {
+	now      time.Time
+	location *time.Location
+}
+
+type ageTierCount struct {
+	files                int
+	logSeverityFrequency LogSeverityFrequency
+}
+
+// getFileAgeTier puts a file whose latest entry is at endTime in today, the
+// seven days before it, or older.
+func getFileAgeTier(endTime time.Time, options ageTierOptions) string {
+	if endTime.IsZero() {
+		return ageTierUndated
+	}
+	location := options.location
+	if location == nil {
+		location = time.UTC
+	}
+	now := options.now.In(location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	switch {
+	case !endTime.Before(today):
+		return ageTierToday
+	case !endTime.Before(today.AddDate(0, 0, -7)):
+		return ageTierWeek
+	}
+	return ageTierOlder
+}
+
+// getAgeTierCounts counts one file's entries under its tier.
+func getAgeTierCounts(logSeverityFrequency LogSeverityFrequency, endTime time.Time, options ageTierOptions) map[string]ageTierCount {
+	var tierFrequency LogSeverityFrequency
+	mergeLogSeverityFrequency(&tierFrequency, logSeverityFrequency)
+	return map[string]ageTierCount{getFileAgeTier(endTime, options): {files: 1, logSeverityFrequency: tierFrequency}}
+}
+
+func mergeAgeTierCounts(into map[string]ageTierCount, from map[string]ageTierCount) {
+	for tier, count := range from {
+		merged := into[tier]
+		merged.files += count.files
+		mergeLogSeverityFrequency(&merged.logSeverityFrequency, count.logSeverityFrequency)
+		into[tier] = merged
+	}
+}
+
+// getSortedAgeTiers lists the tiers with files, newest first.
+func getSortedAgeTiers(ageTierCounts map[string]ageTierCount) (tiers []string) {
+	for _, tier := range ageTiers {
+		if _, ok := ageTierCounts[tier]; ok {
+			tiers = append(tiers, tier)
+		}
+	}
+	return
+}
+
+func formatAgeTierCounts(ageTierCounts map[string]ageTierCount, options reportOptions) (lines []string) {
+	for _, tier := range getSortedAgeTiers(ageTierCounts) {
+		count := ageTierCounts[tier]
+		lines = append(lines, tier+": "+strconv.Itoa(count.files)+" files, "+
+			humanizeCount(getSeverityEntries(count.logSeverityFrequency), options)+" entries ("+
+			formatSeverityCounts(count.logSeverityFrequency, getSortedSeverities(count.logSeverityFrequency), options)+")")
+	}
+	return
+}
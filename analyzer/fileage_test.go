@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetFileAgeTier(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	options := ageTierOptions{now: now}
+	tests := map[time.Time]string{
+		time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC):   ageTierToday,
+		time.Date(2024, 6, 9, 23, 59, 0, 0, time.UTC):  ageTierWeek,
+		time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC):    ageTierWeek,
+		time.Date(2024, 6, 2, 23, 59, 59, 0, time.UTC): ageTierOlder,
+		{}: ageTierUndated,
+	}
+	for endTime, want := range tests {
+		if got := getFileAgeTier(endTime, options); got != want {
+			t.Errorf("getFileAgeTier(%v) = %q, want %q", endTime, got, want)
+		}
+	}
+
+	// Today starts at midnight in the -timezone zone
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	endTime := time.Date(2024, 6, 10, 2, 0, 0, 0, time.UTC)
+	if got := getFileAgeTier(endTime, ageTierOptions{now: now, location: newYork}); got != ageTierWeek {
+		t.Errorf("getFileAgeTier() in New York = %q, want %q", got, ageTierWeek)
+	}
+}
+
+func TestAgeTierCounts(t *testing.T) {
+	options := analysisOptions{ageTiers: &ageTierOptions{now: time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)}}
+	files := [][]LogMessage{
+		{{timestamp: "2024-06-10 08:00:00.000", severity: "ERROR", message: "Timeout"}},
+		{{timestamp: "2024-06-10 09:00:00.000", severity: "INFO", message: "Started"}},
+		{{timestamp: "2024-05-01 09:00:00.000", severity: "INFO", message: "Started"}},
+	}
+	var logAnalyses []LogAnalysis
+	for _, logMessages := range files {
+		logAnalyses = append(logAnalyses, newLogAnalysis("app.log", logMessages, nil, parseStats{lines: 1}, options))
+	}
+	logAnalysis := analyzelogAnalyses(logAnalyses)
+	want := map[string]ageTierCount{
+		ageTierToday: {files: 2, logSeverityFrequency: LogSeverityFrequency{"ERROR": 1, "INFO": 1}},
+		ageTierOlder: {files: 1, logSeverityFrequency: LogSeverityFrequency{"INFO": 1}},
+	}
+	if !reflect.DeepEqual(logAnalysis.ageTierCounts, want) {
+		t.Errorf("ageTierCounts = %v, want %v", logAnalysis.ageTierCounts, want)
+	}
+	wantLines := []string{
+		"today: 2 files, 2 entries (DEBUG 0, INFO 1, WARNING 0, ERROR 1)",
+		"older: 1 files, 1 entries (DEBUG 0, INFO 1, WARNING 0, ERROR 0)",
+	}
+	if got := formatAgeTierCounts(logAnalysis.ageTierCounts, reportOptions{}); !reflect.DeepEqual(got, wantLines) {
+		t.Errorf("formatAgeTierCounts() = %q, want %q", got, wantLines)
+	}
+
+	// Without -age-tiers there is no breakdown
+	if plain := newLogAnalysis("app.log", files[0], nil, parseStats{lines: 1}, analysisOptions{}); plain.ageTierCounts != nil {
+		t.Errorf("ageTierCounts = %v, want nil", plain.ageTierCounts)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package analyzer
+
+import (
+	"os"
+)
+
+// fileID identifies a file by its device and inode, whatever its path.
+type fileID struct {
+	device uint64
+	inode  uint64
+}
+
+// getFileID reports false where os.Stat doesn't expose device and inode
+// numbers; callers fall back to os.SameFile or the path.
+func getFileID(fileInfo os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package analyzer
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file by its device and inode, whatever its path.
+type fileID struct {
+	device uint64
+	inode  uint64
+}
+
+// getFileID returns the device and inode of fileInfo, from os.Stat.
+func getFileID(fileInfo os.FileInfo) (fileID, bool) {
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{device: uint64(stat.Dev), inode: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+var foldedFrameEscaper = strings.NewReplacer(";", ":", "\n", " ")
+
+// formatFoldedStacks writes log volume in the collapsed stack format read by
+// flamegraph.pl, speedscope and most other flame graph viewers. Each stack is
+// time bucket, then the dotted module path one frame per segment, then the
+// severity, so the graph shows how volume is composed over time.
+func formatFoldedStacks(timeBucketCounts map[timeBucketKey]int64, interval time.Duration) string {
+	rebucketed := rebucketTimeBucketCounts(timeBucketCounts, interval)
+	var builder strings.Builder
+	for _, key := range getSortedTimeBucketKeys(rebucketed) {
+		frames := []string{key.start.UTC().Format(time.RFC3339)}
+		if key.module == "" {
+			frames = append(frames, "(no module)")
+		} else {
+			for _, segment := range strings.Split(key.module, ".") {
+				frames = append(frames, foldedFrameEscaper.Replace(segment))
+			}
+		}
+		if key.severity != "" {
+			frames = append(frames, foldedFrameEscaper.Replace(key.severity))
+		}
+		builder.WriteString(strings.Join(frames, ";") + " " + strconv.FormatInt(rebucketed[key], 10) + "\n")
+	}
+	return builder.String()
+}
+
+func writeFoldedStacksFile(foldedPath string, timeBucketCounts map[timeBucketKey]int64, interval time.Duration, key []byte) error {
+	return writeOutputFile(foldedPath, []byte(formatFoldedStacks(timeBucketCounts, interval)), key)
+}
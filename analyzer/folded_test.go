@@ -0,0 +1,23 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatFoldedStacks(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:10:00.000", module: "app.db", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:50:00.000", module: "app.db", severity: "ERROR"},
+		{timestamp: "2024-01-01 01:05:00.000", module: "app;web", severity: "INFO"},
+		{timestamp: "2024-01-01 01:06:00.000", severity: "INFO"},
+	}
+
+	got := formatFoldedStacks(getTimeBucketCounts(testLogs), time.Hour)
+	want := "2024-01-01T00:00:00Z;app;db;ERROR 2\n" +
+		"2024-01-01T01:00:00Z;(no module);INFO 1\n" +
+		"2024-01-01T01:00:00Z;app:web;INFO 1\n"
+	if got != want {
+		t.Errorf("formatFoldedStacks() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,258 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// followedFile tracks how far a followed file has been read. Lines are only
+// analyzed once their newline has arrived; the rest waits in partial.
+type followedFile struct {
+	logPath  string
+	file     *os.File
+	fileInfo os.FileInfo
+	offset   int64
+	partial  []byte
+	// rotatedFile is the file rotation replaced, open until its last lines
+	// have been read
+	rotatedFile *os.File
+	// parser keeps a stateful format's state across reads of the file
+	parser Parser
+}
+
+// followBlockSize is how much of a file is searched at a time for the last
+// newline.
+const followBlockSize = 64 * 1024
+
+// readNewLines returns a reader of the complete lines appended since the last
+// call, or nil when there are none. A file replaced by rotation is read to
+// its end before the new file is opened, and a truncated one is reread from
+// the start, like tail -F. Only the unfinished last line is held in memory;
+// the lines are read from the file as the reader is, so the first poll of a
+// large file streams it like any other input.
+func (followed *followedFile) readNewLines() (lines io.Reader, err error) {
+	if followed.rotatedFile != nil {
+		followed.rotatedFile.Close()
+		followed.rotatedFile = nil
+	}
+	currentInfo, err := os.Stat(followed.logPath)
+	if err != nil {
+		// The file may be between rotation steps; try again next time
+		return nil, nil
+	}
+	var readers []io.Reader
+	if followed.file == nil || !os.SameFile(followed.fileInfo, currentInfo) {
+		if followed.file != nil {
+			// Lines written between the last poll and the rotation are only in
+			// the old file
+			if rotatedInfo, statErr := followed.file.Stat(); statErr == nil {
+				if rotatedLines, readErr := followed.readLines(followed.file, rotatedInfo.Size()); readErr == nil && rotatedLines != nil {
+					readers = append(readers, rotatedLines)
+				}
+			}
+			if len(followed.partial) > 0 {
+				// Nothing will finish the old file's last line
+				readers = append(readers, bytes.NewReader(append(followed.partial, '\n')))
+			}
+			followed.rotatedFile = followed.file
+			followed.file = nil
+		}
+		followed.offset = 0
+		followed.partial = nil
+		if followed.file, err = os.Open(followed.logPath); err != nil {
+			followed.file = nil
+			return joinReaders(readers), err
+		}
+		followed.fileInfo = currentInfo
+	}
+	if currentInfo.Size() < followed.offset {
+		followed.offset = 0
+		followed.partial = nil
+	}
+	newLines, err := followed.readLines(followed.file, currentInfo.Size())
+	if newLines != nil {
+		readers = append(readers, newLines)
+	}
+	return joinReaders(readers), err
+}
+
+// readLines returns a reader of the complete lines of file from the offset
+// to size, after the partial line before them, or nil when no line was
+// finished. What follows the last newline becomes the partial line.
+func (followed *followedFile) readLines(file *os.File, size int64) (io.Reader, error) {
+	end, err := findLastNewline(file, followed.offset, size)
+	if err != nil {
+		return nil, err
+	}
+	tail := make([]byte, size-end)
+	if _, err := file.ReadAt(tail, end); err != nil {
+		return nil, err
+	}
+	var lines io.Reader
+	if end > followed.offset {
+		lines = io.MultiReader(bytes.NewReader(followed.partial), io.NewSectionReader(file, followed.offset, end-followed.offset))
+		followed.partial = tail
+	} else {
+		followed.partial = append(followed.partial, tail...)
+	}
+	followed.offset = size
+	return lines, nil
+}
+
+// findLastNewline returns the offset after the last newline of file between
+// start and end, or start when there is none.
+func findLastNewline(file *os.File, start int64, end int64) (int64, error) {
+	block := make([]byte, followBlockSize)
+	for end > start {
+		blockStart := max(start, end-followBlockSize)
+		data := block[:end-blockStart]
+		if _, err := file.ReadAt(data, blockStart); err != nil {
+			return 0, err
+		}
+		if index := bytes.LastIndexByte(data, '\n'); index >= 0 {
+			return blockStart + int64(index) + 1, nil
+		}
+		end = blockStart
+	}
+	return start, nil
+}
+
+func joinReaders(readers []io.Reader) io.Reader {
+	if len(readers) == 0 {
+		return nil
+	}
+	return io.MultiReader(readers...)
+}
+
+func (followed *followedFile) close() {
+	if followed.file != nil {
+		followed.file.Close()
+	}
+	if followed.rotatedFile != nil {
+		followed.rotatedFile.Close()
+	}
+}
+
+// followLogFiles analyzes logPaths and keeps folding newly appended lines
+// into each file's analysis, polling every interval until ctx is done.
+// update receives the merged analysis after the first pass and after every
+// poll that found new lines.
+func followLogFiles(ctx context.Context, logPaths []string, options analysisOptions, interval time.Duration, update func(logAnalysis LogAnalysis)) error {
+	var followedFiles []*followedFile
+	for _, logPath := range logPaths {
+		if logPath == stdinPath {
+			return errors.New("standard input cannot be followed")
+		}
+		followed := &followedFile{logPath: logPath}
+		if options.newParser != nil {
+			followed.parser = options.newParser()
+		}
+		followedFiles = append(followedFiles, followed)
+	}
+	defer func() {
+		for _, followed := range followedFiles {
+			followed.close()
+		}
+	}()
+
+	fileLogAnalyses := make([]LogAnalysis, len(followedFiles))
+	seen := make([]bool, len(followedFiles))
+	poll := func() (changed bool) {
+		for index, followed := range followedFiles {
+			lines, err := followed.readNewLines()
+			if err != nil {
+				metrics.counter("files_failed").Add(1)
+			}
+			if lines == nil {
+				continue
+			}
+			fileOptions := options
+			if followed.parser != nil {
+				fileOptions.parser = followed.parser
+				fileOptions.newParser = nil
+			}
+			batch := analyzeLogReader(ctx, lines, followed.logPath, fileOptions)
+			if seen[index] {
+				// Warnings about the whole file only make sense for the first read
+				batch.dataQualityWarnings = nil
+				batch = analyzelogAnalyses([]LogAnalysis{fileLogAnalyses[index], batch})
+				batch.logPath = followed.logPath
+			}
+			fileLogAnalyses[index] = batch
+			seen[index] = true
+			changed = true
+		}
+		return
+	}
+
+	poll()
+	update(analyzelogAnalyses(fileLogAnalyses))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if poll() {
+				update(analyzelogAnalyses(fileLogAnalyses))
+			}
+		}
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	fileInfo, err := file.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// runFollow re-renders the text report whenever followed files grow, and
+// evaluates alert rules against each update, sending every alert only once.
+func runFollow(logPaths []string, options analysisOptions, reporting reportOptions, interval time.Duration, alertRulesPath string) {
+	runLive(reporting, alertRulesPath, func(ctx context.Context, update func(LogAnalysis)) error {
+		return followLogFiles(ctx, logPaths, options, interval, update)
+	})
+}
+
+// runLive renders every analysis source passes to update until Ctrl-C, for
+// -follow and -gelf-udp.
+func runLive(reporting reportOptions, alertRulesPath string, source func(ctx context.Context, update func(LogAnalysis)) error) {
+	var alertRules alertConfig
+	if alertRulesPath != "" {
+		var err error
+		if alertRules, err = loadAlertRules(alertRulesPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading alert rules:", err)
+			os.Exit(exitUsage)
+		}
+		alertRules.handledAlerts = make(map[handledAlert]bool)
+	}
+	clearScreen := isTerminal(os.Stdout)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// A second signal kills an update that is slow to stop
+	context.AfterFunc(ctx, stop)
+	err := source(ctx, func(logAnalysis LogAnalysis) {
+		if clearScreen {
+			fmt.Print("\033[H\033[2J")
+		} else {
+			fmt.Println("=== " + time.Now().Format(layout) + " ===")
+		}
+		printLogAnalysis(logAnalysis, reporting)
+		if alertRules.handledAlerts != nil {
+			if _, _, err := evaluateAlertRules(alertRules, logAnalysis.timeBucketCounts, time.Now()); err != nil {
+				fmt.Fprintln(os.Stderr, "Error sending alerts:", err)
+			}
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+}
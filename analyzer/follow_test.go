@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowLogFiles(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte("2024-01-01 00:00:00.000 | INFO | app.module: function: 1 - User logged in\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan LogAnalysis, 16)
+	done := make(chan error)
+	go func() {
+		done <- followLogFiles(ctx, []string{logPath}, analysisOptions{}, 10*time.Millisecond, func(logAnalysis LogAnalysis) {
+			updates <- logAnalysis
+		})
+	}()
+
+	if first := <-updates; first.numEntries != 1 {
+		t.Fatalf("Expected 1 entry on the first pass, got %d", first.numEntries)
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second line is only complete once its newline is written
+	logFile.WriteString("2024-01-01 00:01:00.000 | ERROR | app.module: function: 2 - Database error\n2024-01-01 00:02:00.000 | ERROR")
+	logFile.Sync()
+	if second := <-updates; second.numEntries != 2 || second.logSeverityFrequency["ERROR"] != 1 {
+		t.Errorf("Expected 2 entries with 1 error after appending, got %d with %d", second.numEntries, second.logSeverityFrequency["ERROR"])
+	}
+	logFile.WriteString(" | app.module: function: 3 - Database error\n")
+	logFile.Close()
+	third := <-updates
+	if third.numEntries != 3 || third.topFiveLogMessages[0] != "Database error" {
+		t.Errorf("Expected 3 entries led by Database error, got %d led by %q", third.numEntries, third.topFiveLogMessages[0])
+	}
+	if !third.endTime.Equal(time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)) {
+		t.Errorf("Expected the end time to move with the appended lines, got %v", third.endTime)
+	}
+
+	// A truncated file is read again from the start
+	if err := os.WriteFile(logPath, []byte("2024-01-02 00:00:00.000 | WARNING | app.module: function: 4 - Low memory\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fourth := <-updates; fourth.numEntries != 4 || fourth.logSeverityFrequency["WARNING"] != 1 {
+		t.Errorf("Expected the rewritten file to add 1 warning, got %d entries with %d warnings", fourth.numEntries, fourth.logSeverityFrequency["WARNING"])
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadNewLinesDrainsRotatedFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	followed := &followedFile{logPath: logPath}
+	defer followed.close()
+	if lines, err := readAllNewLines(followed); err != nil || lines != "first\n" {
+		t.Fatalf("readNewLines() = %q, %v, want %q", lines, err, "first\n")
+	}
+
+	// Lines written just before the rotation are still read from the old file
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile.WriteString("second\nthird")
+	logFile.Close()
+	if err := os.Rename(logPath, logPath+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("fourth\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := "second\nthird\nfourth\n"
+	if lines, err := readAllNewLines(followed); err != nil || lines != want {
+		t.Errorf("readNewLines() = %q, %v, want %q", lines, err, want)
+	}
+}
+
+func readAllNewLines(followed *followedFile) (string, error) {
+	lines, err := followed.readNewLines()
+	if err != nil || lines == nil {
+		return "", err
+	}
+	data, err := io.ReadAll(lines)
+	return string(data), err
+}
+
+func TestReadNewLinesKeepsUnfinishedLine(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	// The last newline is more than a search block before the end
+	complete := strings.Repeat("line\n", 1000)
+	unfinished := strings.Repeat("x", followBlockSize+10)
+	if err := os.WriteFile(logPath, []byte(complete+unfinished), 0644); err != nil {
+		t.Fatal(err)
+	}
+	followed := &followedFile{logPath: logPath}
+	defer followed.close()
+	if lines, err := readAllNewLines(followed); err != nil || lines != complete {
+		t.Fatalf("readNewLines() = %d bytes, %v, want %d", len(lines), err, len(complete))
+	}
+	if lines, err := readAllNewLines(followed); err != nil || lines != "" {
+		t.Errorf("readNewLines() without new lines = %q, %v", lines, err)
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile.WriteString("end\nnext")
+	logFile.Close()
+	if lines, err := readAllNewLines(followed); err != nil || lines != unfinished+"end\n" {
+		t.Errorf("readNewLines() = %d bytes, %v, want the finished line", len(lines), err)
+	}
+}
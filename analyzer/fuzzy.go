@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyQuery matches messages containing every one of its words, each
+// allowing a few typos: "databse conection" finds "Database connection
+// refused".
+type fuzzyQuery struct {
+	tokens []string
+}
+
+// getFuzzyTokens splits text into lowercase words and numbers.
+func getFuzzyTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(character rune) bool {
+		return !unicode.IsLetter(character) && !unicode.IsDigit(character)
+	})
+}
+
+func newFuzzyQuery(query string) fuzzyQuery {
+	return fuzzyQuery{tokens: getFuzzyTokens(query)}
+}
+
+// getMaxTokenEdits allows no typos in words of up to three letters, one
+// in words of up to six and two in longer ones.
+func getMaxTokenEdits(token []rune) int {
+	switch {
+	case len(token) <= 3:
+		return 0
+	case len(token) <= 6:
+		return 1
+	}
+	return 2
+}
+
+// getEditDistance counts the insertions, deletions and substitutions that
+// turn a into b.
+func getEditDistance(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for index := range previous {
+		previous[index] = index
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// matches reports whether every query word is within its edit budget of
+// some word of message. An empty query matches everything.
+func (query fuzzyQuery) matches(message string) bool {
+	messageTokens := getFuzzyTokens(message)
+	for _, token := range query.tokens {
+		queryRunes := []rune(token)
+		maxEdits := getMaxTokenEdits(queryRunes)
+		found := false
+		for _, messageToken := range messageTokens {
+			messageRunes := []rune(messageToken)
+			// Lengths further apart than the budget can't be close enough
+			lengthDifference := len(messageRunes) - len(queryRunes)
+			if lengthDifference > maxEdits || -lengthDifference > maxEdits {
+				continue
+			}
+			if getEditDistance(queryRunes, messageRunes) <= maxEdits {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
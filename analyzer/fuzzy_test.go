@@ -0,0 +1,43 @@
+package analyzer
+
+import "testing"
+
+func TestGetEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"databse", "database", 1},
+		{"conection", "connection", 1},
+		{"timeout", "timeout", 0},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := getEditDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("getEditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyQueryMatches(t *testing.T) {
+	query := newFuzzyQuery("databse conection")
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"Database connection refused", true},
+		{"Connection to DATABASE lost", true},
+		{"Database timeout", false},
+		{"Cache connection refused", false},
+	}
+	for _, tt := range tests {
+		if got := query.matches(tt.message); got != tt.want {
+			t.Errorf("matches(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+	// Short words have to match exactly
+	if newFuzzyQuery("got 404").matches("got 405") {
+		t.Errorf("Expected a three-digit code to need an exact match")
+	}
+}
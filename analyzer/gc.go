@@ -0,0 +1,266 @@
+package analyzer
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Full GCs are counted per hour unless -bucket asks for another width.
+const defaultFullGCInterval = time.Hour
+
+var (
+	jvmGCDecorationPattern = regexp.MustCompile(`^\[([^\]]*)\]\s*`)
+	jvmGCUptimePattern     = regexp.MustCompile(`^[\d.,]+m?s$`)
+	jvmGCTagsPattern       = regexp.MustCompile(`^[a-z0-9_]+(?:,[a-z0-9_]+)*$`)
+	jvmGCIDPattern         = regexp.MustCompile(`^GC\((\d+)\)\s*`)
+	// 512M->128M(1024M) 12.345ms as logged by G1 and the other collectors,
+	// or 1024M(10%)->512M(5%) by ZGC
+	jvmGCHeapPattern  = regexp.MustCompile(`\s(\d+)([KMG])(?:\(\d+%\))?->(\d+)([KMG])(?:\(\d+%\))?(?:\((\d+)([KMG])\))?(?:\s+([\d.]+)ms)?$`)
+	jvmGCPausePattern = regexp.MustCompile(`\s([\d.]+)ms$`)
+)
+
+var jvmGCLevels = map[string]string{
+	"trace":   "TRACE",
+	"debug":   "DEBUG",
+	"info":    "INFO",
+	"warning": "WARNING",
+	"error":   "ERROR",
+}
+
+func parseJVMGCSize(value string, unit string) int64 {
+	size, _ := strconv.ParseInt(value, 10, 64)
+	switch unit {
+	case "K":
+		return size << 10
+	case "M":
+		return size << 20
+	}
+	return size << 30
+}
+
+// parseJVMGCMessage reads JDK 9+ unified GC logging, as written with
+// -Xlog:gc*:file=gc.log:time,uptime,level,tags. A time or utctime
+// decoration is required; the tags become the module. The GC(n) prefix,
+// heap transition and pause time are kept as the gc_id, heap_before,
+// heap_after, heap_total and pause_ms attributes, so the message is the
+// event itself, like "Pause Young (Normal) (G1 Evacuation Pause)".
+func parseJVMGCMessage(logRow string) (logMessage LogMessage, err error) {
+	logMessage.attributes = make(map[string]string)
+	logMessage.severity = "INFO"
+	body := strings.TrimSpace(logRow)
+	decorated := false
+	for {
+		match := jvmGCDecorationPattern.FindStringSubmatch(body)
+		if match == nil {
+			break
+		}
+		decorated = true
+		body = body[len(match[0]):]
+		decoration := strings.TrimSpace(match[1])
+		if severity, ok := jvmGCLevels[decoration]; ok {
+			logMessage.severity = severity
+		} else if timestamp, timeErr := normalizeTimestamp(decoration, "2006-01-02T15:04:05.000-0700", time.RFC3339Nano); timeErr == nil {
+			logMessage.timestamp = timestamp
+		} else if jvmGCUptimePattern.MatchString(decoration) {
+			logMessage.attributes["uptime"] = decoration
+		} else if jvmGCTagsPattern.MatchString(decoration) {
+			logMessage.module = decoration
+		}
+	}
+	if !decorated {
+		return logMessage, errMissingDelimiter
+	}
+	if logMessage.timestamp == "" {
+		return logMessage, errBadTimestamp
+	}
+	if match := jvmGCIDPattern.FindStringSubmatch(body); match != nil {
+		logMessage.attributes["gc_id"] = match[1]
+		body = body[len(match[0]):]
+	}
+	if match := jvmGCHeapPattern.FindStringSubmatchIndex(body); match != nil {
+		values := func(group int) string {
+			if match[2*group] < 0 {
+				return ""
+			}
+			return body[match[2*group]:match[2*group+1]]
+		}
+		logMessage.attributes["heap_before"] = strconv.FormatInt(parseJVMGCSize(values(1), values(2)), 10)
+		logMessage.attributes["heap_after"] = strconv.FormatInt(parseJVMGCSize(values(3), values(4)), 10)
+		if values(5) != "" {
+			logMessage.attributes["heap_total"] = strconv.FormatInt(parseJVMGCSize(values(5), values(6)), 10)
+		}
+		if values(7) != "" {
+			logMessage.attributes["pause_ms"] = values(7)
+		}
+		body = body[:match[0]]
+	} else if match := jvmGCPausePattern.FindStringSubmatchIndex(body); match != nil {
+		logMessage.attributes["pause_ms"] = body[match[2]:match[3]]
+		body = body[:match[0]]
+	}
+	// Only stop-the-world pauses count towards pause times
+	if !strings.HasPrefix(body, "Pause ") {
+		delete(logMessage.attributes, "pause_ms")
+	}
+	logMessage.message = body
+	return
+}
+
+// getJVMGCPauseType names a pause event without its cause, like
+// "Pause Young" for "Pause Young (Normal) (G1 Evacuation Pause)".
+func getJVMGCPauseType(event string) string {
+	pauseType, _, _ := strings.Cut(event, " (")
+	return pauseType
+}
+
+// gcProfile aggregates the attributes of the jvm-gc preset. Allocation is
+// the heap growth between one collection and the next, over the time
+// between them.
+type gcProfile struct {
+	pauses         []float64
+	pauseTypes     map[string]int64
+	allocatedBytes int64
+	allocationTime time.Duration
+	fullGCCounts   map[timeBucketKey]int64
+}
+
+func newGCProfile() gcProfile {
+	return gcProfile{
+		pauseTypes:   make(map[string]int64),
+		fullGCCounts: make(map[timeBucketKey]int64),
+	}
+}
+
+// getGCProfile reads the entries in log order, as a JVM wrote them, which
+// allocation rates depend on.
+func getGCProfile(logMessages []LogMessage) (profile gcProfile) {
+	profile = newGCProfile()
+	var previousHeapAfter int64
+	var previousTime time.Time
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		if value := logMessage.attributes["pause_ms"]; value != "" {
+			pause, _ := strconv.ParseFloat(value, 64)
+			pauseType := getJVMGCPauseType(logMessage.message)
+			profile.pauses = append(profile.pauses, pause)
+			profile.pauseTypes[pauseType] += 1
+			if pauseType == "Pause Full" {
+				profile.fullGCCounts[timeBucketKey{start: timestamp.Truncate(bucketResolution), module: logMessage.module, severity: logMessage.severity}] += 1
+			}
+		}
+		heapBefore, err := strconv.ParseInt(logMessage.attributes["heap_before"], 10, 64)
+		if err != nil {
+			continue
+		}
+		heapAfter, _ := strconv.ParseInt(logMessage.attributes["heap_after"], 10, 64)
+		if !previousTime.IsZero() && heapBefore >= previousHeapAfter && timestamp.After(previousTime) {
+			profile.allocatedBytes += heapBefore - previousHeapAfter
+			profile.allocationTime += timestamp.Sub(previousTime)
+		}
+		previousHeapAfter = heapAfter
+		previousTime = timestamp
+	}
+	return
+}
+
+func mergeGCProfiles(into *gcProfile, from gcProfile) {
+	if from.pauseTypes == nil {
+		return
+	}
+	if into.pauseTypes == nil {
+		*into = newGCProfile()
+	}
+	into.pauses = append(into.pauses, from.pauses...)
+	for pauseType, count := range from.pauseTypes {
+		into.pauseTypes[pauseType] += count
+	}
+	into.allocatedBytes += from.allocatedBytes
+	into.allocationTime += from.allocationTime
+	mergeTimeBucketCounts(into.fullGCCounts, from.fullGCCounts)
+}
+
+func getFullGCInterval(logAnalysis LogAnalysis) time.Duration {
+	if logAnalysis.histogramBucket > 0 {
+		return logAnalysis.histogramBucket
+	}
+	return defaultFullGCInterval
+}
+
+// getPercentile returns the nearest-rank percentile of sorted values.
+func getPercentile(sorted []float64, percentile float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+type gcPauseSummary struct {
+	count              int
+	total              float64
+	p50, p90, p99, max float64
+}
+
+func getGCPauseSummary(pauses []float64) (summary gcPauseSummary) {
+	sorted := append([]float64(nil), pauses...)
+	sort.Float64s(sorted)
+	summary.count = len(sorted)
+	for _, pause := range sorted {
+		summary.total += pause
+	}
+	summary.p50 = getPercentile(sorted, 50)
+	summary.p90 = getPercentile(sorted, 90)
+	summary.p99 = getPercentile(sorted, 99)
+	if len(sorted) > 0 {
+		summary.max = sorted[len(sorted)-1]
+	}
+	return
+}
+
+func (profile gcProfile) getAllocationRate() float64 {
+	if profile.allocationTime <= 0 {
+		return 0
+	}
+	return float64(profile.allocatedBytes) / profile.allocationTime.Seconds()
+}
+
+func formatMilliseconds(milliseconds float64) string {
+	return strconv.FormatFloat(milliseconds, 'f', 3, 64) + "ms"
+}
+
+// formatGCProfile summarizes pauses, allocation and full GCs; span is the
+// analyzed time span the pause time is a share of.
+func formatGCProfile(profile gcProfile, span time.Duration, interval time.Duration, options reportOptions) (lines []string) {
+	summary := getGCPauseSummary(profile.pauses)
+	line := "Pauses: " + humanizeCount(int64(summary.count), options) + ", total " + formatMilliseconds(summary.total)
+	if span > 0 {
+		line += " (" + formatPercent(summary.total/float64(span.Milliseconds())) + " of the time span)"
+	}
+	lines = append(lines, line)
+	if summary.count > 0 {
+		lines = append(lines, "Pause Times: p50 "+formatMilliseconds(summary.p50)+", p90 "+formatMilliseconds(summary.p90)+
+			", p99 "+formatMilliseconds(summary.p99)+", max "+formatMilliseconds(summary.max))
+	}
+	if len(profile.pauseTypes) > 0 {
+		lines = append(lines, "Pause Types: ")
+	}
+	for _, pauseType := range rankLogMessages(profile.pauseTypes) {
+		lines = append(lines, "   "+pauseType+": "+humanizeCount(profile.pauseTypes[pauseType], options))
+	}
+	if profile.allocationTime > 0 {
+		lines = append(lines, "Allocation Rate: "+humanizeBytes(int64(profile.getAllocationRate()), options)+"/s")
+	}
+	if len(profile.fullGCCounts) > 0 {
+		lines = append(lines, "Full GCs per "+humanizeDuration(interval, reportOptions{})+": ")
+		for _, histogramLine := range formatHistogram(getHistogramBuckets(profile.fullGCCounts, interval), false, options) {
+			lines = append(lines, "   "+histogramLine)
+		}
+	}
+	return
+}
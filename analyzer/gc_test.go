@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseJVMGCMessage(t *testing.T) {
+	got, err := parseJVMGCMessage("[2024-01-02T15:00:10.012+0000][10.022s][info][gc          ] GC(7) Pause Young (Normal) (G1 Evacuation Pause) 512M->128M(1024M) 12.345ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAttributes := map[string]string{
+		"uptime":      "10.022s",
+		"gc_id":       "7",
+		"heap_before": "536870912",
+		"heap_after":  "134217728",
+		"heap_total":  "1073741824",
+		"pause_ms":    "12.345",
+	}
+	if got.timestamp != "2024-01-02 15:00:10.012" || got.severity != "INFO" || got.module != "gc" ||
+		got.message != "Pause Young (Normal) (G1 Evacuation Pause)" || !reflect.DeepEqual(got.attributes, wantAttributes) {
+		t.Errorf("parseJVMGCMessage() = %+v", got)
+	}
+
+	// Concurrent phases take time but do not pause the application
+	if got, _ := parseJVMGCMessage("[2024-01-02T15:00:30.000+0000][info][gc] GC(2) Concurrent Mark Cycle 120.5ms"); got.Attribute("pause_ms") != "" {
+		t.Errorf("Expected no pause for a concurrent phase, got %+v", got)
+	}
+	if _, err := parseJVMGCMessage("[10.022s][info][gc] Using G1"); err != errBadTimestamp {
+		t.Errorf("Expected lines without a time decoration to be rejected, got %v", err)
+	}
+}
+
+func TestGetGCProfile(t *testing.T) {
+	var logMessages []LogMessage
+	for _, line := range []string{
+		"[2024-01-02T15:00:10.000+0000][info][gc] GC(0) Pause Young (Normal) (G1 Evacuation Pause) 512M->128M(1024M) 10.000ms",
+		"[2024-01-02T15:00:20.000+0000][info][gc] GC(1) Pause Young (Normal) (G1 Evacuation Pause) 448M->128M(1024M) 20.000ms",
+		"[2024-01-02T15:00:30.000+0000][info][gc] GC(2) Pause Full (System.gc()) 448M->100M(1024M) 300.000ms",
+		"[2024-01-02T16:10:00.000+0000][info][gc] GC(3) Pause Full (Allocation Failure) 1000M->100M(1024M) 400.000ms",
+	} {
+		logMessage, err := parseJVMGCMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logMessages = append(logMessages, logMessage)
+	}
+	profile := getGCProfile(logMessages)
+	summary := getGCPauseSummary(profile.pauses)
+	if summary.count != 4 || summary.total != 730 || summary.p50 != 20 || summary.p99 != 400 {
+		t.Errorf("Unexpected pause summary %+v", summary)
+	}
+	if !reflect.DeepEqual(profile.pauseTypes, map[string]int64{"Pause Young": 2, "Pause Full": 2}) {
+		t.Errorf("Unexpected pause types %v", profile.pauseTypes)
+	}
+	// 320M and 320M in the first 20s, then 900M over the next 69m30s
+	if profile.allocatedBytes != 1540<<20 || profile.allocationTime != 20*time.Second+69*time.Minute+30*time.Second {
+		t.Errorf("Expected 1540M allocated over 69m50s, got %d over %v", profile.allocatedBytes, profile.allocationTime)
+	}
+
+	buckets := getHistogramBuckets(profile.fullGCCounts, time.Hour)
+	if len(buckets) != 2 || buckets[0].entries != 1 || buckets[1].entries != 1 {
+		t.Errorf("Expected one full GC in each hour, got %+v", buckets)
+	}
+
+	var merged gcProfile
+	mergeGCProfiles(&merged, profile)
+	mergeGCProfiles(&merged, profile)
+	if len(merged.pauses) != 8 || merged.pauseTypes["Pause Full"] != 4 || merged.getAllocationRate() != profile.getAllocationRate() {
+		t.Errorf("Unexpected merged profile %+v", merged)
+	}
+}
@@ -0,0 +1,272 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gelfChunkMagic0    = 0x1e
+	gelfChunkMagic1    = 0x0f
+	gelfChunkHeaderLen = 12
+	gelfMaxChunks      = 128
+	// Incomplete chunked messages are dropped after this long, per the spec.
+	gelfChunkTimeout = 5 * time.Second
+	// Decompressed messages larger than this are dropped rather than buffered.
+	gelfMaxMessageSize = 8 << 20
+	gelfUDPPath        = "gelf-udp"
+)
+
+var errGELFMessage = errors.New("bad GELF message")
+
+func formatGELFValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(typed)
+		return string(data)
+	}
+}
+
+// parseGELFMessage reads one GELF 1.1 JSON message, as written to files one
+// per line or sent over TCP with a null terminator. Additional fields are kept
+// as attributes without their leading underscore; the logger name, facility
+// or host becomes the module.
+func parseGELFMessage(logRow string) (logMessage LogMessage, err error) {
+	decoder := json.NewDecoder(strings.NewReader(strings.TrimRight(logRow, "\x00")))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err = decoder.Decode(&fields); err != nil {
+		return logMessage, errMissingDelimiter
+	}
+	shortMessage, ok := fields["short_message"].(string)
+	if !ok {
+		return logMessage, errMissingDelimiter
+	}
+	// The spec lets the receiver fill in a missing timestamp and level
+	timestamp := time.Now()
+	if value, ok := fields["timestamp"].(json.Number); ok {
+		seconds, parseErr := value.Float64()
+		if parseErr != nil {
+			return logMessage, errBadTimestamp
+		}
+		whole, fraction := math.Modf(seconds)
+		timestamp = time.Unix(int64(whole), int64(math.Round(fraction*1e3))*int64(time.Millisecond))
+	}
+	level := int64(1)
+	if value, ok := fields["level"].(json.Number); ok {
+		if level, err = value.Int64(); err != nil {
+			return logMessage, errMissingSeverity
+		}
+	}
+	logMessage.timestamp = timestamp.UTC().Format(layout)
+	logMessage.severity = getSyslogSeverity(level)
+	logMessage.message = shortMessage
+	logMessage.attributes = make(map[string]string)
+	for key, value := range fields {
+		if name, ok := strings.CutPrefix(key, "_"); ok {
+			logMessage.attributes[name] = formatGELFValue(value)
+		}
+	}
+	attributes := logMessage.attributes
+	for _, module := range []string{attributes["logger_name"], formatGELFValue(fields["facility"]), attributes["facility"], formatGELFValue(fields["host"])} {
+		if module != "" {
+			logMessage.module = module
+			break
+		}
+	}
+	logMessage.function = attributes["function"]
+	line := formatGELFValue(fields["line"])
+	if line == "" {
+		line = attributes["line"]
+	}
+	logMessage.lineNumber, _ = strconv.ParseInt(line, 10, 64)
+	logMessage.thread = attributes["thread_name"]
+	logMessage.pid = attributes["pid"]
+	logMessage.clientIP = attributes["client_ip"]
+	return
+}
+
+// decodeGELFPayload inflates a gzip or zlib compressed message and passes
+// uncompressed ones through.
+func decodeGELFPayload(payload []byte) ([]byte, error) {
+	var reader io.Reader
+	var err error
+	switch {
+	case len(payload) >= 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case len(payload) >= 2 && payload[0] == 0x78:
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(io.LimitReader(reader, gelfMaxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > gelfMaxMessageSize {
+		return nil, errGELFMessage
+	}
+	return decoded, nil
+}
+
+type gelfChunkedMessage struct {
+	chunks   [][]byte
+	received int
+	started  time.Time
+}
+
+// gelfAssembler reassembles chunked GELF datagrams. Chunks may arrive in any
+// order; messages still incomplete after gelfChunkTimeout are dropped.
+type gelfAssembler struct {
+	pending map[[8]byte]*gelfChunkedMessage
+}
+
+func newGELFAssembler() *gelfAssembler {
+	return &gelfAssembler{pending: make(map[[8]byte]*gelfChunkedMessage)}
+}
+
+// add takes one datagram and returns the complete message, still compressed
+// if it was sent that way, once every chunk of it has arrived.
+func (assembler *gelfAssembler) add(datagram []byte, now time.Time) (payload []byte, complete bool, err error) {
+	for id, message := range assembler.pending {
+		if now.Sub(message.started) > gelfChunkTimeout {
+			delete(assembler.pending, id)
+		}
+	}
+	if len(datagram) < 2 || datagram[0] != gelfChunkMagic0 || datagram[1] != gelfChunkMagic1 {
+		return datagram, true, nil
+	}
+	if len(datagram) < gelfChunkHeaderLen {
+		return nil, false, errGELFMessage
+	}
+	var id [8]byte
+	copy(id[:], datagram[2:10])
+	sequence, count := int(datagram[10]), int(datagram[11])
+	if count == 0 || count > gelfMaxChunks || sequence >= count {
+		return nil, false, errGELFMessage
+	}
+	message := assembler.pending[id]
+	if message == nil {
+		message = &gelfChunkedMessage{chunks: make([][]byte, count), started: now}
+		assembler.pending[id] = message
+	}
+	if len(message.chunks) != count {
+		delete(assembler.pending, id)
+		return nil, false, errGELFMessage
+	}
+	if message.chunks[sequence] == nil {
+		message.chunks[sequence] = append([]byte(nil), datagram[gelfChunkHeaderLen:]...)
+		message.received++
+	}
+	if message.received < count {
+		return nil, false, nil
+	}
+	delete(assembler.pending, id)
+	return bytes.Join(message.chunks, nil), true, nil
+}
+
+// receiveGELF reads datagrams from conn until ctx is done, passing each
+// complete, decompressed message to handle. Malformed datagrams are counted
+// and dropped.
+func receiveGELF(ctx context.Context, conn net.PacketConn, handle func(message []byte)) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	assembler := newGELFAssembler()
+	buffer := make([]byte, 65536)
+	for {
+		size, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		payload, complete, err := assembler.add(buffer[:size], time.Now())
+		if err == nil && complete {
+			payload, err = decodeGELFPayload(payload)
+		}
+		if err != nil {
+			metrics.counter("gelf_dropped").Add(1)
+			continue
+		}
+		if complete {
+			handle(payload)
+		}
+	}
+}
+
+// listenGELF analyzes GELF messages arriving on the UDP address, folding
+// each interval's messages into a running analysis like followLogFiles, and
+// calls update after every interval that brought new messages.
+func listenGELF(ctx context.Context, address string, options analysisOptions, interval time.Duration, update func(logAnalysis LogAnalysis)) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return err
+	}
+	var mutex sync.Mutex
+	var received bytes.Buffer
+	receiveErr := make(chan error, 1)
+	go func() {
+		receiveErr <- receiveGELF(ctx, conn, func(message []byte) {
+			// Messages are one per line for the line parser
+			message = bytes.ReplaceAll(bytes.TrimRight(message, "\x00"), []byte("\n"), []byte(" "))
+			mutex.Lock()
+			received.Write(message)
+			received.WriteByte('\n')
+			mutex.Unlock()
+		})
+	}()
+
+	options.parser = parseGELFMessage
+	options.newParser = nil
+	var logAnalysis LogAnalysis
+	seen := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-receiveErr:
+			return err
+		case <-ticker.C:
+			mutex.Lock()
+			chunk := append([]byte(nil), received.Bytes()...)
+			received.Reset()
+			mutex.Unlock()
+			if len(chunk) == 0 {
+				continue
+			}
+			logMessages, unlabeledMessages, stats := parseLogReader(ctx, bytes.NewReader(chunk), gelfUDPPath, options)
+			batch := newLogAnalysis(gelfUDPPath, logMessages, unlabeledMessages, stats, options)
+			if seen {
+				batch.dataQualityWarnings = nil
+				batch = analyzelogAnalyses([]LogAnalysis{logAnalysis, batch})
+				batch.logPath = gelfUDPPath
+			}
+			logAnalysis = batch
+			seen = true
+			update(logAnalysis)
+		}
+	}
+}
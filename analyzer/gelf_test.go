@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseGELFMessage(t *testing.T) {
+	got, err := parseGELFMessage(`{"version":"1.1","host":"web-1","short_message":"Payment failed","timestamp":1704207845.25,"level":3,"_logger_name":"app.payments","_line":42,"_client_ip":"203.0.113.9","_order":17}` + "\x00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.timestamp != "2024-01-02 15:04:05.25" || got.severity != "ERROR" || got.module != "app.payments" ||
+		got.lineNumber != 42 || got.clientIP != "203.0.113.9" || got.Attribute("order") != "17" {
+		t.Errorf("parseGELFMessage() = %+v", got)
+	}
+
+	got, err = parseGELFMessage(`{"host":"web-1","short_message":"Started","timestamp":1704207845,"level":6}`)
+	if err != nil || got.severity != "INFO" || got.module != "web-1" {
+		t.Errorf("parseGELFMessage() = %+v, %v", got, err)
+	}
+	if _, err := parseGELFMessage(`{"host":"web-1"}`); err == nil {
+		t.Errorf("parseGELFMessage() accepted a message without short_message")
+	}
+}
+
+func TestGELFAssembler(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte(`{"host":"h","short_message":"Chunked","timestamp":1704207845}`))
+	writer.Close()
+	payload := compressed.Bytes()
+	half := len(payload) / 2
+	chunk := func(sequence byte, data []byte) []byte {
+		return append([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, sequence, 2}, data...)
+	}
+
+	assembler := newGELFAssembler()
+	now := time.Now()
+	// Chunks may arrive out of order
+	if _, complete, err := assembler.add(chunk(1, payload[half:]), now); complete || err != nil {
+		t.Fatalf("Expected the first chunk to wait for the second, got %v, %v", complete, err)
+	}
+	message, complete, err := assembler.add(chunk(0, payload[:half]), now)
+	if !complete || err != nil {
+		t.Fatalf("Expected the message to complete, got %v, %v", complete, err)
+	}
+	decoded, err := decodeGELFPayload(message)
+	if err != nil || !bytes.Contains(decoded, []byte("Chunked")) {
+		t.Errorf("decodeGELFPayload() = %q, %v", decoded, err)
+	}
+
+	// A message missing a chunk is dropped once it times out
+	assembler.add(chunk(0, payload[:half]), now)
+	if _, complete, _ := assembler.add(chunk(1, payload[half:]), now.Add(2*gelfChunkTimeout)); complete {
+		t.Errorf("Expected a timed out message to be dropped")
+	}
+}
+
+func TestListenGELF(t *testing.T) {
+	// Find a free port, then listen on it
+	probe, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("UDP unavailable:", err)
+	}
+	address := probe.LocalAddr().String()
+	probe.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	updates := make(chan LogAnalysis, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- listenGELF(ctx, address, analysisOptions{}, 20*time.Millisecond, func(logAnalysis LogAnalysis) {
+			updates <- logAnalysis
+		})
+	}()
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for {
+		conn.Write([]byte(`{"host":"h","short_message":"Over UDP","timestamp":1704207845,"level":4}`))
+		select {
+		case logAnalysis := <-updates:
+			if logAnalysis.logSeverityFrequency["WARNING"] == 0 {
+				t.Errorf("Expected a WARNING entry, got %+v", logAnalysis.logSeverityFrequency)
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("listenGELF() = %v", err)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("No update received")
+		}
+	}
+}
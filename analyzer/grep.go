@@ -0,0 +1,10 @@
+package analyzer
+
+import "regexp"
+
+// isExcludedMessage reports whether -grep or -exclude-grep drops an entry:
+// its message doesn't match grep, or matches excludeGrep. Nil patterns
+// keep everything.
+func isExcludedMessage(message string, grep *regexp.Regexp, excludeGrep *regexp.Regexp) bool {
+	return (grep != nil && !grep.MatchString(message)) || (excludeGrep != nil && excludeGrep.MatchString(message))
+}
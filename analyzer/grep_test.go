@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeLogFilesGrep(t *testing.T) {
+	tmpFileName := createTestLogFile(t, `2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Connection refused
+2024-01-01 01:00:00.000 | INFO | app.module: function: 2 - User logged in
+2024-01-01 01:30:00.000 | ERROR | app.module: function: 3 - Read timeout
+2024-01-01 02:00:00.000 | WARNING | app.module: function: 4 - Read timeout on replica`)
+	defer os.Remove(tmpFileName)
+
+	options := analysisOptions{
+		grep:        regexp.MustCompile(`timeout|refused`),
+		excludeGrep: regexp.MustCompile(`replica`),
+	}
+	analysis := analyzeLogFiles(context.Background(), []string{tmpFileName}, options)
+	if analysis.numEntries != 2 || analysis.logSeverityFrequency["ERROR"] != 2 {
+		t.Errorf("Expected the 2 matching errors, got %d entries and %v", analysis.numEntries, analysis.logSeverityFrequency)
+	}
+	// Timestamps come from the matching entries themselves
+	if !analysis.endTime.Equal(time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected the last match's end time, got %v", analysis.endTime)
+	}
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+)
+
+const histogramLabelLayout = "2006-01-02 15:04"
+
+// maxHistogramBuckets caps how many buckets getHistogramBuckets returns with
+// the empty ones filled in.
+const maxHistogramBuckets = 10000
+
+type histogramBucket struct {
+	start             time.Time
+	entries           int64
+	severityFrequency LogSeverityFrequency
+}
+
+// getHistogramBuckets counts entries per interval wide bucket from the first
+// to the last entry, keeping empty buckets so gaps in the log stay visible.
+// When that would take more than maxHistogramBuckets buckets, as a few wrong
+// timestamps years away from the rest easily do, only the buckets with
+// entries are returned.
+func getHistogramBuckets(timeBucketCounts map[timeBucketKey]int64, interval time.Duration) (buckets []histogramBucket) {
+	if interval < bucketResolution {
+		interval = bucketResolution
+	}
+	bucketsByStart := make(map[time.Time]*histogramBucket)
+	for key, count := range rebucketTimeBucketCounts(timeBucketCounts, interval) {
+		bucket := bucketsByStart[key.start]
+		if bucket == nil {
+			bucket = &histogramBucket{start: key.start}
+			bucketsByStart[key.start] = bucket
+		}
+		bucket.entries += count
+		addSeverityCount(&bucket.severityFrequency, key.severity, count)
+	}
+	if len(bucketsByStart) == 0 {
+		return
+	}
+	starts := make([]time.Time, 0, len(bucketsByStart))
+	for start := range bucketsByStart {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	if starts[len(starts)-1].Sub(starts[0])/interval >= maxHistogramBuckets {
+		for _, start := range starts {
+			buckets = append(buckets, *bucketsByStart[start])
+		}
+		return
+	}
+	for start := starts[0]; !start.After(starts[len(starts)-1]); start = start.Add(interval) {
+		if bucket := bucketsByStart[start]; bucket != nil {
+			buckets = append(buckets, *bucket)
+		} else {
+			buckets = append(buckets, histogramBucket{start: start})
+		}
+	}
+	return
+}
+
+// getSeveritiesMostSevereFirst orders the known severities from most to
+// least severe, with custom ones after them.
+func getSeveritiesMostSevereFirst(logSeverityFrequency LogSeverityFrequency) (severities []string) {
+	sorted := getSortedSeverities(logSeverityFrequency)
+	known := 0
+	for known < len(sorted) {
+		if _, ok := severityRanks[sorted[known]]; !ok {
+			break
+		}
+		known++
+	}
+	for index := known - 1; index >= 0; index-- {
+		severities = append(severities, sorted[index])
+	}
+	return append(severities, sorted[known:]...)
+}
+
+// formatHistogram draws one bar per bucket, scaled to the busiest one, with
+// the per-severity counts appended when bySeverity is set.
+func formatHistogram(buckets []histogramBucket, bySeverity bool, options reportOptions) (lines []string) {
+	var maxEntries int64
+	for _, bucket := range buckets {
+		if bucket.entries > maxEntries {
+			maxEntries = bucket.entries
+		}
+	}
+	for _, bucket := range buckets {
+		line := options.formatTime(bucket.start, histogramLabelLayout) + " " + formatFrequencyBar(bucket.entries, maxEntries) +
+			" " + humanizeCount(bucket.entries, options)
+		if bySeverity && bucket.entries > 0 {
+			line += " (" + formatSeverityCounts(bucket.severityFrequency, getSeveritiesMostSevereFirst(bucket.severityFrequency), options) + ")"
+		}
+		lines = append(lines, line)
+	}
+	return
+}
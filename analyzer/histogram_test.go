@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetHistogramBuckets(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:01:00.000", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:04:00.000", severity: "INFO"},
+		{timestamp: "2024-01-01 00:12:00.000", severity: "INFO"},
+	}
+
+	buckets := getHistogramBuckets(getTimeBucketCounts(testLogs), 5*time.Minute)
+	if len(buckets) != 3 {
+		t.Fatalf("Expected 3 buckets including the empty one, got %d", len(buckets))
+	}
+	if buckets[0].entries != 2 || !reflect.DeepEqual(buckets[0].severityFrequency, LogSeverityFrequency{"INFO": 1, "ERROR": 1}) || buckets[1].entries != 0 || buckets[2].entries != 1 {
+		t.Errorf("Unexpected buckets %+v", buckets)
+	}
+
+	lines := formatHistogram(buckets, true, reportOptions{})
+	want := "2024-01-01 00:00 [" + strings.Repeat("#", frequencyBarWidth) + "] 2 (ERROR 1, WARNING 0, INFO 1, DEBUG 0)"
+	if lines[0] != want || !strings.HasSuffix(lines[1], "] 0") {
+		t.Errorf("formatHistogram() = %q, want first line %q", lines, want)
+	}
+}
+
+func TestGetHistogramBucketsSkipsGapsOverCap(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "1970-01-01 00:00:00.000", severity: "INFO"},
+		{timestamp: "2024-01-01 00:00:00.000", severity: "INFO"},
+		{timestamp: "2024-01-01 00:01:00.000", severity: "ERROR"},
+	}
+
+	buckets := getHistogramBuckets(getTimeBucketCounts(testLogs), time.Minute)
+	if len(buckets) != 3 {
+		t.Fatalf("Expected only the 3 buckets with entries, got %d", len(buckets))
+	}
+	if !buckets[0].start.Equal(time.Unix(0, 0).UTC()) || buckets[2].entries != 1 {
+		t.Errorf("Unexpected buckets %+v", buckets)
+	}
+}
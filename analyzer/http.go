@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// httpTopCount is how many paths and clients the HTTP report lists.
+const httpTopCount = 10
+
+// Requests are counted per hour unless -bucket asks for another width.
+const defaultRequestRateInterval = time.Hour
+
+// httpProfile counts the requests of access logs by path, status code and
+// client, from the sc-status, cs-uri-stem and sc-bytes attributes the
+// access, w3c and iis presets keep.
+type httpProfile struct {
+	requests      int64
+	bytesSent     int64
+	pathCounts    map[string]int64
+	statusCounts  map[string]int64
+	clientCounts  map[string]int64
+	requestCounts map[timeBucketKey]int64
+}
+
+func newHTTPProfile() httpProfile {
+	return httpProfile{
+		pathCounts:    make(map[string]int64),
+		statusCounts:  make(map[string]int64),
+		clientCounts:  make(map[string]int64),
+		requestCounts: make(map[timeBucketKey]int64),
+	}
+}
+
+// getHTTPProfile counts the entries with a status code; others, like the
+// directives of w3c logs, aren't requests.
+func getHTTPProfile(logMessages []LogMessage) (profile httpProfile) {
+	profile = newHTTPProfile()
+	for _, logMessage := range logMessages {
+		status := logMessage.attributes["sc-status"]
+		if status == "" {
+			continue
+		}
+		profile.requests += 1
+		profile.statusCounts[status] += 1
+		if path := logMessage.attributes["cs-uri-stem"]; path != "" {
+			profile.pathCounts[path] += 1
+		}
+		if logMessage.clientIP != "" {
+			profile.clientCounts[logMessage.clientIP] += 1
+		}
+		bytesSent, _ := strconv.ParseInt(logMessage.attributes["sc-bytes"], 10, 64)
+		profile.bytesSent += bytesSent
+		if timestamp, err := time.Parse(layout, logMessage.timestamp); err == nil {
+			profile.requestCounts[timeBucketKey{start: timestamp.Truncate(bucketResolution), module: logMessage.module, severity: logMessage.severity}] += 1
+		}
+	}
+	return
+}
+
+func mergeHTTPProfiles(into *httpProfile, from httpProfile) {
+	if from.statusCounts == nil {
+		return
+	}
+	if into.statusCounts == nil {
+		*into = newHTTPProfile()
+	}
+	into.requests += from.requests
+	into.bytesSent += from.bytesSent
+	for path, count := range from.pathCounts {
+		into.pathCounts[path] += count
+	}
+	for status, count := range from.statusCounts {
+		into.statusCounts[status] += count
+	}
+	for clientIP, count := range from.clientCounts {
+		into.clientCounts[clientIP] += count
+	}
+	mergeTimeBucketCounts(into.requestCounts, from.requestCounts)
+}
+
+func getRequestRateInterval(logAnalysis LogAnalysis) time.Duration {
+	if logAnalysis.histogramBucket > 0 {
+		return logAnalysis.histogramBucket
+	}
+	return defaultRequestRateInterval
+}
+
+// getRequestsPerSecond is the average request rate over span, the analyzed
+// time span.
+func (profile httpProfile) getRequestsPerSecond(span time.Duration) float64 {
+	if span <= 0 {
+		return 0
+	}
+	return float64(profile.requests) / span.Seconds()
+}
+
+func getSortedStatusCodes(statusCounts map[string]int64) (statuses []string) {
+	for status := range statusCounts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return
+}
+
+func getTopHTTPKeys(counts map[string]int64) []string {
+	ranked := rankLogMessages(counts)
+	return ranked[:min(len(ranked), httpTopCount)]
+}
+
+// formatHTTPProfile summarizes the requests by status code, path and client
+// and over time; span is the analyzed time span the average rate is over.
+func formatHTTPProfile(profile httpProfile, span time.Duration, interval time.Duration, options reportOptions) (lines []string) {
+	line := "Requests: " + humanizeCount(profile.requests, options)
+	if rate := profile.getRequestsPerSecond(span); rate >= 1 {
+		line += ", " + strconv.FormatFloat(rate, 'f', 2, 64) + "/s on average"
+	} else if rate > 0 {
+		line += ", " + strconv.FormatFloat(rate*60, 'f', 2, 64) + "/min on average"
+	}
+	lines = append(lines, line, "Bytes Sent: "+humanizeBytes(profile.bytesSent, options))
+	if len(profile.statusCounts) > 0 {
+		lines = append(lines, "Status Codes: ")
+	}
+	for _, status := range getSortedStatusCodes(profile.statusCounts) {
+		count := profile.statusCounts[status]
+		lines = append(lines, "   "+status+": "+humanizeCount(count, options)+" ("+formatPercent(float64(count)/float64(profile.requests))+")")
+	}
+	if len(profile.pathCounts) > 0 {
+		lines = append(lines, "Top URLs: ")
+	}
+	for _, path := range getTopHTTPKeys(profile.pathCounts) {
+		lines = append(lines, "   "+path+": "+humanizeCount(profile.pathCounts[path], options))
+	}
+	if len(profile.clientCounts) > 0 {
+		lines = append(lines, "Top Clients: ")
+	}
+	for _, clientIP := range getTopHTTPKeys(profile.clientCounts) {
+		lines = append(lines, "   "+clientIP+": "+humanizeCount(profile.clientCounts[clientIP], options))
+	}
+	if len(profile.requestCounts) > 0 {
+		lines = append(lines, "Requests per "+humanizeDuration(interval, reportOptions{})+": ")
+		for _, histogramLine := range formatHistogram(getHistogramBuckets(profile.requestCounts, interval), options.histogramBySeverity, options) {
+			lines = append(lines, "   "+histogramLine)
+		}
+	}
+	return
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseAccessLines(t *testing.T, lines []string) (logMessages []LogMessage) {
+	for _, line := range lines {
+		logMessage, err := parseAccessMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logMessages = append(logMessages, logMessage)
+	}
+	return
+}
+
+func TestGetHTTPProfile(t *testing.T) {
+	first := getHTTPProfile(parseAccessLines(t, []string{
+		`203.0.113.9 - - [02/Jan/2024:15:04:05 +0000] "GET /index.html HTTP/1.1" 200 1000 "-" "Mozilla/5.0"`,
+		`203.0.113.9 - - [02/Jan/2024:15:04:06 +0000] "GET /index.html?lang=en HTTP/1.1" 200 1000 "https://example.com/" "Mozilla/5.0 \"quoted\""`,
+		`198.51.100.7 - alice [02/Jan/2024:15:30:00 +0000] "POST /login HTTP/1.1" 401 - "-" "curl/8.0"`,
+	}))
+	second := getHTTPProfile(parseAccessLines(t, []string{
+		`198.51.100.7 - - [02/Jan/2024:16:10:00 +0000] "GET /index.html HTTP/1.1" 503 24`,
+	}))
+	var profile httpProfile
+	mergeHTTPProfiles(&profile, first)
+	mergeHTTPProfiles(&profile, second)
+	if profile.requests != 4 || profile.bytesSent != 2024 {
+		t.Errorf("Expected 4 requests sending 2024 bytes, got %d and %d", profile.requests, profile.bytesSent)
+	}
+	if !reflect.DeepEqual(profile.statusCounts, map[string]int64{"200": 2, "401": 1, "503": 1}) {
+		t.Errorf("Unexpected status codes %v", profile.statusCounts)
+	}
+	if got := getTopHTTPKeys(profile.pathCounts); !reflect.DeepEqual(got, []string{"/index.html", "/login"}) {
+		t.Errorf("Unexpected top URLs %v", got)
+	}
+	if got := getTopHTTPKeys(profile.clientCounts); !reflect.DeepEqual(got, []string{"198.51.100.7", "203.0.113.9"}) {
+		t.Errorf("Unexpected top clients %v", got)
+	}
+
+	lines := formatHTTPProfile(profile, 2*time.Hour, time.Hour, reportOptions{exact: true})
+	report := strings.Join(lines, "\n")
+	for _, want := range []string{"Requests: 4, 0.03/min on average", "   200: 2 (50.0%)", "   /index.html: 3", "Requests per 1h: "} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected %q in\n%s", want, report)
+		}
+	}
+	if buckets := getHistogramBuckets(profile.requestCounts, time.Hour); len(buckets) != 2 || buckets[0].entries != 3 || buckets[1].entries != 1 {
+		t.Errorf("Unexpected request rate %+v", buckets)
+	}
+}
+
+func TestGetHTTPProfileSkipsNonRequests(t *testing.T) {
+	profile := getHTTPProfile([]LogMessage{{timestamp: "2024-01-02 15:04:05", severity: "INFO", message: "#Fields: date time"}})
+	if profile.requests != 0 || profile.statusCounts == nil {
+		t.Errorf("Expected an empty profile, got %+v", profile)
+	}
+}
@@ -0,0 +1,81 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+func formatScaled(value float64, units []string, base float64) string {
+	unitIndex := 0
+	for value >= base && unitIndex < len(units)-1 {
+		value /= base
+		unitIndex += 1
+	}
+	formatted := strconv.FormatFloat(value, 'f', 1, 64)
+	formatted = strings.TrimSuffix(formatted, ".0")
+	return formatted + units[unitIndex]
+}
+
+func humanizeCount(count int64, options reportOptions) string {
+	if options.exact || count < 1000 && count > -1000 {
+		return strconv.FormatInt(count, 10)
+	}
+	if count < 0 {
+		return "-" + humanizeCount(-count, options)
+	}
+	return formatScaled(float64(count), []string{"", "K", "M", "B", "T"}, 1000)
+}
+
+func humanizeBytes(bytes int64, options reportOptions) string {
+	if options.exact || bytes < 1000 {
+		return strconv.FormatInt(bytes, 10) + " B"
+	}
+	return formatScaled(float64(bytes), []string{" B", " KB", " MB", " GB", " TB", " PB"}, 1000)
+}
+
+func humanizeDuration(duration time.Duration, options reportOptions) string {
+	if options.exact {
+		return duration.String()
+	}
+	if duration < time.Second {
+		return duration.Round(time.Millisecond).String()
+	}
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	var parts []string
+	remaining := duration
+	for _, unit := range units {
+		if remaining < unit.size {
+			continue
+		}
+		parts = append(parts, strconv.FormatInt(int64(remaining/unit.size), 10)+unit.suffix)
+		remaining %= unit.size
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+const frequencyBarWidth = 20
+
+// formatFrequencyBar draws frequency as a bar scaled so maxFrequency fills
+// frequencyBarWidth characters. Any non-zero frequency gets at least one.
+func formatFrequencyBar(frequency int64, maxFrequency int64) string {
+	filled := 0
+	if maxFrequency > 0 {
+		filled = int(frequency * frequencyBarWidth / maxFrequency)
+		if filled == 0 && frequency > 0 {
+			filled = 1
+		}
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(" ", frequencyBarWidth-filled) + "]"
+}
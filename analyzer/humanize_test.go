@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanize(t *testing.T) {
+	humanized := reportOptions{}
+	exact := reportOptions{exact: true}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "small count", got: humanizeCount(999, humanized), want: "999"},
+		{name: "millions", got: humanizeCount(1234567, humanized), want: "1.2M"},
+		{name: "round thousands", got: humanizeCount(2000, humanized), want: "2K"},
+		{name: "exact count", got: humanizeCount(1234567, exact), want: "1234567"},
+		{name: "megabytes", got: humanizeBytes(850000000, humanized), want: "850 MB"},
+		{name: "exact bytes", got: humanizeBytes(850000000, exact), want: "850000000 B"},
+		{name: "hours and minutes", got: humanizeDuration(3*time.Hour+42*time.Minute+10*time.Second, humanized), want: "3h 42m"},
+		{name: "days", got: humanizeDuration(50*time.Hour, humanized), want: "2d 2h"},
+		{name: "exact duration", got: humanizeDuration(3*time.Hour+42*time.Minute, exact), want: "3h42m0s"},
+		{name: "full bar", got: formatFrequencyBar(40, 40), want: "[####################]"},
+		{name: "half bar", got: formatFrequencyBar(20, 40), want: "[##########          ]"},
+		{name: "tiny bar", got: formatFrequencyBar(1, 1000), want: "[#                   ]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var influxTagEscaper = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+
+func formatInfluxLineProtocol(timeBucketCounts map[timeBucketKey]int64, interval time.Duration) string {
+	rebucketed := rebucketTimeBucketCounts(timeBucketCounts, interval)
+	var builder strings.Builder
+	for _, key := range getSortedTimeBucketKeys(rebucketed) {
+		builder.WriteString("log_entries")
+		if key.module != "" {
+			builder.WriteString(",module=" + influxTagEscaper.Replace(key.module))
+		}
+		if key.severity != "" {
+			builder.WriteString(",severity=" + influxTagEscaper.Replace(key.severity))
+		}
+		builder.WriteString(" count=" + strconv.FormatInt(rebucketed[key], 10) + "i")
+		builder.WriteString(" " + strconv.FormatInt(key.start.UnixNano(), 10) + "\n")
+	}
+	return builder.String()
+}
+
+// writeInfluxLineProtocol writes to a file, encrypted with key when it is
+// set, or posts to an InfluxDB write URL, which needs the plain protocol.
+func writeInfluxLineProtocol(destination string, timeBucketCounts map[timeBucketKey]int64, interval time.Duration, key []byte) error {
+	payload := formatInfluxLineProtocol(timeBucketCounts, interval)
+	if !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+		return writeOutputFile(destination, []byte(payload), key)
+	}
+	response, err := httpClient.Post(destination, "text/plain; charset=utf-8", bytes.NewBufferString(payload))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return errors.New("influx write failed: " + response.Status)
+	}
+	return nil
+}
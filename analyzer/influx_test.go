@@ -0,0 +1,21 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatInfluxLineProtocol(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:00:10.000", module: "app.db", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:03:00.000", module: "app.db", severity: "ERROR"},
+		{timestamp: "2024-01-01 00:06:00.000", module: "app web", severity: "INFO"},
+	}
+
+	got := formatInfluxLineProtocol(getTimeBucketCounts(testLogs), 5*time.Minute)
+	want := "log_entries,module=app.db,severity=ERROR count=2i 1704067200000000000\n" +
+		"log_entries,module=app\\ web,severity=INFO count=1i 1704067500000000000\n"
+	if got != want {
+		t.Errorf("formatInfluxLineProtocol() = %q, want %q", got, want)
+	}
+}
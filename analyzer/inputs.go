@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stringListFlag collects every value of a flag that may be repeated.
+type stringListFlag []string
+
+func (values *stringListFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *stringListFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// matchGlob is filepath.Match with "**" matching any number of directories.
+func matchGlob(pattern string, name string) bool {
+	return matchGlobSegments(strings.Split(filepath.ToSlash(pattern), "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchGlobSegments(patternSegments []string, nameSegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(nameSegments) == 0
+	}
+	if patternSegments[0] == "**" {
+		for skipped := 0; skipped <= len(nameSegments); skipped++ {
+			if matchGlobSegments(patternSegments[1:], nameSegments[skipped:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(nameSegments) == 0 {
+		return false
+	}
+	matched, err := filepath.Match(patternSegments[0], nameSegments[0])
+	return err == nil && matched && matchGlobSegments(patternSegments[1:], nameSegments[1:])
+}
+
+func isExcluded(logPath string, excludes []string) bool {
+	for _, exclude := range excludes {
+		if matchGlob(exclude, logPath) {
+			return true
+		}
+		if matched, _ := filepath.Match(exclude, filepath.Base(logPath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func walkLogFiles(root string, visit func(logPath string)) error {
+	return filepath.WalkDir(root, func(logPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			visit(logPath)
+		}
+		return nil
+	})
+}
+
+// expandLogPaths turns directories into the files below them and globs,
+// including "**" for any depth, into their matches. Paths matching one of
+// excludes, either in full or by base name, are left out. Plain paths are
+// passed through untouched so missing files are still reported later.
+func expandLogPaths(arguments []string, excludes []string) (logPaths []string, err error) {
+	for _, argument := range arguments {
+		var expanded []string
+		collect := func(logPath string) {
+			expanded = append(expanded, logPath)
+		}
+		switch {
+		case strings.Contains(argument, "**"):
+			pattern := filepath.Clean(argument)
+			var staticSegments []string
+			for _, segment := range strings.Split(filepath.ToSlash(pattern), "/") {
+				if hasGlobMeta(segment) {
+					break
+				}
+				staticSegments = append(staticSegments, segment)
+			}
+			root := strings.Join(staticSegments, "/")
+			if root == "" {
+				root = "."
+				if strings.HasPrefix(pattern, "/") {
+					root = "/"
+				}
+			}
+			err = walkLogFiles(root, func(logPath string) {
+				if matchGlob(pattern, logPath) {
+					collect(logPath)
+				}
+			})
+		case hasGlobMeta(argument):
+			var matches []string
+			if matches, err = filepath.Glob(argument); err != nil {
+				return
+			}
+			for _, match := range matches {
+				if err = walkLogFiles(match, collect); err != nil {
+					break
+				}
+			}
+		default:
+			if fileInfo, statErr := os.Stat(argument); statErr == nil && fileInfo.IsDir() {
+				err = walkLogFiles(argument, collect)
+			} else {
+				collect(argument)
+			}
+		}
+		if err != nil {
+			return
+		}
+		sort.Strings(expanded)
+		for _, logPath := range expanded {
+			if !isExcluded(logPath, excludes) {
+				logPaths = append(logPaths, logPath)
+			}
+		}
+	}
+	return
+}
+
+// dedupeLogPaths drops paths that refer to a file already in the list, whether
+// through a symlink, a hardlink or a different spelling of the same path.
+// Files are looked up by device and inode, so long lists take one stat per
+// path; only where those aren't available are they compared one by one.
+func dedupeLogPaths(logPaths []string) (uniqueLogPaths []string, duplicateLogPaths []string) {
+	seenIDs := make(map[fileID]bool)
+	var seen []os.FileInfo
+	seenStdin := false
+	for _, logPath := range logPaths {
+		if logPath == stdinPath {
+			if seenStdin {
+				duplicateLogPaths = append(duplicateLogPaths, logPath)
+			} else {
+				uniqueLogPaths = append(uniqueLogPaths, logPath)
+			}
+			seenStdin = true
+			continue
+		}
+		// Stat follows symlinks, so a link and its target share an ID
+		fileInfo, err := os.Stat(logPath)
+		if err != nil {
+			uniqueLogPaths = append(uniqueLogPaths, logPath)
+			continue
+		}
+		duplicate := false
+		if id, ok := getFileID(fileInfo); ok {
+			duplicate = seenIDs[id]
+			seenIDs[id] = true
+		} else {
+			duplicate = slices.ContainsFunc(seen, func(seenFileInfo os.FileInfo) bool {
+				return os.SameFile(fileInfo, seenFileInfo)
+			})
+			if !duplicate {
+				seen = append(seen, fileInfo)
+			}
+		}
+		if duplicate {
+			duplicateLogPaths = append(duplicateLogPaths, logPath)
+			continue
+		}
+		uniqueLogPaths = append(uniqueLogPaths, logPath)
+	}
+	return
+}
+
+func filterLogPathsByModTime(logPaths []string, maxAge time.Duration, now time.Time) (keptLogPaths []string, skippedLogPaths []string) {
+	cutoff := now.Add(-maxAge)
+	for _, logPath := range logPaths {
+		fileInfo, err := os.Stat(logPath)
+		if err == nil && fileInfo.ModTime().Before(cutoff) {
+			skippedLogPaths = append(skippedLogPaths, logPath)
+			continue
+		}
+		keptLogPaths = append(keptLogPaths, logPath)
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDedupeLogPaths(t *testing.T) {
+	directory := t.TempDir()
+	original := filepath.Join(directory, "app.log")
+	other := filepath.Join(directory, "other.log")
+	symlink := filepath.Join(directory, "link.log")
+	hardlink := filepath.Join(directory, "hard.log")
+	for _, logPath := range []string{original, other} {
+		if err := os.WriteFile(logPath, []byte("entry\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(original, symlink); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(original, hardlink); err != nil {
+		t.Fatal(err)
+	}
+
+	logPaths := []string{original, symlink, other, hardlink, filepath.Join(directory, ".", "app.log")}
+	gotUnique, gotDuplicates := dedupeLogPaths(logPaths)
+
+	if !reflect.DeepEqual(gotUnique, []string{original, other}) {
+		t.Errorf("dedupeLogPaths() unique = %v", gotUnique)
+	}
+	if len(gotDuplicates) != 3 {
+		t.Errorf("dedupeLogPaths() duplicates = %v, want 3 entries", gotDuplicates)
+	}
+}
+
+func TestFilterLogPathsByModTime(t *testing.T) {
+	directory := t.TempDir()
+	recent := filepath.Join(directory, "recent.log")
+	stale := filepath.Join(directory, "stale.log")
+	for _, logPath := range []string{recent, stale} {
+		if err := os.WriteFile(logPath, []byte("entry\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	if err := os.Chtimes(stale, now.Add(-48*time.Hour), now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	gotKept, gotSkipped := filterLogPathsByModTime([]string{recent, stale}, 24*time.Hour, now)
+	if !reflect.DeepEqual(gotKept, []string{recent}) || !reflect.DeepEqual(gotSkipped, []string{stale}) {
+		t.Errorf("filterLogPathsByModTime() = %v, %v", gotKept, gotSkipped)
+	}
+}
+
+func TestExpandLogPaths(t *testing.T) {
+	directory := t.TempDir()
+	for _, logPath := range []string{"a.log", "nested/b.log", "nested/deeper/c.log", "nested/deeper/debug.log", "nested/notes.txt"} {
+		fullPath := filepath.Join(directory, logPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte("entry\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	join := func(logPaths ...string) (joined []string) {
+		for _, logPath := range logPaths {
+			joined = append(joined, filepath.Join(directory, logPath))
+		}
+		return
+	}
+
+	tests := []struct {
+		name      string
+		arguments []string
+		excludes  []string
+		want      []string
+	}{
+		{name: "recursive glob", arguments: []string{filepath.Join(directory, "**", "*.log")}, want: join("a.log", "nested/b.log", "nested/deeper/c.log", "nested/deeper/debug.log")},
+		{name: "directory", arguments: []string{filepath.Join(directory, "nested")}, want: join("nested/b.log", "nested/deeper/c.log", "nested/deeper/debug.log", "nested/notes.txt")},
+		{name: "flat glob", arguments: []string{filepath.Join(directory, "*.log")}, want: join("a.log")},
+		{name: "excludes", arguments: []string{directory}, excludes: []string{"*.txt", "debug*", filepath.Join(directory, "**", "deeper", "c.log")}, want: join("a.log", "nested/b.log")},
+		{name: "plain path", arguments: []string{"missing.log"}, want: []string{"missing.log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandLogPaths(tt.arguments, tt.excludes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandLogPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// stringInterner hands out one shared copy of each distinct value. Cloning on
+// first sight also stops a short field from pinning the whole file buffer it
+// was sliced from. Each analysis has its own, so the table is freed with it
+// rather than growing with every value a long-running process ever sees.
+type stringInterner struct {
+	values   sync.Map
+	lookups  *atomic.Int64
+	distinct *atomic.Int64
+}
+
+func newStringInterner(registry *metricsRegistry) *stringInterner {
+	return &stringInterner{
+		lookups:  registry.counter("intern_lookups"),
+		distinct: registry.counter("intern_distinct"),
+	}
+}
+
+func (interner *stringInterner) intern(value string) string {
+	interner.lookups.Add(1)
+	if existing, ok := interner.values.Load(value); ok {
+		return existing.(string)
+	}
+	cloned := strings.Clone(value)
+	actual, loaded := interner.values.LoadOrStore(cloned, cloned)
+	if !loaded {
+		interner.distinct.Add(1)
+	}
+	return actual.(string)
+}
+
+func (interner *stringInterner) internLogMessage(logMessage LogMessage, internMessages bool) LogMessage {
+	logMessage.severity = interner.intern(logMessage.severity)
+	logMessage.module = interner.intern(logMessage.module)
+	logMessage.function = interner.intern(logMessage.function)
+	if internMessages {
+		logMessage.message = interner.intern(logMessage.message)
+	}
+	return logMessage
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestStringInterner(t *testing.T) {
+	registry := newMetricsRegistry()
+	interner := newStringInterner(registry)
+
+	var workers sync.WaitGroup
+	results := make([]string, 16)
+	for worker := range results {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			results[worker] = interner.intern(string([]byte("app.module")))
+		}()
+	}
+	workers.Wait()
+
+	for _, result := range results {
+		if unsafe.StringData(result) != unsafe.StringData(results[0]) {
+			t.Fatalf("intern() returned distinct copies of the same value")
+		}
+	}
+	snapshot := registry.snapshot()
+	if snapshot["intern_lookups"] != 16 || snapshot["intern_distinct"] != 1 {
+		t.Errorf("snapshot() = %v, want 16 lookups of 1 distinct value", snapshot)
+	}
+}
+
+func TestInternerPerAnalysis(t *testing.T) {
+	input := "2024-01-01 08:00:00.000 | ERROR | db:query:12 - Timeout\n"
+	parseSeverity := func(options analysisOptions) string {
+		logMessages, _, _ := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, options)
+		return logMessages[0].severity
+	}
+
+	if first, second := parseSeverity(analysisOptions{}), parseSeverity(analysisOptions{}); unsafe.StringData(first) == unsafe.StringData(second) {
+		t.Errorf("parseLogReader() shared a value between separate analyses")
+	}
+	shared := analysisOptions{interner: newStringInterner(newMetricsRegistry())}
+	if first, second := parseSeverity(shared), parseSeverity(shared); unsafe.StringData(first) != unsafe.StringData(second) {
+		t.Errorf("parseLogReader() returned distinct copies within one analysis")
+	}
+}
@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getJournalField reads a journal export field. Fields are strings, byte
+// arrays when they aren't valid UTF-8, and arrays of either when a field
+// was set more than once, in which case the first value is used.
+func getJournalField(fields map[string]any, name string) string {
+	return formatJournalValue(fields[name])
+}
+
+func formatJournalValue(value any) string {
+	values, ok := value.([]any)
+	if !ok {
+		text, _ := value.(string)
+		return text
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	if _, isByte := values[0].(json.Number); !isByte {
+		return formatJournalValue(values[0])
+	}
+	data := make([]byte, 0, len(values))
+	for _, item := range values {
+		if number, ok := item.(json.Number); ok {
+			character, _ := number.Int64()
+			data = append(data, byte(character))
+		}
+	}
+	return string(data)
+}
+
+// parseJournalMessage reads the systemd journal as exported by journalctl
+// -o json. PRIORITY maps like GELF's syslog levels and defaults to INFO; the
+// syslog identifier, command or unit becomes the module. The boot ID, unit
+// and host are kept as the boot_id, unit and host attributes.
+func parseJournalMessage(logRow string) (logMessage LogMessage, err error) {
+	decoder := json.NewDecoder(strings.NewReader(logRow))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err = decoder.Decode(&fields); err != nil {
+		return logMessage, errMissingDelimiter
+	}
+	realtime, err := strconv.ParseInt(getJournalField(fields, "__REALTIME_TIMESTAMP"), 10, 64)
+	if err != nil {
+		return logMessage, errBadTimestamp
+	}
+	logMessage.timestamp = time.UnixMicro(realtime).UTC().Format(layout)
+	logMessage.severity = "INFO"
+	if priority, err := strconv.ParseInt(getJournalField(fields, "PRIORITY"), 10, 64); err == nil {
+		logMessage.severity = getSyslogSeverity(priority)
+	}
+	for _, name := range []string{"SYSLOG_IDENTIFIER", "_COMM", "_SYSTEMD_UNIT"} {
+		if logMessage.module = getJournalField(fields, name); logMessage.module != "" {
+			break
+		}
+	}
+	logMessage.pid = getJournalField(fields, "_PID")
+	logMessage.message = getJournalField(fields, "MESSAGE")
+	logMessage.attributes = make(map[string]string)
+	for attribute, name := range map[string]string{"boot_id": "_BOOT_ID", "unit": "_SYSTEMD_UNIT", "host": "_HOSTNAME"} {
+		if value := getJournalField(fields, name); value != "" {
+			logMessage.attributes[attribute] = value
+		}
+	}
+	return logMessage, nil
+}
@@ -0,0 +1,175 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultJSONFieldKeys lists, for every entry field, the keys the json
+// preset tries in order. Nested objects are flattened, so "log.level" finds
+// {"log": {"level": "info"}} as written by Elastic Common Schema loggers.
+var defaultJSONFieldKeys = map[string][]string{
+	"timestamp": {"ts", "time", "timestamp", "@timestamp", "t"},
+	"severity":  {"level", "severity", "lvl", "log.level", "levelname"},
+	"message":   {"msg", "message", "@message"},
+	"module":    {"logger", "module", "name", "log.logger", "component"},
+	"function":  {"func", "function", "log.origin.function"},
+	"line":      {"line", "lineno", "log.origin.file.line"},
+	"thread":    {"thread", "thread_name", "tid", "goroutine"},
+	"pid":       {"pid", "process.pid"},
+	"ip":        {"client_ip", "remote_addr", "ip", "client.ip"},
+}
+
+var jsonTimestampLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700", "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"}
+
+// parseJSONFieldKeys reads a -json-fields value such as
+// "timestamp=ts,severity=level|lvl,message=msg", where | separates keys to
+// try in order. Fields it names replace the defaults, the others keep them.
+func parseJSONFieldKeys(spec string) (map[string][]string, error) {
+	fieldKeys := make(map[string][]string, len(defaultJSONFieldKeys))
+	for field, keys := range defaultJSONFieldKeys {
+		fieldKeys[field] = keys
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, keys, found := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		if _, known := defaultJSONFieldKeys[field]; !known || !found || strings.TrimSpace(keys) == "" {
+			names := make([]string, 0, len(defaultJSONFieldKeys))
+			for name := range defaultJSONFieldKeys {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, errors.New("bad JSON field mapping " + pair + ", expected field=key with one of " + strings.Join(names, ", "))
+		}
+		fieldKeys[field] = nil
+		for _, key := range strings.Split(keys, "|") {
+			fieldKeys[field] = append(fieldKeys[field], strings.TrimSpace(key))
+		}
+	}
+	return fieldKeys, nil
+}
+
+// flattenJSONFields turns nested objects into dotted keys and every value
+// into text; arrays are kept as JSON.
+func flattenJSONFields(prefix string, object map[string]any, fields map[string]string) {
+	for key, value := range object {
+		switch typed := value.(type) {
+		case map[string]any:
+			flattenJSONFields(prefix+key+".", typed, fields)
+		default:
+			fields[prefix+key] = formatGELFValue(typed)
+		}
+	}
+}
+
+// parseJSONEpoch reads a number of seconds, milliseconds, microseconds or
+// nanoseconds since the epoch, telling them apart by magnitude.
+func parseJSONEpoch(value string) (time.Time, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch magnitude := math.Abs(number); {
+	case magnitude >= 1e17:
+		return time.Unix(0, int64(number)), nil
+	case magnitude >= 1e14:
+		return time.UnixMicro(int64(number)), nil
+	case magnitude >= 1e11:
+		return time.UnixMilli(int64(number)), nil
+	}
+	whole, fraction := math.Modf(number)
+	return time.Unix(int64(whole), int64(math.Round(fraction*1e6))*int64(time.Microsecond)), nil
+}
+
+// getJSONSeverity normalizes a level name, or a numeric level on the pino
+// and bunyan scale of 10 (trace) to 60 (fatal), or the syslog scale of 0-7.
+func getJSONSeverity(level string) string {
+	number, err := strconv.ParseInt(level, 10, 64)
+	if err != nil {
+		return normalizeSeverity(level)
+	}
+	switch {
+	case number < 10:
+		return getSyslogSeverity(number)
+	case number < 20:
+		return "TRACE"
+	case number < 30:
+		return "DEBUG"
+	case number < 40:
+		return "INFO"
+	case number < 50:
+		return "WARNING"
+	case number < 60:
+		return "ERROR"
+	}
+	return "FATAL"
+}
+
+// newJSONLinesParser reads one JSON object per line, taking each entry field
+// from the first of its keys that is present. Keys not used for a field are
+// kept as attributes, those of an "attributes" object, as convert -to jsonl
+// and OpenTelemetry write, without the "attributes." prefix. An entry without a level is reported as missing a
+// severity, so -infer-severity can label it.
+func newJSONLinesParser(fieldKeys map[string][]string) Parser {
+	return func(logRow string) (logMessage LogMessage, err error) {
+		decoder := json.NewDecoder(strings.NewReader(logRow))
+		decoder.UseNumber()
+		var object map[string]any
+		if err = decoder.Decode(&object); err != nil {
+			return logMessage, errMissingDelimiter
+		}
+		fields := make(map[string]string, len(object))
+		flattenJSONFields("", object, fields)
+		values := make(map[string]string, len(fieldKeys))
+		for field, keys := range fieldKeys {
+			for _, key := range keys {
+				if value, ok := fields[key]; ok {
+					values[field] = value
+					delete(fields, key)
+					break
+				}
+			}
+		}
+
+		message, ok := values["message"]
+		if !ok {
+			return logMessage, errMissingDelimiter
+		}
+		if epoch, epochErr := parseJSONEpoch(values["timestamp"]); epochErr == nil {
+			logMessage.timestamp = epoch.UTC().Format(layout)
+		} else if logMessage.timestamp, err = normalizeTimestamp(values["timestamp"], jsonTimestampLayouts...); err != nil {
+			return logMessage, errBadTimestamp
+		}
+		logMessage.message = message
+		logMessage.module = values["module"]
+		logMessage.function = values["function"]
+		logMessage.lineNumber, _ = strconv.ParseInt(values["line"], 10, 64)
+		logMessage.thread = values["thread"]
+		logMessage.pid = values["pid"]
+		if address, err := netip.ParseAddr(values["ip"]); err == nil {
+			logMessage.clientIP = address.String()
+		} else if addressPort, err := netip.ParseAddrPort(values["ip"]); err == nil {
+			logMessage.clientIP = addressPort.Addr().String()
+		}
+		if len(fields) > 0 {
+			logMessage.attributes = make(map[string]string, len(fields))
+			for key, value := range fields {
+				logMessage.attributes[strings.TrimPrefix(key, attributeKeyPrefix)] = value
+			}
+		}
+		if values["severity"] == "" {
+			return logMessage, errMissingSeverity
+		}
+		logMessage.severity = getJSONSeverity(values["severity"])
+		return logMessage, nil
+	}
+}
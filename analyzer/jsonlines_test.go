@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONLinesParser(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LogMessage
+	}{
+		{
+			input: `{"ts":"2024-01-02T16:04:05.250+01:00","level":"warn","logger":"payments","msg":"Retrying charge","order":42,"remote_addr":"203.0.113.9:51234"}`,
+			want:  LogMessage{timestamp: "2024-01-02 15:04:05.25", severity: "WARNING", module: "payments", message: "Retrying charge", clientIP: "203.0.113.9", attributes: map[string]string{"order": "42"}},
+		},
+		{
+			// pino: epoch milliseconds and numeric levels
+			input: `{"level":50,"time":1704207845250,"pid":4242,"hostname":"web1","msg":"Boom"}`,
+			want:  LogMessage{timestamp: "2024-01-02 15:04:05.25", severity: "ERROR", pid: "4242", message: "Boom", attributes: map[string]string{"hostname": "web1"}},
+		},
+		{
+			// Elastic Common Schema with nested objects
+			input: `{"@timestamp":"2024-01-02T15:04:05.000Z","log":{"level":"error","logger":"db"},"message":"Connection lost","tags":["a","b"]}`,
+			want:  LogMessage{timestamp: "2024-01-02 15:04:05", severity: "ERROR", module: "db", message: "Connection lost", attributes: map[string]string{"tags": `["a","b"]`}},
+		},
+	}
+	parser, err := getLineParser("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		got, err := parser(tt.input)
+		if err != nil {
+			t.Fatalf("parser(%s) error = %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parser(%s) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	got, err := parser(`{"time":1704207845,"msg":"No level"}`)
+	if err != errMissingSeverity || got.message != "No level" {
+		t.Errorf("parser() without a level = %+v, %v", got, err)
+	}
+	for _, input := range []string{"not json", `{"level":"info"}`, `{"msg":"x","level":"info","ts":"yesterday"}`} {
+		if _, err := parser(input); err == nil {
+			t.Errorf("parser(%s) accepted a bad entry", input)
+		}
+	}
+}
+
+func TestJSONParserFields(t *testing.T) {
+	parser, err := JSONParser("timestamp=when, severity=sev|priority, message=text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parser(`{"when":"2024-01-02 15:04:05","priority":"CRIT","text":"Disk full","logger":"disk"}`)
+	if err != nil || got.severity != "CRITICAL" || got.message != "Disk full" || got.module != "disk" || got.timestamp != "2024-01-02 15:04:05" {
+		t.Errorf("parser() = %+v, %v", got, err)
+	}
+	for _, spec := range []string{"colour=c", "message", "message="} {
+		if _, err := JSONParser(spec); err == nil {
+			t.Errorf("JSONParser(%q) accepted a bad mapping", spec)
+		}
+	}
+}
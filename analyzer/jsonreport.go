@@ -0,0 +1,626 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// jsonFloat always renders with six decimals so reports can be checksummed
+// and diffed byte for byte.
+type jsonFloat float64
+
+func (value jsonFloat) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(value), 'f', 6, 64)), nil
+}
+
+type jsonSeverityFrequency struct {
+	Trace    int64 `json:"trace,omitempty"`
+	Debug    int64 `json:"debug"`
+	Info     int64 `json:"info"`
+	Notice   int64 `json:"notice,omitempty"`
+	Warning  int64 `json:"warning"`
+	Error    int64 `json:"error"`
+	Critical int64 `json:"critical,omitempty"`
+	Fatal    int64 `json:"fatal,omitempty"`
+	// Custom severities by name
+	Other map[string]int64 `json:"other,omitempty"`
+}
+
+type jsonTopMessage struct {
+	Message      string    `json:"message"`
+	Frequency    int64     `json:"frequency"`
+	Trend        string    `json:"trend,omitempty"`
+	SlopePerHour jsonFloat `json:"slopePerHour"`
+	TraceIDs     []string  `json:"traceIds,omitempty"`
+	Examples     []string  `json:"examples,omitempty"`
+}
+
+type jsonVersionCount struct {
+	Version string `json:"version"`
+	Entries int64  `json:"entries"`
+	Errors  int64  `json:"errors"`
+}
+
+type jsonThreadError struct {
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+}
+
+type jsonThreadCount struct {
+	Thread    string            `json:"thread"`
+	Entries   int64             `json:"entries"`
+	Errors    int64             `json:"errors"`
+	TopErrors []jsonThreadError `json:"topErrors,omitempty"`
+}
+
+type jsonModuleMessage struct {
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+}
+
+type jsonFacility struct {
+	Facility          string                `json:"facility"`
+	Entries           int64                 `json:"entries"`
+	SeverityFrequency jsonSeverityFrequency `json:"severityFrequency"`
+}
+
+type jsonAgeTier struct {
+	Tier              string                `json:"tier"`
+	Files             int                   `json:"files"`
+	Entries           int64                 `json:"entries"`
+	SeverityFrequency jsonSeverityFrequency `json:"severityFrequency"`
+}
+
+type jsonStability struct {
+	Baseline string    `json:"baseline"`
+	Score    jsonFloat `json:"score"`
+	Shared   int       `json:"shared"`
+	Entered  []string  `json:"entered,omitempty"`
+	Left     []string  `json:"left,omitempty"`
+}
+
+type jsonModuleStats struct {
+	Module            string                `json:"module"`
+	Entries           int64                 `json:"entries"`
+	SeverityFrequency jsonSeverityFrequency `json:"severityFrequency"`
+	TopMessages       []jsonModuleMessage   `json:"topMessages,omitempty"`
+}
+
+type jsonClientIPCount struct {
+	ClientIP string `json:"clientIP"`
+	Entries  int64  `json:"entries"`
+	Errors   int64  `json:"errors"`
+}
+
+type jsonClientIPs struct {
+	TopTalkers      []jsonClientIPCount `json:"topTalkers"`
+	TopErrorSources []jsonClientIPCount `json:"topErrorSources,omitempty"`
+	NotInBaseline   []jsonClientIPCount `json:"notInBaseline,omitempty"`
+}
+
+type jsonHistogramBucket struct {
+	Start             time.Time             `json:"start"`
+	Entries           int64                 `json:"entries"`
+	SeverityFrequency jsonSeverityFrequency `json:"severityFrequency"`
+}
+
+type jsonErrorSpike struct {
+	Start             time.Time           `json:"start"`
+	Entries           int64               `json:"entries"`
+	Errors            int64               `json:"errors"`
+	ErrorRate         jsonFloat           `json:"errorRate"`
+	BaselineErrorRate *jsonFloat          `json:"baselineErrorRate,omitempty"`
+	TopMessages       []jsonModuleMessage `json:"topMessages,omitempty"`
+}
+
+type jsonQueryStats struct {
+	Fingerprint  string    `json:"fingerprint"`
+	Count        int64     `json:"count"`
+	TotalTime    jsonFloat `json:"totalTime"`
+	MaxTime      jsonFloat `json:"maxTime"`
+	LockTime     jsonFloat `json:"lockTime"`
+	RowsExamined int64     `json:"rowsExamined"`
+}
+
+type jsonQueryTimeBucket struct {
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type jsonQueries struct {
+	Distinct         int                   `json:"distinct"`
+	ByTotalTime      []jsonQueryStats      `json:"byTotalTime"`
+	ByCount          []jsonQueryStats      `json:"byCount"`
+	TimeDistribution []jsonQueryTimeBucket `json:"timeDistribution"`
+	LockWaits        int64                 `json:"lockWaits"`
+	LockWaitTime     jsonFloat             `json:"lockWaitTime"`
+	LockWaitTypes    map[string]int64      `json:"lockWaitTypes,omitempty"`
+}
+
+func newJSONQueryStats(fingerprint string, stats queryStats) jsonQueryStats {
+	return jsonQueryStats{
+		Fingerprint:  fingerprint,
+		Count:        stats.count,
+		TotalTime:    jsonFloat(stats.totalTime),
+		MaxTime:      jsonFloat(stats.maxTime),
+		LockTime:     jsonFloat(stats.lockTime),
+		RowsExamined: stats.rowsExamined,
+	}
+}
+
+type jsonGC struct {
+	Pauses                   int                   `json:"pauses"`
+	TotalPauseMs             jsonFloat             `json:"totalPauseMs"`
+	PauseP50Ms               jsonFloat             `json:"pauseP50Ms"`
+	PauseP90Ms               jsonFloat             `json:"pauseP90Ms"`
+	PauseP99Ms               jsonFloat             `json:"pauseP99Ms"`
+	PauseMaxMs               jsonFloat             `json:"pauseMaxMs"`
+	PauseTypes               map[string]int64      `json:"pauseTypes,omitempty"`
+	AllocationBytesPerSecond jsonFloat             `json:"allocationBytesPerSecond"`
+	FullGCs                  []jsonHistogramBucket `json:"fullGCs,omitempty"`
+}
+
+type jsonHTTPPath struct {
+	Path     string `json:"path"`
+	Requests int64  `json:"requests"`
+}
+
+type jsonHTTPClient struct {
+	ClientIP string `json:"clientIP"`
+	Requests int64  `json:"requests"`
+}
+
+type jsonHTTP struct {
+	Requests          int64                 `json:"requests"`
+	RequestsPerSecond jsonFloat             `json:"requestsPerSecond"`
+	BytesSent         int64                 `json:"bytesSent"`
+	StatusCodes       map[string]int64      `json:"statusCodes"`
+	TopURLs           []jsonHTTPPath        `json:"topUrls,omitempty"`
+	TopClients        []jsonHTTPClient      `json:"topClients,omitempty"`
+	RequestRate       []jsonHistogramBucket `json:"requestRate,omitempty"`
+}
+
+type jsonProcessRestart struct {
+	LogPath      string    `json:"logPath"`
+	Process      string    `json:"process,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	PreviousPID  string    `json:"previousPid"`
+	PID          string    `json:"pid"`
+	ErrorsBefore int64     `json:"errorsBefore"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+type jsonBootSession struct {
+	LogPath         string    `json:"logPath"`
+	BootID          string    `json:"bootId,omitempty"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds jsonFloat `json:"durationSeconds"`
+	Entries         int64     `json:"entries"`
+	Errors          int64     `json:"errors"`
+	Shutdown        string    `json:"shutdown,omitempty"`
+	FinalMessages   []string  `json:"finalMessages,omitempty"`
+}
+
+type jsonWindow struct {
+	Name              string                `json:"name"`
+	Entries           int64                 `json:"entries"`
+	SeverityFrequency jsonSeverityFrequency `json:"severityFrequency"`
+	ErrorRate         jsonFloat             `json:"errorRate"`
+	TopMessages       []jsonTopMessage      `json:"topMessages"`
+}
+
+type jsonMessageConcentration struct {
+	Message   string    `json:"message"`
+	Total     int64     `json:"total"`
+	LogPath   string    `json:"logPath"`
+	FileCount int64     `json:"fileCount"`
+	Share     jsonFloat `json:"share"`
+	Files     int       `json:"files"`
+}
+
+type jsonSeverityTransition struct {
+	Module string `json:"module"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Count  int64  `json:"count"`
+}
+
+type jsonParseError struct {
+	LogPath string `json:"logPath"`
+	Reason  string `json:"reason"`
+	Count   int64  `json:"count"`
+}
+
+type jsonBadLine struct {
+	LogPath string `json:"logPath"`
+	Line    int64  `json:"line"`
+	Reason  string `json:"reason"`
+	Text    string `json:"text"`
+}
+
+type jsonFileError struct {
+	LogPath string `json:"logPath"`
+	Line    int64  `json:"line,omitempty"`
+	Error   string `json:"error"`
+}
+
+type jsonBudgetRow struct {
+	Month        string    `json:"month"`
+	Module       string    `json:"module"`
+	Entries      int64     `json:"entries"`
+	Errors       int64     `json:"errors"`
+	MaxErrors    int64     `json:"maxErrors,omitempty"`
+	MaxErrorRate jsonFloat `json:"maxErrorRate,omitempty"`
+	Consumed     jsonFloat `json:"consumed"`
+	MonthElapsed jsonFloat `json:"monthElapsed"`
+}
+
+// Analysis is the result of analyzing one or more logs, as returned by
+// Analyze and written by -format json.
+type Analysis struct {
+	LogPath                   string                     `json:"logPath,omitempty"`
+	Entries                   int                        `json:"entries"`
+	BytesRead                 int64                      `json:"bytesRead"`
+	SeverityFrequency         jsonSeverityFrequency      `json:"severityFrequency"`
+	InferredSeverityFrequency *jsonSeverityFrequency     `json:"inferredSeverityFrequency,omitempty"`
+	Facilities                []jsonFacility             `json:"facilities,omitempty"`
+	AgeTiers                  []jsonAgeTier              `json:"ageTiers,omitempty"`
+	TopMessages               []jsonTopMessage           `json:"topMessages"`
+	Stability                 *jsonStability             `json:"stability,omitempty"`
+	StartTime                 time.Time                  `json:"startTime"`
+	EndTime                   time.Time                  `json:"endTime"`
+	Histogram                 []jsonHistogramBucket      `json:"histogram,omitempty"`
+	ErrorSpikes               []jsonErrorSpike           `json:"errorSpikes,omitempty"`
+	Queries                   *jsonQueries               `json:"queries,omitempty"`
+	GC                        *jsonGC                    `json:"gc,omitempty"`
+	HTTP                      *jsonHTTP                  `json:"http,omitempty"`
+	Versions                  []jsonVersionCount         `json:"versions,omitempty"`
+	Threads                   []jsonThreadCount          `json:"threads,omitempty"`
+	Modules                   []jsonModuleStats          `json:"modules,omitempty"`
+	ClientIPs                 *jsonClientIPs             `json:"clientIPs,omitempty"`
+	ProcessRestarts           []jsonProcessRestart       `json:"processRestarts,omitempty"`
+	BootSessions              []jsonBootSession          `json:"bootSessions,omitempty"`
+	Windows                   []jsonWindow               `json:"windows,omitempty"`
+	SeverityTransitions       []jsonSeverityTransition   `json:"severityTransitions,omitempty"`
+	HostLocalMessages         []jsonMessageConcentration `json:"hostLocalMessages,omitempty"`
+	Lines                     int64                      `json:"lines,omitempty"`
+	MalformedLineCount        int64                      `json:"malformedLineCount,omitempty"`
+	MalformedLines            []jsonParseError           `json:"malformedLines,omitempty"`
+	BadLines                  []jsonBadLine              `json:"badLines,omitempty"`
+	DataQualityWarnings       []string                   `json:"dataQualityWarnings,omitempty"`
+	ErrorBudgets              []jsonBudgetRow            `json:"errorBudgets,omitempty"`
+	Files                     []Analysis                 `json:"files,omitempty"`
+	Interrupted               bool                       `json:"interrupted,omitempty"`
+	FileErrors                []jsonFileError            `json:"fileErrors,omitempty"`
+}
+
+func newJSONSeverityFrequency(logSeverityFrequency LogSeverityFrequency) jsonSeverityFrequency {
+	report := jsonSeverityFrequency{
+		Trace:    logSeverityFrequency["TRACE"],
+		Debug:    logSeverityFrequency["DEBUG"],
+		Info:     logSeverityFrequency["INFO"],
+		Notice:   logSeverityFrequency["NOTICE"],
+		Warning:  logSeverityFrequency["WARNING"],
+		Error:    logSeverityFrequency["ERROR"],
+		Critical: logSeverityFrequency["CRITICAL"],
+		Fatal:    logSeverityFrequency["FATAL"],
+	}
+	for severity, count := range logSeverityFrequency {
+		if _, ok := severityRanks[severity]; !ok {
+			if report.Other == nil {
+				report.Other = make(map[string]int64)
+			}
+			report.Other[severity] = count
+		}
+	}
+	return report
+}
+
+func newJSONClientIPs(clientIPCounts map[string]clientIPCount, baseline []netip.Prefix) *jsonClientIPs {
+	toJSON := func(clientIPs []string) (counts []jsonClientIPCount) {
+		for _, clientIP := range clientIPs {
+			counts = append(counts, jsonClientIPCount{ClientIP: clientIP, Entries: clientIPCounts[clientIP].entries, Errors: clientIPCounts[clientIP].errors})
+		}
+		return
+	}
+	report := &jsonClientIPs{
+		TopTalkers:      toJSON(getTopClientIPs(clientIPCounts, false)),
+		TopErrorSources: toJSON(getTopClientIPs(clientIPCounts, true)),
+	}
+	if baseline != nil {
+		report.NotInBaseline = toJSON(getNewClientIPs(clientIPCounts, baseline))
+	}
+	return report
+}
+
+func newJSONLogAnalysis(logAnalysis LogAnalysis, budgetReport []budgetReportRow) (report Analysis) {
+	report.LogPath = logAnalysis.logPath
+	report.Entries = logAnalysis.numEntries
+	report.BytesRead = logAnalysis.bytesRead
+	report.SeverityFrequency = newJSONSeverityFrequency(logAnalysis.logSeverityFrequency)
+	if len(logAnalysis.unlabeledMessages) > 0 {
+		inferred := newJSONSeverityFrequency(logAnalysis.inferredSeverityFrequency)
+		report.InferredSeverityFrequency = &inferred
+	}
+	for _, facility := range getSortedFacilities(logAnalysis.facilitySeverityFrequencies) {
+		logSeverityFrequency := logAnalysis.facilitySeverityFrequencies[facility]
+		report.Facilities = append(report.Facilities, jsonFacility{Facility: facility, Entries: getSeverityEntries(logSeverityFrequency),
+			SeverityFrequency: newJSONSeverityFrequency(logSeverityFrequency)})
+	}
+	for _, tier := range getSortedAgeTiers(logAnalysis.ageTierCounts) {
+		count := logAnalysis.ageTierCounts[tier]
+		report.AgeTiers = append(report.AgeTiers, jsonAgeTier{Tier: tier, Files: count.files,
+			Entries: getSeverityEntries(count.logSeverityFrequency), SeverityFrequency: newJSONSeverityFrequency(count.logSeverityFrequency)})
+	}
+	if logAnalysis.topMessageBaseline != nil {
+		if stability, ok := getTopMessageStability(logAnalysis.topFiveLogMessages, logAnalysis.topMessageBaseline.messages); ok {
+			report.Stability = &jsonStability{Baseline: logAnalysis.topMessageBaseline.path, Score: jsonFloat(stability.score),
+				Shared: stability.shared, Entered: stability.entered, Left: stability.left}
+		}
+	}
+	report.TopMessages = []jsonTopMessage{}
+	for index, message := range logAnalysis.topFiveLogMessages {
+		if message == "" {
+			continue
+		}
+		topMessage := jsonTopMessage{Message: message, TraceIDs: logAnalysis.messageTraceIDs[message], Examples: logAnalysis.messageExamples[message]}
+		if index < len(logAnalysis.topFiveLogMessageFrequencies) {
+			topMessage.Frequency = logAnalysis.topFiveLogMessageFrequencies[index]
+		}
+		if index < len(logAnalysis.topFiveLogMessageTrends) {
+			topMessage.Trend = logAnalysis.topFiveLogMessageTrends[index].direction
+			topMessage.SlopePerHour = jsonFloat(logAnalysis.topFiveLogMessageTrends[index].slopePerHour)
+		}
+		report.TopMessages = append(report.TopMessages, topMessage)
+	}
+	report.StartTime = logAnalysis.startTime
+	report.EndTime = logAnalysis.endTime
+	if logAnalysis.histogramBucket > 0 {
+		for _, bucket := range getHistogramBuckets(logAnalysis.timeBucketCounts, logAnalysis.histogramBucket) {
+			report.Histogram = append(report.Histogram, jsonHistogramBucket{
+				Start:             bucket.start,
+				Entries:           bucket.entries,
+				SeverityFrequency: newJSONSeverityFrequency(bucket.severityFrequency),
+			})
+		}
+	}
+	if logAnalysis.spikeOptions.interval > 0 {
+		for _, spike := range getErrorSpikes(logAnalysis.timeBucketCounts, logAnalysis.messageBucketCounts, logAnalysis.spikeOptions) {
+			spikeReport := jsonErrorSpike{
+				Start:     spike.start,
+				Entries:   spike.entries,
+				Errors:    spike.errors,
+				ErrorRate: jsonFloat(getErrorRate(spike.errors, spike.entries)),
+			}
+			if !spike.noBaseline {
+				baselineErrorRate := jsonFloat(spike.baselineErrorRate)
+				spikeReport.BaselineErrorRate = &baselineErrorRate
+			}
+			for _, message := range getTopSpikeMessages(spike) {
+				spikeReport.TopMessages = append(spikeReport.TopMessages, jsonModuleMessage{Message: message, Count: spike.messageFrequencies[message]})
+			}
+			report.ErrorSpikes = append(report.ErrorSpikes, spikeReport)
+		}
+	}
+	if profile := logAnalysis.queryProfile; profile.queries != nil {
+		queries := &jsonQueries{
+			Distinct:     len(profile.queries),
+			ByTotalTime:  []jsonQueryStats{},
+			ByCount:      []jsonQueryStats{},
+			LockWaits:    profile.lockWaits,
+			LockWaitTime: jsonFloat(profile.lockWaitTime),
+		}
+		for _, fingerprint := range getTopQueries(profile.queries, false) {
+			queries.ByTotalTime = append(queries.ByTotalTime, newJSONQueryStats(fingerprint, profile.queries[fingerprint]))
+		}
+		for _, fingerprint := range getTopQueries(profile.queries, true) {
+			queries.ByCount = append(queries.ByCount, newJSONQueryStats(fingerprint, profile.queries[fingerprint]))
+		}
+		for bucket, count := range profile.timeBuckets {
+			queries.TimeDistribution = append(queries.TimeDistribution, jsonQueryTimeBucket{Label: getQueryTimeBucketLabel(bucket), Count: count})
+		}
+		if profile.lockWaits > 0 {
+			queries.LockWaitTypes = profile.lockWaitTypes
+		}
+		report.Queries = queries
+	}
+	if profile := logAnalysis.gcProfile; profile.pauseTypes != nil {
+		summary := getGCPauseSummary(profile.pauses)
+		gc := &jsonGC{
+			Pauses:                   summary.count,
+			TotalPauseMs:             jsonFloat(summary.total),
+			PauseP50Ms:               jsonFloat(summary.p50),
+			PauseP90Ms:               jsonFloat(summary.p90),
+			PauseP99Ms:               jsonFloat(summary.p99),
+			PauseMaxMs:               jsonFloat(summary.max),
+			PauseTypes:               profile.pauseTypes,
+			AllocationBytesPerSecond: jsonFloat(profile.getAllocationRate()),
+		}
+		if len(profile.fullGCCounts) > 0 {
+			for _, bucket := range getHistogramBuckets(profile.fullGCCounts, getFullGCInterval(logAnalysis)) {
+				gc.FullGCs = append(gc.FullGCs, jsonHistogramBucket{
+					Start:             bucket.start,
+					Entries:           bucket.entries,
+					SeverityFrequency: newJSONSeverityFrequency(bucket.severityFrequency),
+				})
+			}
+		}
+		report.GC = gc
+	}
+	if profile := logAnalysis.httpProfile; profile.statusCounts != nil {
+		http := &jsonHTTP{
+			Requests:          profile.requests,
+			RequestsPerSecond: jsonFloat(profile.getRequestsPerSecond(logAnalysis.endTime.Sub(logAnalysis.startTime))),
+			BytesSent:         profile.bytesSent,
+			StatusCodes:       profile.statusCounts,
+		}
+		for _, path := range getTopHTTPKeys(profile.pathCounts) {
+			http.TopURLs = append(http.TopURLs, jsonHTTPPath{Path: path, Requests: profile.pathCounts[path]})
+		}
+		for _, clientIP := range getTopHTTPKeys(profile.clientCounts) {
+			http.TopClients = append(http.TopClients, jsonHTTPClient{ClientIP: clientIP, Requests: profile.clientCounts[clientIP]})
+		}
+		for _, bucket := range getHistogramBuckets(profile.requestCounts, getRequestRateInterval(logAnalysis)) {
+			http.RequestRate = append(http.RequestRate, jsonHistogramBucket{
+				Start:             bucket.start,
+				Entries:           bucket.entries,
+				SeverityFrequency: newJSONSeverityFrequency(bucket.severityFrequency),
+			})
+		}
+		report.HTTP = http
+	}
+	for _, version := range getSortedVersions(logAnalysis.versionCounts) {
+		count := logAnalysis.versionCounts[version]
+		report.Versions = append(report.Versions, jsonVersionCount{Version: version, Entries: count.entries, Errors: count.errors})
+	}
+	for _, thread := range getSortedThreads(logAnalysis.threadCounts) {
+		count := logAnalysis.threadCounts[thread]
+		threadReport := jsonThreadCount{Thread: thread, Entries: count.entries, Errors: count.errors}
+		for _, message := range getTopThreadErrors(count) {
+			threadReport.TopErrors = append(threadReport.TopErrors, jsonThreadError{Message: message, Count: count.errorMessages[message]})
+		}
+		report.Threads = append(report.Threads, threadReport)
+	}
+	for _, module := range getSortedModules(logAnalysis.moduleStats) {
+		stats := logAnalysis.moduleStats[module]
+		moduleReport := jsonModuleStats{Module: module, Entries: stats.entries, SeverityFrequency: newJSONSeverityFrequency(stats.severityFrequency)}
+		for _, message := range getTopModuleMessages(stats) {
+			moduleReport.TopMessages = append(moduleReport.TopMessages, jsonModuleMessage{Message: message, Count: stats.messageFrequencies[message]})
+		}
+		report.Modules = append(report.Modules, moduleReport)
+	}
+	if len(logAnalysis.clientIPCounts) > 0 {
+		report.ClientIPs = newJSONClientIPs(logAnalysis.clientIPCounts, logAnalysis.clientIPBaseline)
+	}
+	for _, restart := range logAnalysis.processRestarts {
+		report.ProcessRestarts = append(report.ProcessRestarts, jsonProcessRestart{
+			LogPath:      restart.logPath,
+			Process:      restart.process,
+			Timestamp:    restart.timestamp,
+			PreviousPID:  restart.previousPID,
+			PID:          restart.pid,
+			ErrorsBefore: restart.errorsBefore,
+			LastError:    restart.lastError,
+		})
+	}
+	for _, session := range logAnalysis.bootSessions {
+		bootSession := jsonBootSession{
+			LogPath:         session.logPath,
+			BootID:          session.bootID,
+			Start:           session.start,
+			End:             session.end,
+			DurationSeconds: jsonFloat(session.end.Sub(session.start).Seconds()),
+			Entries:         session.entries,
+			Errors:          session.errors,
+			Shutdown:        session.getShutdown(),
+		}
+		if bootSession.Shutdown == "unclean" {
+			bootSession.FinalMessages = session.finalMessages
+		}
+		report.BootSessions = append(report.BootSessions, bootSession)
+	}
+	for _, name := range logAnalysis.windowNames {
+		windowStat := logAnalysis.windowStats[name]
+		window := jsonWindow{
+			Name:              name,
+			Entries:           windowStat.entries,
+			SeverityFrequency: newJSONSeverityFrequency(windowStat.severityFrequency),
+			ErrorRate:         jsonFloat(getWindowErrorRate(windowStat)),
+			TopMessages:       []jsonTopMessage{},
+		}
+		messages := rankLogMessages(windowStat.messageFrequencies)
+		if len(messages) > 5 {
+			messages = messages[:5]
+		}
+		for _, message := range messages {
+			window.TopMessages = append(window.TopMessages, jsonTopMessage{Message: message, Frequency: windowStat.messageFrequencies[message]})
+		}
+		report.Windows = append(report.Windows, window)
+	}
+	for _, concentration := range logAnalysis.messageConcentrations {
+		report.HostLocalMessages = append(report.HostLocalMessages, jsonMessageConcentration{
+			Message:   concentration.message,
+			Total:     concentration.total,
+			LogPath:   concentration.logPath,
+			FileCount: concentration.fileCount,
+			Share:     jsonFloat(float64(concentration.fileCount) / float64(concentration.total)),
+			Files:     concentration.files,
+		})
+	}
+	for _, module := range getTransitionModules(logAnalysis.severityTransitions) {
+		for _, from := range severityLevels {
+			for _, to := range severityLevels {
+				transition := severityTransition{module: module, from: from, to: to}
+				if count := logAnalysis.severityTransitions[transition]; count > 0 {
+					report.SeverityTransitions = append(report.SeverityTransitions, jsonSeverityTransition{Module: module, From: from, To: to, Count: count})
+				}
+			}
+		}
+	}
+	for _, key := range getSortedFileParseErrors(logAnalysis.parseErrorCounts) {
+		report.MalformedLines = append(report.MalformedLines, jsonParseError{
+			LogPath: key.logPath,
+			Reason:  key.reason,
+			Count:   logAnalysis.parseErrorCounts[key],
+		})
+	}
+	report.Lines = logAnalysis.lines
+	report.MalformedLineCount = logAnalysis.malformedLines
+	for _, line := range logAnalysis.badLines {
+		report.BadLines = append(report.BadLines, jsonBadLine{LogPath: line.logPath, Line: line.lineNumber, Reason: line.reason, Text: line.text})
+	}
+	report.DataQualityWarnings = logAnalysis.dataQualityWarnings
+	report.Interrupted = logAnalysis.interrupted
+	for _, err := range logAnalysis.fileErrors {
+		var fileError *FileError
+		if errors.As(err, &fileError) {
+			report.FileErrors = append(report.FileErrors, jsonFileError{LogPath: fileError.Path, Line: fileError.Line, Error: fileError.Err.Error()})
+		}
+	}
+	for _, row := range budgetReport {
+		report.ErrorBudgets = append(report.ErrorBudgets, jsonBudgetRow{
+			Month:        row.month,
+			Module:       row.module,
+			Entries:      row.entries,
+			Errors:       row.errors,
+			MaxErrors:    row.budget.MaxErrors,
+			MaxErrorRate: jsonFloat(row.budget.MaxErrorRate),
+			Consumed:     jsonFloat(row.consumedRatio),
+			MonthElapsed: jsonFloat(row.elapsedRatio),
+		})
+	}
+	return
+}
+
+func writeLogAnalysisJSON(writer io.Writer, logAnalysis LogAnalysis, fileLogAnalyses []LogAnalysis, budgetReport []budgetReportRow) error {
+	report := newJSONLogAnalysis(logAnalysis, budgetReport)
+	for _, fileLogAnalysis := range fileLogAnalyses {
+		report.Files = append(report.Files, newJSONLogAnalysis(fileLogAnalysis, nil))
+	}
+	return writeCanonicalJSON(writer, report, "  ")
+}
+
+// writeCanonicalJSON writes value with object keys sorted at every level,
+// indented by indent or on one line when it is empty. Numbers are passed
+// through as encoded, so integers and jsonFloat values keep their exact text.
+func writeCanonicalJSON(writer io.Writer, value any, indent string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", indent)
+	return encoder.Encode(generic)
+}
@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestWriteLogAnalysisJSON(t *testing.T) {
+	logContent := `2024-01-01 00:00:00.000 | INFO | app.module: function: 123 - User logged in
+2024-01-01 00:01:00.000 | ERROR | app.module: function: 124 - Database error
+2024-01-01 00:02:00.000 | ERROR | app.module: function: 125 - Database error`
+
+	tmpFileName := createTestLogFile(t, logContent)
+	defer os.Remove(tmpFileName)
+	logAnalysis := analyzeLogFiles(context.Background(), []string{tmpFileName}, analysisOptions{})
+
+	var output bytes.Buffer
+	if err := writeLogAnalysisJSON(&output, logAnalysis, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var report Analysis
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, output.String())
+	}
+
+	if report.Entries != 3 || report.SeverityFrequency.Error != 2 || report.SeverityFrequency.Info != 1 {
+		t.Errorf("Unexpected counts in report: %+v", report)
+	}
+	if len(report.TopMessages) != 2 || report.TopMessages[0].Message != "Database error" || report.TopMessages[0].Frequency != 2 {
+		t.Errorf("Unexpected top messages in report: %+v", report.TopMessages)
+	}
+	if !report.StartTime.Equal(logAnalysis.startTime) || !report.EndTime.Equal(logAnalysis.endTime) {
+		t.Errorf("Unexpected time range in report: %v - %v", report.StartTime, report.EndTime)
+	}
+}
+
+func TestWriteLogAnalysisJSONIsCanonical(t *testing.T) {
+	tmpFile1 := createTestLogFile(t, `2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Disk full trace_id=abc
+2024-01-01 00:01:00.000 | ERROR | app.module: function: 2 - Cache miss`)
+	tmpFile2 := createTestLogFile(t, `2024-01-01 00:02:00.000 | WARNING | app.module: function: 3 - Broken pipe
+2024-01-01 00:03:00.000 | ERROR | app.module: function: 4 - Disk full trace_id=def`)
+	defer os.Remove(tmpFile1)
+	defer os.Remove(tmpFile2)
+
+	var first bytes.Buffer
+	logAnalysis := analyzeLogFiles(context.Background(), []string{tmpFile1, tmpFile2}, analysisOptions{})
+	if err := writeLogAnalysisJSON(&first, logAnalysis, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 10; run++ {
+		var again bytes.Buffer
+		logAnalysis := analyzeLogFiles(context.Background(), []string{tmpFile1, tmpFile2}, analysisOptions{})
+		if err := writeLogAnalysisJSON(&again, logAnalysis, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first.Bytes(), again.Bytes()) {
+			t.Fatalf("JSON report changed between runs:\n%s\n%s", first.String(), again.String())
+		}
+	}
+
+	output := first.String()
+	if bytes.Index(first.Bytes(), []byte(`"bytesRead"`)) > bytes.Index(first.Bytes(), []byte(`"entries"`)) {
+		t.Errorf("Expected sorted keys in report:\n%s", output)
+	}
+	if !regexp.MustCompile(`"slopePerHour": -?[0-9]+\.[0-9]{6},`).Match(first.Bytes()) {
+		t.Errorf("Expected fixed float formatting in report:\n%s", output)
+	}
+	var report Analysis
+	if err := json.Unmarshal(first.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	// All messages are tied, so they rank alphabetically
+	wantMessages := []string{"Broken pipe", "Cache miss", "Disk full trace_id=abc", "Disk full trace_id=def"}
+	for index, want := range wantMessages {
+		if report.TopMessages[index].Message != want {
+			t.Errorf("Top message %d = %q, want %q", index, report.TopMessages[index].Message, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// errLineFiltered marks raw lines -line-filter dropped without parsing them.
+var errLineFiltered = errors.New("Line filtered")
+
+type lineFilterTerm struct {
+	text    string
+	pattern *regexp.Regexp
+	negated bool
+}
+
+// lineFilter is a -line-filter: terms that must all hold for a raw line to be
+// parsed at all.
+type lineFilter struct {
+	terms []lineFilterTerm
+}
+
+// parseLineFilter reads terms separated by spaces: a word or "quoted text"
+// that must appear in the line, or a /regex/ it must match. A leading !
+// turns a term around, so `timeout !/health ?check/` keeps lines that
+// mention timeout but no health check. Text is matched case-sensitively,
+// as that is the fastest; regexes can use (?i).
+func parseLineFilter(filter string) (*lineFilter, error) {
+	var parsed lineFilter
+	rest := strings.TrimSpace(filter)
+	for rest != "" {
+		var term lineFilterTerm
+		if strings.HasPrefix(rest, "!") {
+			term.negated = true
+			rest = rest[1:]
+		}
+		delimiter := ""
+		if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "/") {
+			delimiter, rest = rest[:1], rest[1:]
+		}
+		var value string
+		if delimiter != "" {
+			end := strings.Index(rest, delimiter)
+			if end < 0 {
+				return nil, errors.New("unterminated " + delimiter + " in line filter " + filter)
+			}
+			value, rest = rest[:end], rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		if value == "" {
+			return nil, errors.New("empty term in line filter " + filter)
+		}
+		if delimiter == "/" {
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				return nil, err
+			}
+			term.pattern = pattern
+		} else {
+			term.text = value
+		}
+		parsed.terms = append(parsed.terms, term)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	if len(parsed.terms) == 0 {
+		return nil, errors.New("empty line filter")
+	}
+	return &parsed, nil
+}
+
+func (filter *lineFilter) matches(logRow string) bool {
+	for _, term := range filter.terms {
+		var found bool
+		if term.pattern != nil {
+			found = term.pattern.MatchString(logRow)
+		} else {
+			found = strings.Contains(logRow, term.text)
+		}
+		if found == term.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// getRequiredTexts lists the text every line passing filter contains.
+func (filter *lineFilter) getRequiredTexts() (texts []string) {
+	for _, term := range filter.terms {
+		if term.pattern == nil && !term.negated {
+			texts = append(texts, term.text)
+		}
+	}
+	return
+}
+
+// filterParser skips parser for the lines filter drops. With -multiline only
+// the lines that start an entry are filtered, and the lines continuing it
+// follow it in or out.
+func (filter *lineFilter) filterParser(parser Parser, entryStartPattern *regexp.Regexp) Parser {
+	return func(logRow string) (LogMessage, error) {
+		if filter.matches(logRow) || (entryStartPattern != nil && isContinuationLine(logRow, entryStartPattern)) {
+			return parser(logRow)
+		}
+		return LogMessage{}, errLineFiltered
+	}
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseLineFilter(t *testing.T) {
+	filter, err := parseLineFilter(`db "after 30s" !/(?i)retry(ing)?/`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"ERROR | db:query:12 - Timeout after 30s":           true,
+		"ERROR | db:query:12 - Timeout after 30s, Retrying": false,
+		"ERROR | db:query:12 - Timeout after 31s":           false,
+		"ERROR | api:get:3 - Timeout after 30s":             false,
+	}
+	for logRow, want := range tests {
+		if got := filter.matches(logRow); got != want {
+			t.Errorf("matches(%q) = %v, want %v", logRow, got, want)
+		}
+	}
+
+	for _, invalid := range []string{"", `"unterminated`, "/[/", `!""`} {
+		if _, err := parseLineFilter(invalid); err == nil {
+			t.Errorf("parseLineFilter(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestLineFilterSkipsParsing(t *testing.T) {
+	filter, _ := parseLineFilter("Timeout")
+	input := "2024-01-01 08:00:00.000 | ERROR | db:query:12 - Timeout after 30s\n" +
+		"2024-01-01 08:00:01.000 | INFO | db:query:12 - Query done\n" +
+		"not a log line\n"
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, analysisOptions{lineFilter: filter})
+	if len(logMessages) != 1 || logMessages[0].message != "Timeout after 30s" {
+		t.Errorf("parseLogReader() = %+v, want only the timeout", logMessages)
+	}
+	// The malformed line is dropped before parsing, so it isn't malformed
+	if stats.filteredLines != 2 || stats.malformedLines != 0 {
+		t.Errorf("filtered %d and malformed %d lines, want 2 and 0", stats.filteredLines, stats.malformedLines)
+	}
+}
+
+func TestLineFilterKeepsMultilineEntriesWhole(t *testing.T) {
+	filter, _ := parseLineFilter("Traceback")
+	input := "2024-01-01 08:00:00.000 | ERROR | app:run:1 - Traceback (most recent call last):\n" +
+		"  File \"app.py\", line 1\n" +
+		"2024-01-01 08:00:01.000 | ERROR | app:run:2 - Failed\n" +
+		"  File \"app.py\", line 2\n"
+	options := analysisOptions{lineFilter: filter, entryStartPattern: defaultEntryStartPattern}
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, options)
+	if len(logMessages) != 1 || logMessages[0].Attribute("stack_trace") != `  File "app.py", line 1` {
+		t.Errorf("parseLogReader() = %+v, want the traceback with its continuation", logMessages)
+	}
+	if stats.filteredLines != 2 {
+		t.Errorf("filtered %d lines, want the failure and its continuation", stats.filteredLines)
+	}
+}
@@ -0,0 +1,439 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A line index is a sidecar file next to a log, named after it with
+// lineIndexSuffix. It holds the byte offset where every line starts, so any
+// line range is found without reading what comes before it, and a bloom
+// filter of the byte trigrams in every block of lines, so -line-filter can
+// skip blocks that can't contain its text. The index records the log's
+// size and modification time, and is ignored once either changes.
+//
+// The layout, in little-endian integers, is the header
+//
+//	magic | log size | log mtime | lines | lines per block | bloom size
+//
+// then lines+1 offsets, the last being the end of the log, then for every
+// block its count of non-blank lines and its bloom filter.
+const (
+	lineIndexMagic         = "CLAIDX1\n"
+	lineIndexSuffix        = ".clidx"
+	lineIndexHeaderSize    = len(lineIndexMagic) + 5*8
+	defaultIndexBlockLines = 4096
+	indexBloomBytes        = 8192
+	indexBloomHashes       = 3
+)
+
+var errStaleLineIndex = errors.New("line index is out of date")
+
+type lineIndex struct {
+	data       []byte
+	lines      int64
+	blockLines int64
+	bloomBytes int64
+	release    func() error
+}
+
+func getLineIndexPath(logPath string) string {
+	return logPath + lineIndexSuffix
+}
+
+// trigramSet builds the bloom filter of a block. Lines in a block repeat
+// most of their trigrams, so each one is hashed only the first time.
+type trigramSet struct {
+	seen     []uint64
+	trigrams []uint32
+}
+
+func newTrigramSet() *trigramSet {
+	return &trigramSet{seen: make([]uint64, 1<<24/64)}
+}
+
+// add records every three byte substring of text.
+func (set *trigramSet) add(text string) {
+	if len(text) < 3 {
+		return
+	}
+	trigram := uint32(text[0]) | uint32(text[1])<<8
+	for index := 2; index < len(text); index++ {
+		trigram = trigram&0xffff | uint32(text[index])<<16
+		if set.seen[trigram/64]&(1<<(trigram%64)) == 0 {
+			set.seen[trigram/64] |= 1 << (trigram % 64)
+			set.trigrams = append(set.trigrams, trigram)
+		}
+		trigram >>= 8
+	}
+}
+
+// flush sets the bits of the trigrams added since the last flush in bloom.
+func (set *trigramSet) flush(bloom []byte) {
+	for _, trigram := range set.trigrams {
+		for _, bit := range getTrigramBits(string([]byte{byte(trigram), byte(trigram >> 8), byte(trigram >> 16)}), len(bloom)*8) {
+			bloom[bit/8] |= 1 << (bit % 8)
+		}
+		set.seen[trigram/64] = 0
+	}
+	set.trigrams = set.trigrams[:0]
+}
+
+// mayContain reports whether text can be in the block of bloom. Text under
+// three bytes has no trigrams, so it always can.
+func mayContain(bloom []byte, text string) bool {
+	for index := 0; index+3 <= len(text); index++ {
+		for _, bit := range getTrigramBits(text[index:index+3], len(bloom)*8) {
+			if bloom[bit/8]&(1<<(bit%8)) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// getTrigramBits hashes a trigram to its bits in a bloom filter of bits bits,
+// which is a power of two.
+func getTrigramBits(trigram string, bits int) (positions [indexBloomHashes]int) {
+	hash := uint64(trigram[0]) | uint64(trigram[1])<<8 | uint64(trigram[2])<<16
+	hash *= 0x9e3779b97f4a7c15
+	first, step := hash>>32, hash&0xffffffff|1
+	for index := range positions {
+		positions[index] = int((first + uint64(index)*step) & uint64(bits-1))
+	}
+	return
+}
+
+// writeLineIndex indexes logPath with blockLines lines per bloom filter.
+// Offsets are written as they are found and the blocks are buffered in a
+// second file, so memory stays bounded however long the log is.
+func writeLineIndex(logPath string, blockLines int64) (lines int64, err error) {
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		return
+	}
+	defer logFile.Close()
+	logInfo, err := logFile.Stat()
+	if err != nil {
+		return
+	}
+	indexPath := getLineIndexPath(logPath)
+	indexFile, err := os.CreateTemp(filepath.Dir(indexPath), filepath.Base(indexPath)+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(indexFile.Name())
+	defer indexFile.Close()
+	blocksFile, err := os.CreateTemp(filepath.Dir(indexPath), filepath.Base(indexPath)+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(blocksFile.Name())
+	defer blocksFile.Close()
+
+	offsets := bufio.NewWriter(indexFile)
+	blocks := bufio.NewWriter(blocksFile)
+	if _, err = offsets.Write(make([]byte, lineIndexHeaderSize)); err != nil {
+		return
+	}
+	bloom := make([]byte, indexBloomBytes)
+	trigrams := newTrigramSet()
+	offsetBytes := make([]byte, 8)
+	var offset, nonBlankLines int64
+	writeBlock := func() error {
+		trigrams.flush(bloom)
+		if _, err := blocks.Write(binary.LittleEndian.AppendUint64(nil, uint64(nonBlankLines))); err != nil {
+			return err
+		}
+		_, err := blocks.Write(bloom)
+		clear(bloom)
+		nonBlankLines = 0
+		return err
+	}
+	reader := bufio.NewReaderSize(logFile, streamBufferSize)
+	for {
+		logRow, readErr := reader.ReadString('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return lines, readErr
+		}
+		if logRow == "" {
+			break
+		}
+		binary.LittleEndian.PutUint64(offsetBytes, uint64(offset))
+		if _, err = offsets.Write(offsetBytes); err != nil {
+			return
+		}
+		offset += int64(len(logRow))
+		lines++
+		if logRow = strings.TrimRight(logRow, "\r\n"); strings.TrimSpace(logRow) != "" {
+			nonBlankLines++
+			trigrams.add(logRow)
+		}
+		if lines%blockLines == 0 {
+			if err = writeBlock(); err != nil {
+				return
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if lines%blockLines != 0 {
+		if err = writeBlock(); err != nil {
+			return
+		}
+	}
+	binary.LittleEndian.PutUint64(offsetBytes, uint64(offset))
+	if _, err = offsets.Write(offsetBytes); err != nil {
+		return
+	}
+	if err = blocks.Flush(); err != nil {
+		return
+	}
+	if _, err = blocksFile.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	if _, err = io.Copy(offsets, blocksFile); err != nil {
+		return
+	}
+	if err = offsets.Flush(); err != nil {
+		return
+	}
+	header := append([]byte(lineIndexMagic), make([]byte, lineIndexHeaderSize-len(lineIndexMagic))...)
+	for field, value := range []int64{offset, logInfo.ModTime().UnixNano(), lines, blockLines, indexBloomBytes} {
+		binary.LittleEndian.PutUint64(header[len(lineIndexMagic)+field*8:], uint64(value))
+	}
+	if _, err = indexFile.WriteAt(header, 0); err != nil {
+		return
+	}
+	if err = indexFile.Close(); err != nil {
+		return
+	}
+	err = os.Rename(indexFile.Name(), indexPath)
+	return
+}
+
+// loadLineIndex maps the index of logPath into memory. It fails with
+// errStaleLineIndex when the log changed since it was indexed, and with
+// os.ErrNotExist when it never was.
+func loadLineIndex(logPath string) (index *lineIndex, err error) {
+	logInfo, err := os.Stat(logPath)
+	if err != nil {
+		return
+	}
+	indexFile, err := os.Open(getLineIndexPath(logPath))
+	if err != nil {
+		return
+	}
+	defer indexFile.Close()
+	indexInfo, err := indexFile.Stat()
+	if err != nil {
+		return
+	}
+	data, release, err := mapFile(indexFile, int(indexInfo.Size()))
+	if err != nil {
+		return
+	}
+	index = &lineIndex{data: data, release: release}
+	if err = index.parseHeader(logInfo); err != nil {
+		index.close()
+		return nil, err
+	}
+	return
+}
+
+func (index *lineIndex) parseHeader(logInfo os.FileInfo) error {
+	if len(index.data) < lineIndexHeaderSize || string(index.data[:len(lineIndexMagic)]) != lineIndexMagic {
+		return errors.New("not a line index")
+	}
+	field := func(number int) int64 {
+		return int64(binary.LittleEndian.Uint64(index.data[len(lineIndexMagic)+number*8:]))
+	}
+	index.lines, index.blockLines, index.bloomBytes = field(2), field(3), field(4)
+	if index.blockLines <= 0 || index.bloomBytes <= 0 || index.bloomBytes&(index.bloomBytes-1) != 0 || index.lines < 0 ||
+		int64(len(index.data)) != int64(lineIndexHeaderSize)+(index.lines+1)*8+index.blocks()*(8+index.bloomBytes) {
+		return errors.New("line index is corrupt")
+	}
+	if field(0) != logInfo.Size() || field(1) != logInfo.ModTime().UnixNano() {
+		return errStaleLineIndex
+	}
+	return nil
+}
+
+func (index *lineIndex) close() error {
+	return index.release()
+}
+
+func (index *lineIndex) blocks() int64 {
+	return (index.lines + index.blockLines - 1) / index.blockLines
+}
+
+// lineOffset returns where line starts, counting from 0; line lines is the
+// end of the log.
+func (index *lineIndex) lineOffset(line int64) int64 {
+	return int64(binary.LittleEndian.Uint64(index.data[int64(lineIndexHeaderSize)+line*8:]))
+}
+
+// block returns the non-blank line count and bloom filter of a block.
+func (index *lineIndex) block(block int64) (nonBlankLines int64, bloom []byte) {
+	start := int64(lineIndexHeaderSize) + (index.lines+1)*8 + block*(8+index.bloomBytes)
+	nonBlankLines = int64(binary.LittleEndian.Uint64(index.data[start:]))
+	return nonBlankLines, index.data[start+8 : start+8+index.bloomBytes]
+}
+
+// getSkippedBlocks marks the blocks without some text every line passing
+// filter must contain. It returns nil when filter requires no such text.
+func (index *lineIndex) getSkippedBlocks(filter *lineFilter) (skipped []bool) {
+	required := filter.getRequiredTexts()
+	if len(required) == 0 {
+		return
+	}
+	skipped = make([]bool, index.blocks())
+	for block := range skipped {
+		_, bloom := index.block(int64(block))
+		for _, text := range required {
+			if !mayContain(bloom, text) {
+				skipped[block] = true
+				break
+			}
+		}
+	}
+	return
+}
+
+// blockSkip lets streamLogMessages pass over the blocks of an indexed file
+// that -line-filter rules out without reading them line by line. The reader
+// starts at firstLine and stops at endLine, both counting from 0 and at the
+// start of a block unless endLine is the end of the file.
+type blockSkip struct {
+	index     *lineIndex
+	skipped   []bool
+	firstLine int64
+	endLine   int64
+}
+
+func newBlockSkip(index *lineIndex, filter *lineFilter, firstLine int64, endLine int64) *blockSkip {
+	skipped := index.getSkippedBlocks(filter)
+	if skipped == nil {
+		return nil
+	}
+	return &blockSkip{index: index, skipped: skipped, firstLine: firstLine, endLine: endLine}
+}
+
+// next returns how many lines, non-blank lines and bytes to skip from line,
+// counting from the reader's first line: the skipped blocks in a row
+// starting there, if it starts one.
+func (skip *blockSkip) next(line int64) (lines int64, nonBlankLines int64, size int64) {
+	line += skip.firstLine
+	if line%skip.index.blockLines != 0 {
+		return
+	}
+	block := line / skip.index.blockLines
+	for ; block*skip.index.blockLines < skip.endLine && skip.skipped[block]; block++ {
+		blockNonBlankLines, _ := skip.index.block(block)
+		nonBlankLines += blockNonBlankLines
+		metrics.counter("index_blocks_skipped").Add(1)
+	}
+	end := min(block*skip.index.blockLines, skip.endLine)
+	if end <= line {
+		return 0, 0, 0
+	}
+	return end - line, nonBlankLines, skip.index.lineOffset(end) - skip.index.lineOffset(line)
+}
+
+// readLineRange returns lines from through to of logPath, counting from 1,
+// using its index to seek straight to them when it has a current one.
+func readLineRange(logPath string, from int64, to int64) (logRows []string, err error) {
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		return
+	}
+	defer logFile.Close()
+	var line int64 = 1
+	if index, indexErr := loadLineIndex(logPath); indexErr == nil {
+		defer index.close()
+		if from > index.lines {
+			return
+		}
+		if _, err = logFile.Seek(index.lineOffset(from-1), io.SeekStart); err != nil {
+			return
+		}
+		line = from
+	}
+	reader := bufio.NewReaderSize(logFile, streamBufferSize)
+	for ; line <= to; line++ {
+		logRow, readErr := reader.ReadString('\n')
+		if logRow != "" && line >= from {
+			logRows = append(logRows, strings.TrimRight(logRow, "\r\n"))
+		}
+		if errors.Is(readErr, io.EOF) {
+			return
+		}
+		if readErr != nil {
+			return logRows, readErr
+		}
+	}
+	return
+}
+
+func runIndex(args []string) {
+	flagSet := flag.NewFlagSet("index", flag.ExitOnError)
+	blockLines := flagSet.Int64("block-lines", defaultIndexBlockLines, "lines per bloom filter; smaller blocks let -line-filter skip more at the cost of a larger index")
+	flagSet.Parse(args)
+	if flagSet.NArg() == 0 || *blockLines <= 0 {
+		fmt.Fprintln(os.Stderr, "Usage: index [-block-lines 4096] file...")
+		os.Exit(exitUsage)
+	}
+	status := exitOK
+	for _, logPath := range flagSet.Args() {
+		lines, err := writeLineIndex(logPath, *blockLines)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error indexing "+logPath+":", err)
+			status = exitFailure
+			continue
+		}
+		fmt.Println(getLineIndexPath(logPath) + ": " + strconv.FormatInt(lines, 10) + " lines")
+	}
+	os.Exit(status)
+}
+
+// runLines prints a line range with line numbers, like the context around a
+// line -show-bad-lines reported.
+func runLines(args []string) {
+	flagSet := flag.NewFlagSet("lines", flag.ExitOnError)
+	contextLines := flagSet.Int64("context", 0, "also print this many lines before and after the range")
+	flagSet.Parse(args)
+	usage := "Usage: lines [-context 3] file line[-line]"
+	if flagSet.NArg() != 2 || *contextLines < 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+	fromText, toText, isRange := strings.Cut(flagSet.Arg(1), "-")
+	from, fromErr := strconv.ParseInt(fromText, 10, 64)
+	to, toErr := from, error(nil)
+	if isRange {
+		to, toErr = strconv.ParseInt(toText, 10, 64)
+	}
+	if fromErr != nil || toErr != nil || from < 1 || to < from {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+	from = max(1, from-*contextLines)
+	to += *contextLines
+	logRows, err := readLineRange(flagSet.Arg(0), from, to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
+		os.Exit(exitFailure)
+	}
+	for offset, logRow := range logRows {
+		fmt.Println(strconv.FormatInt(from+int64(offset), 10) + ": " + logRow)
+	}
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeIndexedLog writes lines to a log in a temporary directory and indexes
+// it with blockLines lines per block.
+func writeIndexedLog(t *testing.T, lines []string, blockLines int64) string {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeLineIndex(logPath, blockLines); err != nil {
+		t.Fatal(err)
+	}
+	return logPath
+}
+
+func getIndexTestLines() (lines []string) {
+	for line := 1; line <= 10; line++ {
+		message := "Query done"
+		if line == 6 {
+			message = "Timeout after 30s"
+		}
+		lines = append(lines, "2024-01-01 08:00:0"+strconv.Itoa(line%10)+".000 | INFO | db:query:"+strconv.Itoa(line)+" - "+message)
+	}
+	return
+}
+
+func TestLineIndexOffsets(t *testing.T) {
+	logPath := writeIndexedLog(t, []string{"first", "", "third line"}, 2)
+	index, err := loadLineIndex(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.close()
+	if index.lines != 3 || index.blocks() != 2 {
+		t.Fatalf("index has %d lines in %d blocks, want 3 in 2", index.lines, index.blocks())
+	}
+	for line, want := range []int64{0, 6, 7, 18} {
+		if got := index.lineOffset(int64(line)); got != want {
+			t.Errorf("lineOffset(%d) = %d, want %d", line, got, want)
+		}
+	}
+	if nonBlankLines, _ := index.block(0); nonBlankLines != 1 {
+		t.Errorf("first block has %d non-blank lines, want 1", nonBlankLines)
+	}
+}
+
+func TestLineIndexIsStaleAfterAppend(t *testing.T) {
+	logPath := writeIndexedLog(t, []string{"first"}, 2)
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFile.WriteString("second\n")
+	logFile.Close()
+	if _, err := loadLineIndex(logPath); !errors.Is(err, errStaleLineIndex) {
+		t.Errorf("loadLineIndex() = %v, want %v", err, errStaleLineIndex)
+	}
+	if _, err := loadLineIndex(filepath.Join(t.TempDir(), "missing.log")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("loadLineIndex() of a missing log = %v, want not exist", err)
+	}
+}
+
+func TestLineIndexSkipsBlocks(t *testing.T) {
+	logPath := writeIndexedLog(t, getIndexTestLines(), 4)
+	index, err := loadLineIndex(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.close()
+	filter, _ := parseLineFilter("Timeout !/retry/")
+	if got, want := index.getSkippedBlocks(filter), []bool{true, false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getSkippedBlocks() = %v, want %v", got, want)
+	}
+	// A filter of only regexes and negations can't rule out any block
+	filter, _ = parseLineFilter("/Time+out/")
+	if skipped := index.getSkippedBlocks(filter); skipped != nil {
+		t.Errorf("getSkippedBlocks() = %v, want nil", skipped)
+	}
+}
+
+func TestLineIndexFilterMatchesUnindexed(t *testing.T) {
+	logPath := writeIndexedLog(t, getIndexTestLines(), 4)
+	index, err := loadLineIndex(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.close()
+	filter, _ := parseLineFilter("Timeout")
+	parse := func(index *lineIndex) ([]LogMessage, parseStats) {
+		logFile, err := os.Open(logPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer logFile.Close()
+		logMessages, _, stats := parseLogReader(context.Background(), logFile, logPath, analysisOptions{lineFilter: filter, lineIndex: index})
+		return logMessages, stats
+	}
+	wantMessages, wantStats := parse(nil)
+	gotMessages, gotStats := parse(index)
+	if !reflect.DeepEqual(gotMessages, wantMessages) || len(gotMessages) != 1 {
+		t.Errorf("indexed parse = %+v, want %+v", gotMessages, wantMessages)
+	}
+	if gotStats.lines != wantStats.lines || gotStats.filteredLines != wantStats.filteredLines || gotStats.bytesRead != wantStats.bytesRead {
+		t.Errorf("indexed parse read %d lines, filtered %d and read %d bytes, want %d, %d and %d",
+			gotStats.lines, gotStats.filteredLines, gotStats.bytesRead, wantStats.lines, wantStats.filteredLines, wantStats.bytesRead)
+	}
+}
+
+func TestReadLineRange(t *testing.T) {
+	lines := getIndexTestLines()
+	indexedPath := writeIndexedLog(t, lines, 4)
+	plainPath := filepath.Join(t.TempDir(), "plain.log")
+	os.WriteFile(plainPath, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	for _, logPath := range []string{indexedPath, plainPath} {
+		got, err := readLineRange(logPath, 5, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := lines[4:7]; !reflect.DeepEqual(got, want) {
+			t.Errorf("readLineRange(%s, 5, 7) = %q, want %q", filepath.Base(logPath), got, want)
+		}
+		if got, _ := readLineRange(logPath, 10, 20); len(got) != 1 {
+			t.Errorf("readLineRange(%s, 10, 20) = %q, want the last line", filepath.Base(logPath), got)
+		}
+		if got, _ := readLineRange(logPath, 11, 20); len(got) != 0 {
+			t.Errorf("readLineRange(%s, 11, 20) = %q, want nothing", filepath.Base(logPath), got)
+		}
+	}
+}
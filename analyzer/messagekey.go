@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// maxMessageExamples is how many raw messages are kept for each key.
+const maxMessageExamples = 3
+
+// maxExampleCandidates is how many raw messages are counted for each key to
+// find its examples. The counts are exact for keys with no more variants.
+const maxExampleCandidates = 16
+
+// MessageKeyer computes the identity entries are counted and ranked by in
+// the top messages, trends and spikes, host-local messages, trace IDs and
+// the messages per thread, module and window. Entries with the same key
+// count as one message. It is called from several goroutines at once.
+type MessageKeyer interface {
+	MessageKey(logMessage LogMessage) string
+}
+
+// rawMessageKeyer identifies entries by their message text, as when no
+// keyer is set.
+type rawMessageKeyer struct{}
+
+func (rawMessageKeyer) MessageKey(logMessage LogMessage) string { return logMessage.message }
+
+// templateMessageKeyer identifies entries by templateMessage.
+type templateMessageKeyer struct{}
+
+func (templateMessageKeyer) MessageKey(logMessage LogMessage) string {
+	return templateMessage(logMessage.message)
+}
+
+// fieldsMessageKeyer identifies entries by the named fields, written like
+// module=pay severity=ERROR so the report shows what each key stands for.
+// The same message logged by two modules, or at two severities, can thus
+// count as two.
+type fieldsMessageKeyer struct {
+	fields []string
+}
+
+var messageKeyFields = map[string]func(logMessage LogMessage) string{
+	"message":  func(logMessage LogMessage) string { return logMessage.message },
+	"template": func(logMessage LogMessage) string { return templateMessage(logMessage.message) },
+	"module":   func(logMessage LogMessage) string { return logMessage.module },
+	"function": func(logMessage LogMessage) string { return logMessage.function },
+	"line":     func(logMessage LogMessage) string { return strconv.FormatInt(logMessage.lineNumber, 10) },
+	"severity": func(logMessage LogMessage) string { return logMessage.severity },
+}
+
+func (keyer fieldsMessageKeyer) MessageKey(logMessage LogMessage) string {
+	pairs := make([]string, len(keyer.fields))
+	for index, field := range keyer.fields {
+		pairs[index] = field + "=" + formatLogfmtValue(messageKeyFields[field](logMessage))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// NewMessageKeyer returns the keyer named like the -message-key values:
+// "raw", "template", or "fields:" followed by a comma-separated list of
+// message, template, module, function, line and severity.
+func NewMessageKeyer(spec string) (MessageKeyer, error) {
+	switch spec {
+	case "raw":
+		return rawMessageKeyer{}, nil
+	case "template":
+		return templateMessageKeyer{}, nil
+	}
+	fieldList, ok := strings.CutPrefix(spec, "fields:")
+	if !ok {
+		return nil, errors.New("unknown message key " + spec + ", expected raw, template or fields:<field>,...")
+	}
+	var keyer fieldsMessageKeyer
+	for _, field := range strings.Split(fieldList, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := messageKeyFields[field]; !ok {
+			return nil, errors.New("unknown message key field " + strconv.Quote(field) +
+				", expected message, template, module, function, line or severity")
+		}
+		keyer.fields = append(keyer.fields, field)
+	}
+	return keyer, nil
+}
+
+// keyLogMessages sets the key every entry is counted by.
+func keyLogMessages(logMessages []LogMessage, keyer MessageKeyer) {
+	for index := range logMessages {
+		logMessages[index].key = keyer.MessageKey(logMessages[index])
+	}
+}
+
+// messageKey is the entry's key, or its message when no keyer is set.
+func (logMessage LogMessage) messageKey() string {
+	if logMessage.key != "" {
+		return logMessage.key
+	}
+	return logMessage.message
+}
+
+// messageExampleCounts counts the raw messages behind each key that isn't
+// the message itself. Counts are merged across chunks and files before the
+// examples are picked, so they don't depend on where a file was split.
+type messageExampleCounts map[string]map[string]int64
+
+// add counts a raw message of key. Past maxExampleCandidates raw messages,
+// the least frequent is replaced and the new one takes over its count, as
+// in the Space-Saving sketch, so a key with millions of variants still
+// holds a few and a frequent message can't be pushed out by rare ones.
+func (exampleCounts messageExampleCounts) add(key string, message string, count int64) {
+	candidates := exampleCounts[key]
+	if candidates == nil {
+		candidates = make(map[string]int64)
+		exampleCounts[key] = candidates
+	}
+	if _, ok := candidates[message]; ok || len(candidates) < maxExampleCandidates {
+		candidates[message] += count
+		return
+	}
+	leastMessage := ""
+	leastCount := int64(-1)
+	for candidate, candidateCount := range candidates {
+		if leastCount < 0 || candidateCount < leastCount || candidateCount == leastCount && candidate > leastMessage {
+			leastMessage, leastCount = candidate, candidateCount
+		}
+	}
+	delete(candidates, leastMessage)
+	candidates[message] = leastCount + count
+}
+
+// merge adds the counts of another chunk or file, most frequent first so
+// replacements don't depend on map order.
+func (exampleCounts messageExampleCounts) merge(from messageExampleCounts) {
+	for key, candidates := range from {
+		for _, message := range rankLogMessages(candidates) {
+			exampleCounts.add(key, message, candidates[message])
+		}
+	}
+}
+
+// getMessageExampleCounts counts a chunk's raw messages by key.
+func getMessageExampleCounts(logMessages []LogMessage) messageExampleCounts {
+	frequencies := make(map[string]map[string]int64)
+	for _, logMessage := range logMessages {
+		if logMessage.key == "" || logMessage.key == logMessage.message {
+			continue
+		}
+		if frequencies[logMessage.key] == nil {
+			frequencies[logMessage.key] = make(map[string]int64)
+		}
+		frequencies[logMessage.key][logMessage.message] += 1
+	}
+	exampleCounts := make(messageExampleCounts, len(frequencies))
+	exampleCounts.merge(frequencies)
+	return exampleCounts
+}
+
+// getMessageExamples picks up to maxMessageExamples of the most frequent
+// raw messages of each key.
+func getMessageExamples(exampleCounts messageExampleCounts) (messageExamples map[string][]string) {
+	messageExamples = make(map[string][]string, len(exampleCounts))
+	for key, candidates := range exampleCounts {
+		messages := rankLogMessages(candidates)
+		messageExamples[key] = messages[:min(len(messages), maxMessageExamples)]
+	}
+	return
+}
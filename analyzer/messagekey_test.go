@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTemplateMessageKeyer(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "ERROR", message: "Timeout after 30s"},
+		{timestamp: "2024-01-01 08:00:01", severity: "ERROR", message: "Timeout after 30s"},
+		{timestamp: "2024-01-01 08:00:02", severity: "ERROR", message: "Timeout after 45s"},
+		{timestamp: "2024-01-01 08:00:03", severity: "ERROR", message: "Timeout after 5s"},
+		{timestamp: "2024-01-01 08:00:04", severity: "ERROR", message: "Timeout after 60s"},
+		{timestamp: "2024-01-01 08:00:05", severity: "INFO", message: "Started"},
+		{timestamp: "2024-01-01 08:00:06", severity: "INFO", message: "Started"},
+	}
+	options := analysisOptions{messageKeyer: templateMessageKeyer{}}
+	first := newLogAnalysis("a.log", testLogs, nil, parseStats{lines: int64(len(testLogs))}, options)
+	second := newLogAnalysis("b.log", testLogs[:1], nil, parseStats{lines: 1}, options)
+	logAnalysis := analyzelogAnalyses([]LogAnalysis{first, second})
+
+	if logAnalysis.topFiveLogMessages[0] != "Timeout after <num>" || logAnalysis.topFiveLogMessageFrequencies[0] != 6 {
+		t.Errorf("Expected the template to rank first with 6, got %q with %d",
+			logAnalysis.topFiveLogMessages[0], logAnalysis.topFiveLogMessageFrequencies[0])
+	}
+	examples := logAnalysis.messageExamples["Timeout after <num>"]
+	// The most frequent raw message first, at most maxMessageExamples
+	if len(examples) != maxMessageExamples || examples[0] != "Timeout after 30s" {
+		t.Errorf("Expected %d examples starting with the most frequent, got %q", maxMessageExamples, examples)
+	}
+	if _, ok := logAnalysis.messageExamples["Started"]; ok {
+		t.Errorf("Expected no examples for a message without placeholders, got %q", logAnalysis.messageExamples["Started"])
+	}
+}
+
+func TestMessageExamplesDontDependOnChunks(t *testing.T) {
+	var input strings.Builder
+	// 45s shows up first but 30s is the most frequent by the end
+	for _, seconds := range []string{"45", "5", "60", "30", "30", "30", "45"} {
+		input.WriteString("2024-01-01 08:00:00.000 | ERROR | db:query:12 - Timeout after " + seconds + "s\n")
+	}
+	want := []string{"Timeout after 30s", "Timeout after 45s", "Timeout after 5s"}
+	for _, chunkEntries := range []int{1, 2, 0} {
+		options := analysisOptions{messageKeyer: templateMessageKeyer{}, chunkEntries: chunkEntries}
+		logAnalysis := analyzelogAnalyses([]LogAnalysis{analyzeLogReader(context.Background(), strings.NewReader(input.String()), "app.log", options)})
+		if got := logAnalysis.messageExamples["Timeout after <num>"]; !slices.Equal(got, want) {
+			t.Errorf("Examples counted %d entries at a time = %q, want %q", chunkEntries, got, want)
+		}
+	}
+}
+
+func TestMessageExampleCountsAreBounded(t *testing.T) {
+	exampleCounts := make(messageExampleCounts)
+	exampleCounts.add("Timeout after <num>", "Timeout after 30s", 100)
+	for variant := 0; variant < 10*maxExampleCandidates; variant++ {
+		exampleCounts.add("Timeout after <num>", "Timeout after "+strconv.Itoa(variant)+"ms", 1)
+	}
+	candidates := exampleCounts["Timeout after <num>"]
+	if len(candidates) != maxExampleCandidates || candidates["Timeout after 30s"] != 100 {
+		t.Errorf("Expected %d candidates keeping the frequent message, got %d: %v", maxExampleCandidates, len(candidates), candidates)
+	}
+}
+
+func TestMessageKeyBeforeScrub(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "ERROR", message: "Timeout after 30s"},
+		{timestamp: "2024-01-01 08:00:01", severity: "ERROR", message: "Timeout after 45s"},
+	}
+	logAnalysis := newLogAnalysis("a.log", testLogs, nil, parseStats{lines: 2}, analysisOptions{messageKeyer: templateMessageKeyer{}, aggregatesOnly: true})
+	if !slices.Equal(logAnalysis.topFiveLogMessages[:1], []string{getMessageID("Timeout after <num>")}) || logAnalysis.messageExamples != nil {
+		t.Errorf("Expected the template ID and no examples, got %q and %q", logAnalysis.topFiveLogMessages, logAnalysis.messageExamples)
+	}
+}
+
+func TestFieldsMessageKeyer(t *testing.T) {
+	keyer, err := NewMessageKeyer("fields:module, template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "ERROR", module: "db", message: "Timeout after 30s"},
+		{timestamp: "2024-01-01 08:00:01", severity: "WARN", module: "db", message: "Timeout after 45s"},
+		{timestamp: "2024-01-01 08:00:02", severity: "ERROR", module: "api", message: "Timeout after 30s"},
+	}
+	logAnalysis := newLogAnalysis("a.log", testLogs, nil, parseStats{lines: 3}, analysisOptions{messageKeyer: keyer, groupByModule: true})
+	dbKey := keyer.MessageKey(testLogs[0])
+	if want := `module=db template="Timeout after <num>"`; dbKey != want {
+		t.Errorf("MessageKey() = %q, want %q", dbKey, want)
+	}
+	if logAnalysis.messageFrequencies[dbKey] != 2 || len(logAnalysis.messageFrequencies) != 2 {
+		t.Errorf("Expected db's timeouts under one key and api's under another, got %v", logAnalysis.messageFrequencies)
+	}
+	if logAnalysis.moduleStats["db"].messageFrequencies[dbKey] != 2 {
+		t.Errorf("Expected the module breakdown to count by the same key, got %v", logAnalysis.moduleStats["db"].messageFrequencies)
+	}
+	if !slices.Equal(logAnalysis.messageExamples[dbKey], []string{"Timeout after 30s", "Timeout after 45s"}) {
+		t.Errorf("Expected the raw messages as examples, got %q", logAnalysis.messageExamples[dbKey])
+	}
+	counts := getMessageBucketCounts(testLogs)
+	if len(counts) != 2 {
+		t.Errorf("Expected trends and spikes to count by key, got %v", counts)
+	}
+}
+
+func TestNewMessageKeyer(t *testing.T) {
+	for _, spec := range []string{"raw", "template", "fields:severity,message"} {
+		if _, err := NewMessageKeyer(spec); err != nil {
+			t.Errorf("NewMessageKeyer(%q) failed: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "hash", "fields:", "fields:module,host"} {
+		if _, err := NewMessageKeyer(spec); err == nil {
+			t.Errorf("Expected NewMessageKeyer(%q) to fail", spec)
+		}
+	}
+}
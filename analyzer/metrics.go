@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// metricsRegistry holds process-wide counters that every worker updates
+// concurrently, so totals are available while an analysis is still running.
+type metricsRegistry struct {
+	mutex    sync.Mutex
+	counters map[string]*atomic.Int64
+}
+
+var metrics = newMetricsRegistry()
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{counters: make(map[string]*atomic.Int64)}
+}
+
+func (registry *metricsRegistry) counter(name string) *atomic.Int64 {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	counter, ok := registry.counters[name]
+	if !ok {
+		counter = new(atomic.Int64)
+		registry.counters[name] = counter
+	}
+	return counter
+}
+
+func (registry *metricsRegistry) snapshot() (values map[string]int64) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	values = make(map[string]int64, len(registry.counters))
+	for name, counter := range registry.counters {
+		values[name] = counter.Load()
+	}
+	return
+}
+
+func printStats(writer io.Writer, values map[string]int64) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(writer, "Stats: ")
+	for _, name := range names {
+		fmt.Fprintln(writer, "   "+name+": "+fmt.Sprint(values[name]))
+	}
+}
@@ -0,0 +1,27 @@
+package analyzer
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMetricsRegistryConcurrentCounters(t *testing.T) {
+	registry := newMetricsRegistry()
+	var workers sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := 0; index < 1000; index++ {
+				registry.counter("lines_parsed").Add(1)
+				registry.counter("bytes_read").Add(10)
+			}
+		}()
+	}
+	workers.Wait()
+
+	snapshot := registry.snapshot()
+	if snapshot["lines_parsed"] != 8000 || snapshot["bytes_read"] != 80000 {
+		t.Errorf("snapshot() = %v, want 8000 lines and 80000 bytes", snapshot)
+	}
+}
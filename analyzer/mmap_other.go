@@ -0,0 +1,18 @@
+//go:build !unix
+
+package analyzer
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of file, where memory mapping isn't
+// available.
+func mapFile(file *os.File, size int) (data []byte, release func() error, err error) {
+	data = make([]byte, size)
+	if _, err = io.ReadFull(file, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// adb logcat -v threadtime, optionally with the year modifier:
+// 01-02 15:04:05.123  1234  5678 I ActivityManager: Start proc 4242:com.example/u0a123
+var logcatPattern = regexp.MustCompile(`^(?:(\d{4})-)?(\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3})\s+(\d+)\s+(\d+)\s+([VDIWEFA])\s+(.*?)\s*: (.*)$`)
+
+// The default style of log show:
+// 2024-01-02 15:04:05.123456-0800 0x1a2b  Default  0x0  123  0  SpringBoard: (FrontBoard) [com.apple.FrontBoard:Common] Scene update
+var iosDefaultPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d+[+-]\d{4})\s+0x([0-9a-f]+)\s+(\w+)\s+0x[0-9a-f]+\s+(\d+)\s+\d+\s+([^:]+): (.*)$`)
+
+// The compact style of log show:
+// 2024-01-02 15:04:05.123 Df SpringBoard[123:1a2b] (FrontBoard) [com.apple.FrontBoard:Common] Scene update
+var iosCompactPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d+)\s+(\w+)\s+(.+?)\[(\d+):([0-9a-f]+)\] (.*)$`)
+
+var iosSenderPattern = regexp.MustCompile(`^(?:\(([^)]*)\) )?(?:\[([^\]:]+)(?::([^\]]*))?\] )?`)
+
+var logcatPriorities = map[string]string{
+	"V": "TRACE",
+	"D": "DEBUG",
+	"I": "INFO",
+	"W": "WARNING",
+	"E": "ERROR",
+	"F": "FATAL",
+	"A": "FATAL",
+}
+
+// log show prints the type in full in the default style and abbreviated
+// in the compact one. Default is Apple's notice level, and faults are
+// system-level failures.
+var iosTypes = map[string]string{
+	"Default":  "NOTICE",
+	"Df":       "NOTICE",
+	"Info":     "INFO",
+	"I":        "INFO",
+	"Debug":    "DEBUG",
+	"Db":       "DEBUG",
+	"Error":    "ERROR",
+	"E":        "ERROR",
+	"Fault":    "CRITICAL",
+	"F":        "CRITICAL",
+	"Activity": "INFO",
+	"A":        "INFO",
+}
+
+// The lines log show prints before and between entries
+var iosHeaderPrefixes = []string{"Timestamp ", "Filtering the log data", "Skipping ", "---", "Log      -"}
+
+// parseLogcatMessage reads adb logcat's threadtime format. The tag is the
+// module, the priority the severity, and the process and thread IDs are
+// kept as pid and thread. Without the year modifier the year is inferred
+// as for syslog. Device times are local and kept as they are.
+func parseLogcatMessage(logRow string) (logMessage LogMessage, err error) {
+	if strings.HasPrefix(logRow, "--------- ") {
+		// --------- beginning of main
+		return logMessage, errDirectiveLine
+	}
+	match := logcatPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	timestamp, err := time.Parse("01-02 15:04:05.000", match[2])
+	if err != nil {
+		return logMessage, errBadTimestamp
+	}
+	if match[1] != "" {
+		timestamp, err = time.Parse("2006-01-02 15:04:05.000", match[1]+"-"+match[2])
+		if err != nil {
+			return logMessage, errBadTimestamp
+		}
+	} else {
+		timestamp = addMostRecentYear(timestamp, time.Now().UTC())
+	}
+	logMessage.timestamp = timestamp.Format(layout)
+	logMessage.pid = match[3]
+	logMessage.thread = match[4]
+	logMessage.severity = logcatPriorities[match[5]]
+	logMessage.module = match[6]
+	logMessage.message = strings.TrimSpace(match[7])
+	return
+}
+
+// parseIOSMessage reads iOS and macOS unified log exports written by
+// log show in its default or compact style. The subsystem is the module,
+// or the process when the entry has none; process, library, subsystem and
+// category are kept as attributes.
+func parseIOSMessage(logRow string) (logMessage LogMessage, err error) {
+	for _, prefix := range iosHeaderPrefixes {
+		if strings.HasPrefix(logRow, prefix) {
+			return logMessage, errDirectiveLine
+		}
+	}
+	var process, entryType, body string
+	if match := iosDefaultPattern.FindStringSubmatch(logRow); match != nil {
+		logMessage.timestamp, err = normalizeTimestamp(match[1], "2006-01-02 15:04:05.999999-0700")
+		logMessage.thread = match[2]
+		entryType, logMessage.pid, process, body = match[3], match[4], match[5], match[6]
+	} else if match := iosCompactPattern.FindStringSubmatch(logRow); match != nil {
+		// The compact style drops the time zone, so times are kept local
+		logMessage.timestamp, err = normalizeTimestamp(match[1], "2006-01-02 15:04:05.999999")
+		entryType, process, logMessage.pid, logMessage.thread, body = match[2], match[3], match[4], match[5], match[6]
+	} else {
+		return logMessage, errMissingDelimiter
+	}
+	if err != nil {
+		return logMessage, errBadTimestamp
+	}
+	severity, ok := iosTypes[entryType]
+	if !ok {
+		return logMessage, errMissingSeverity
+	}
+	logMessage.severity = severity
+	logMessage.attributes = map[string]string{"process": process}
+	logMessage.module = process
+	sender := iosSenderPattern.FindStringSubmatch(body)
+	if sender[1] != "" {
+		logMessage.attributes["library"] = sender[1]
+	}
+	if sender[2] != "" {
+		logMessage.module = sender[2]
+		logMessage.attributes["subsystem"] = sender[2]
+	}
+	if sender[3] != "" {
+		logMessage.attributes["category"] = sender[3]
+	}
+	logMessage.message = strings.TrimSpace(body[len(sender[0]):])
+	return
+}
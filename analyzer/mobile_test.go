@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogcatMessage(t *testing.T) {
+	got, err := parseLogcatMessage("01-02 15:04:05.123  1234  5678 W Choreographer   : Skipped 42 frames!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got.timestamp, "-01-02 15:04:05.123") || got.severity != "WARNING" || got.module != "Choreographer" || got.message != "Skipped 42 frames!" {
+		t.Errorf("parseLogcatMessage() = %+v", got)
+	}
+
+	input := "--------- beginning of main\n01-02 15:04:05.123  1234  1234 V Zygote: Forked child\n--------- beginning of crash\n01-02 15:04:06.000  1234  1234 A libc: Fatal signal 6 (SIGABRT)\n"
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, analysisOptions{parser: parseLogcatMessage})
+	if len(logMessages) != 2 || stats.malformedLines != 0 {
+		t.Fatalf("Expected 2 entries and no malformed lines, got %d and %d", len(logMessages), stats.malformedLines)
+	}
+	if logMessages[0].severity != "TRACE" || logMessages[1].severity != "FATAL" {
+		t.Errorf("Severities = %q, %q", logMessages[0].severity, logMessages[1].severity)
+	}
+}
+
+func TestAddMostRecentYear(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	timestamp := time.Date(0, time.December, 31, 23, 0, 0, 0, time.UTC)
+	if got := addMostRecentYear(timestamp, now); got.Year() != 2023 {
+		t.Errorf("addMostRecentYear() = %v, want 2023", got)
+	}
+}
+
+func TestParseIOSMessage(t *testing.T) {
+	got, err := parseIOSMessage("2024-01-02 15:04:05.123 Df Google Chrome Helper[123:1a2b] Renderer started")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.timestamp != "2024-01-02 15:04:05.123" || got.severity != "NOTICE" || got.module != "Google Chrome Helper" ||
+		got.pid != "123" || got.thread != "1a2b" || got.message != "Renderer started" {
+		t.Errorf("parseIOSMessage() = %+v", got)
+	}
+
+	for _, header := range []string{
+		"Filtering the log data using \"subsystem == \\\"com.apple.FrontBoard\\\"\"",
+		"Timestamp                       Thread     Type        Activity             PID    TTL  ",
+	} {
+		if _, err := parseIOSMessage(header); err != errDirectiveLine {
+			t.Errorf("parseIOSMessage(%q) error = %v, want a directive line", header, err)
+		}
+	}
+}
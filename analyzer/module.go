@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+)
+
+const maxModuleMessages = 3
+
+// noModule labels entries whose format has no module field.
+const noModule = "(no module)"
+
+type moduleStats struct {
+	entries            int64
+	severityFrequency  LogSeverityFrequency
+	messageFrequencies map[string]int64
+}
+
+// getModuleStats breaks entries, severities and message frequencies down by
+// module, for -group-by module.
+func getModuleStats(logMessages []LogMessage) (moduleStatsByName map[string]moduleStats) {
+	moduleStatsByName = make(map[string]moduleStats)
+	for _, logMessage := range logMessages {
+		module := logMessage.module
+		if module == "" {
+			module = noModule
+		}
+		stats := moduleStatsByName[module]
+		stats.entries += 1
+		countSeverity(&stats.severityFrequency, logMessage.severity)
+		if stats.messageFrequencies == nil {
+			stats.messageFrequencies = make(map[string]int64)
+		}
+		stats.messageFrequencies[logMessage.messageKey()] += 1
+		moduleStatsByName[module] = stats
+	}
+	return
+}
+
+func mergeModuleStats(into map[string]moduleStats, from map[string]moduleStats) {
+	for module, stats := range from {
+		merged := into[module]
+		merged.entries += stats.entries
+		mergeLogSeverityFrequency(&merged.severityFrequency, stats.severityFrequency)
+		for message, frequency := range stats.messageFrequencies {
+			if merged.messageFrequencies == nil {
+				merged.messageFrequencies = make(map[string]int64)
+			}
+			merged.messageFrequencies[message] += frequency
+		}
+		into[module] = merged
+	}
+}
+
+// getSortedModules ranks modules by entry count, busiest first.
+func getSortedModules(moduleStatsByName map[string]moduleStats) (modules []string) {
+	for module := range moduleStatsByName {
+		modules = append(modules, module)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if moduleStatsByName[modules[i]].entries != moduleStatsByName[modules[j]].entries {
+			return moduleStatsByName[modules[i]].entries > moduleStatsByName[modules[j]].entries
+		}
+		return modules[i] < modules[j]
+	})
+	return
+}
+
+func getTopModuleMessages(stats moduleStats) (messages []string) {
+	messages = rankLogMessages(stats.messageFrequencies)
+	if len(messages) > maxModuleMessages {
+		messages = messages[:maxModuleMessages]
+	}
+	return
+}
+
+func formatModuleStats(moduleStatsByName map[string]moduleStats, options reportOptions) (lines []string) {
+	for _, module := range getSortedModules(moduleStatsByName) {
+		stats := moduleStatsByName[module]
+		lines = append(lines, module+": "+humanizeCount(stats.entries, options)+" entries ("+
+			formatSeverityCounts(stats.severityFrequency, getSortedSeverities(stats.severityFrequency), options)+")")
+		for _, message := range getTopModuleMessages(stats) {
+			lines = append(lines, "   "+strconv.FormatInt(stats.messageFrequencies[message], 10)+"x "+message)
+		}
+	}
+	return
+}
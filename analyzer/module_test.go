@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetModuleStats(t *testing.T) {
+	testLogs := []LogMessage{
+		{module: "app.db", severity: "ERROR", message: "Timeout"},
+		{module: "app.db", severity: "ERROR", message: "Timeout"},
+		{module: "app.db", severity: "INFO", message: "Connected"},
+		{module: "app.web", severity: "INFO", message: "GET /"},
+		{severity: "WARNING", message: "No module"},
+	}
+
+	moduleStatsByName := getModuleStats(testLogs[:3])
+	mergeModuleStats(moduleStatsByName, getModuleStats(testLogs[3:]))
+
+	if got, want := getSortedModules(moduleStatsByName), []string{"app.db", noModule, "app.web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getSortedModules() = %v, want %v", got, want)
+	}
+	db := moduleStatsByName["app.db"]
+	if db.entries != 3 || !reflect.DeepEqual(db.severityFrequency, LogSeverityFrequency{"INFO": 1, "ERROR": 2}) {
+		t.Errorf("Expected 3 app.db entries with 2 errors, got %+v", db)
+	}
+	if got, want := getTopModuleMessages(db), []string{"Timeout", "Connected"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getTopModuleMessages() = %v, want %v", got, want)
+	}
+}
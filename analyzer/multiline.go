@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// stackTraceAttribute holds the continuation lines of a multi-line entry.
+const stackTraceAttribute = "stack_trace"
+
+// defaultEntryStartPattern matches the lines that start an entry in the
+// presets: a date like 2024-01-02, 02/Jan/2024: or 1/2/2024, a syslog
+// Jan  2 15:04, a syslog priority, a JSON object or an IPv4 client.
+// Java's "\tat ..." and "Caused by: ...", and Python's "Traceback ..." and
+// "ValueError: ..." lines don't.
+var defaultEntryStartPattern = regexp.MustCompile(`^\[?(?:\d{4}-\d{2}-\d{2}|\d{2}/[A-Z][a-z]{2}/\d{4}:|\d{1,2}/\d{1,2}/\d{2,4}[ ,]|[A-Z][a-z]{2} [ \d]\d \d{2}:|<\d{1,3}>|\{|\d{1,3}(?:\.\d{1,3}){3}[ ,])`)
+
+// isContinuationLine reports whether a line that failed to parse belongs to
+// the entry before it, because it doesn't look like the start of an entry.
+func isContinuationLine(logRow string, entryStartPattern *regexp.Regexp) bool {
+	return strings.TrimSpace(logRow) != "" && !entryStartPattern.MatchString(logRow)
+}
+
+// appendContinuationLine adds a line to the stack_trace attribute of
+// logMessage. The attributes are copied at the first line, since parsers
+// may share them between entries.
+func appendContinuationLine(logMessage *LogMessage, logRow string) {
+	if trace, ok := logMessage.attributes[stackTraceAttribute]; ok {
+		logMessage.attributes[stackTraceAttribute] = trace + "\n" + logRow
+		return
+	}
+	attributes := make(map[string]string, len(logMessage.attributes)+1)
+	for name, value := range logMessage.attributes {
+		attributes[name] = value
+	}
+	attributes[stackTraceAttribute] = logRow
+	logMessage.attributes = attributes
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const multilineLog = `2024-01-02 15:04:05.123 [main] ERROR com.example.PaymentService - Charge failed
+java.lang.IllegalStateException: card declined
+	at com.example.PaymentService.charge(PaymentService.java:42)
+	at com.example.Main.main(Main.java:7)
+Caused by: java.io.IOException: timeout
+	... 2 more
+2024-01-02 15:04:06.000 [main] DEBUG com.example.PaymentService - Retrying
+Traceback (most recent call last):
+  File "retry.py", line 3, in <module>
+ValueError: bad retry
+2024-01-02 15:04:07.000 [main] INFO  com.example.PaymentService - Done
+2024-01-02 garbage
+`
+
+func TestParseLogReaderMultiline(t *testing.T) {
+	parser, err := getLineParser("log4j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := analysisOptions{parser: parser, entryStartPattern: defaultEntryStartPattern, minSeverity: "INFO"}
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(multilineLog), stdinPath, options)
+	if len(logMessages) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", logMessages)
+	}
+	wantTrace := "java.lang.IllegalStateException: card declined\n\tat com.example.PaymentService.charge(PaymentService.java:42)\n" +
+		"\tat com.example.Main.main(Main.java:7)\nCaused by: java.io.IOException: timeout\n\t... 2 more"
+	if got := logMessages[0].Attribute(stackTraceAttribute); got != wantTrace || logMessages[0].message != "Charge failed" {
+		t.Errorf("Unexpected first entry %q with trace %q", logMessages[0].message, got)
+	}
+	if got := logMessages[1].Attribute(stackTraceAttribute); got != "" {
+		t.Errorf("Expected no trace on the last entry, got %q", got)
+	}
+	// The DEBUG entry's traceback is filtered out with it, and a line that
+	// starts like an entry stays malformed
+	if stats.lines != 12 || stats.filteredLines != 4 || stats.malformedLines != 1 {
+		t.Errorf("Expected 12 lines, 4 filtered and 1 malformed, got %+v", stats)
+	}
+
+	logMessages, _, stats = parseLogReader(context.Background(), strings.NewReader(multilineLog), stdinPath, analysisOptions{parser: parser})
+	if len(logMessages) != 3 || stats.malformedLines != 9 {
+		t.Errorf("Expected continuation lines to be malformed without -multiline, got %d entries and %d malformed", len(logMessages), stats.malformedLines)
+	}
+}
+
+func TestConvertLogMultiline(t *testing.T) {
+	parser, err := getLineParser("log4j")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	converted, skipped, err := convertLog(strings.NewReader(multilineLog), &output, parser, formatNativeLogMessage, defaultEntryStartPattern)
+	if err != nil || converted != 3 || skipped != 1 {
+		t.Fatalf("convertLog() converted %d, skipped %d, %v", converted, skipped, err)
+	}
+	if !strings.Contains(output.String(), "Charge failed | thread=main\njava.lang.IllegalStateException: card declined\n\tat ") {
+		t.Errorf("Expected the trace under its entry, got\n%s", output.String())
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"io"
+	"time"
+)
+
+// ndjsonRecord is one line of -format ndjson. A "file" record is written as
+// each file finishes, a "partial" record merges the files finished so far
+// every -progress-interval, and the "summary" record is the merged analysis
+// -format json would write.
+type ndjsonRecord struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Completed int       `json:"completed"`
+	Total     int       `json:"total"`
+	Analysis  Analysis  `json:"analysis"`
+}
+
+type ndjsonWriter struct {
+	writer      io.Writer
+	total       int
+	interval    time.Duration
+	completed   []LogAnalysis
+	lastPartial time.Time
+	now         func() time.Time
+	err         error
+}
+
+// newNDJSONWriter writes the records of a run over total files to writer.
+// Partial merges are left out when interval is 0.
+func newNDJSONWriter(writer io.Writer, total int, interval time.Duration) *ndjsonWriter {
+	return &ndjsonWriter{writer: writer, total: total, interval: interval, now: time.Now, lastPartial: time.Now()}
+}
+
+func (ndjson *ndjsonWriter) writeRecord(recordType string, analysis Analysis) {
+	if ndjson.err != nil {
+		return
+	}
+	record := ndjsonRecord{Type: recordType, Time: ndjson.now().UTC(), Completed: len(ndjson.completed), Total: ndjson.total, Analysis: analysis}
+	ndjson.err = writeCanonicalJSON(ndjson.writer, record, "")
+}
+
+// addFile writes the record of a finished file, and a partial merge when
+// the last one is more than the interval ago. collectLogAnalyses calls it
+// from a single goroutine.
+func (ndjson *ndjsonWriter) addFile(logAnalysis LogAnalysis) {
+	ndjson.completed = append(ndjson.completed, logAnalysis)
+	fileLogAnalysis := analyzelogAnalyses([]LogAnalysis{logAnalysis})
+	fileLogAnalysis.logPath = logAnalysis.logPath
+	ndjson.writeRecord("file", newJSONLogAnalysis(fileLogAnalysis, nil))
+	if ndjson.interval > 0 && len(ndjson.completed) < ndjson.total && ndjson.now().Sub(ndjson.lastPartial) >= ndjson.interval {
+		ndjson.writeRecord("partial", newJSONLogAnalysis(analyzelogAnalyses(ndjson.completed), nil))
+		ndjson.lastPartial = ndjson.now()
+	}
+}
+
+// writeSummary writes the final record and returns the first error writing
+// any of them.
+func (ndjson *ndjsonWriter) writeSummary(logAnalysis LogAnalysis, fileLogAnalyses []LogAnalysis, budgetReport []budgetReportRow) error {
+	report := newJSONLogAnalysis(logAnalysis, budgetReport)
+	for _, fileLogAnalysis := range fileLogAnalyses {
+		report.Files = append(report.Files, newJSONLogAnalysis(fileLogAnalysis, nil))
+	}
+	ndjson.writeRecord("summary", report)
+	return ndjson.err
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	var logAnalyses []LogAnalysis
+	for _, logPath := range []string{"a.log", "b.log", "c.log"} {
+		testLogs := []LogMessage{
+			{timestamp: "2024-01-01 08:00:00", severity: "INFO", message: "Started"},
+			{timestamp: "2024-01-01 08:30:00", severity: "ERROR", message: "Timeout"},
+		}
+		logAnalyses = append(logAnalyses, newLogAnalysis(logPath, testLogs, nil, parseStats{lines: 2}, analysisOptions{}))
+	}
+	var output bytes.Buffer
+	ndjson := newNDJSONWriter(&output, len(logAnalyses), time.Minute)
+	now := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	ndjson.now = func() time.Time { return now }
+	ndjson.lastPartial = now
+	for _, logAnalysis := range logAnalyses {
+		// Only the second file finishes a minute after the last partial merge
+		now = now.Add(40 * time.Second)
+		ndjson.addFile(logAnalysis)
+	}
+	if err := ndjson.writeSummary(analyzelogAnalyses(logAnalyses), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	want := []struct {
+		recordType string
+		completed  int
+		logPath    string
+		entries    int
+	}{
+		{"file", 1, "a.log", 2},
+		{"file", 2, "b.log", 2},
+		{"partial", 2, "", 4},
+		{"file", 3, "c.log", 2},
+		{"summary", 3, "", 6},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d records, got\n%s", len(want), output.String())
+	}
+	for index, line := range lines {
+		var record ndjsonRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Type != want[index].recordType || record.Completed != want[index].completed || record.Total != 3 ||
+			record.Analysis.LogPath != want[index].logPath || record.Analysis.Entries != want[index].entries {
+			t.Errorf("Record %d = %s %d/%d %s with %d entries, want %+v", index, record.Type, record.Completed, record.Total,
+				record.Analysis.LogPath, record.Analysis.Entries, want[index])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var templatePlaceholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+var patternTimestampLayouts = []string{layout, time.RFC3339Nano, "2006-01-02T15:04:05.999", "2006-01-02 15:04:05,999"}
+
+var patternFieldAliases = map[string]string{
+	"time":   "timestamp",
+	"ts":     "timestamp",
+	"level":  "severity",
+	"logger": "module",
+	"func":   "function",
+	"line":   "lineNumber",
+	"lineno": "lineNumber",
+	"msg":    "message",
+	"tid":    "thread",
+	"ip":     "clientIP",
+	"client": "clientIP",
+}
+
+// templateToRegexp turns "{timestamp} | {severity} | {message}" into an
+// anchored regex with one named group per placeholder. The last placeholder
+// swallows the rest of the line; earlier ones match as little as possible.
+func templateToRegexp(template string) (*regexp.Regexp, error) {
+	placeholders := templatePlaceholderPattern.FindAllStringSubmatchIndex(template, -1)
+	if len(placeholders) == 0 {
+		return nil, errors.New("pattern template has no {field} placeholders")
+	}
+	var builder strings.Builder
+	builder.WriteString("^")
+	previousEnd := 0
+	for index, placeholder := range placeholders {
+		builder.WriteString(regexp.QuoteMeta(template[previousEnd:placeholder[0]]))
+		name := template[placeholder[2]:placeholder[3]]
+		if index == len(placeholders)-1 {
+			builder.WriteString("(?P<" + name + ">.*)")
+		} else {
+			builder.WriteString("(?P<" + name + ">.*?)")
+		}
+		previousEnd = placeholder[1]
+	}
+	builder.WriteString(regexp.QuoteMeta(template[previousEnd:]))
+	builder.WriteString("$")
+	return regexp.Compile(builder.String())
+}
+
+func newPatternParser(pattern string, format timeFormat) (Parser, error) {
+	var compiled *regexp.Regexp
+	var err error
+	if strings.Contains(pattern, "(?P<") || strings.Contains(pattern, "(?<") {
+		compiled, err = regexp.Compile(pattern)
+	} else {
+		compiled, err = templateToRegexp(pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]int)
+	for index, name := range compiled.SubexpNames() {
+		if alias, ok := patternFieldAliases[name]; ok {
+			name = alias
+		}
+		if name != "" {
+			fields[name] = index
+		}
+	}
+	if _, ok := fields["message"]; !ok {
+		return nil, errors.New("pattern needs a message field")
+	}
+
+	return func(logRow string) (logMessage LogMessage, err error) {
+		match := compiled.FindStringSubmatch(logRow)
+		if match == nil {
+			return logMessage, errMissingDelimiter
+		}
+		field := func(name string) string {
+			if index, ok := fields[name]; ok {
+				return strings.TrimSpace(match[index])
+			}
+			return ""
+		}
+		logMessage.module = field("module")
+		logMessage.function = field("function")
+		logMessage.message = field("message")
+		logMessage.thread = field("thread")
+		logMessage.pid = field("pid")
+		logMessage.clientIP = field("clientIP")
+		if lineNumber := field("lineNumber"); lineNumber != "" {
+			logMessage.lineNumber, err = strconv.ParseInt(lineNumber, 10, 64)
+			if err != nil {
+				return logMessage, errBadLineNumber
+			}
+		}
+		if _, ok := fields["timestamp"]; ok {
+			logMessage.timestamp, err = format.normalize(field("timestamp"), patternTimestampLayouts...)
+			if err != nil {
+				return
+			}
+		}
+		logMessage.severity = normalizeSeverity(field("severity"))
+		if logMessage.severity == "" {
+			return logMessage, errMissingSeverity
+		}
+		return
+	}, nil
+}
+
+// resolveLineParser prefers an explicit -pattern over the -preset name.
+// Only the native format and patterns take a -time-format or read
+// timestamps in the -timezone zone; the other presets read the timestamps
+// their formats define, and -timezone only changes how those are shown.
+func resolveLineParser(preset string, pattern string, format timeFormat) (Parser, error) {
+	if pattern != "" {
+		return newPatternParser(pattern, format)
+	}
+	if preset == "native" && format.isSet() {
+		return newNativeParser(format), nil
+	}
+	if format.layouts != nil {
+		return nil, errors.New("-time-format applies to the native format and -pattern, not -preset " + preset)
+	}
+	return getLineParser(preset)
+}
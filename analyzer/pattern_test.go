@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewPatternParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		input   string
+		want    LogMessage
+	}{
+		{
+			name:    "template",
+			pattern: "{timestamp} [{level}] {module}#{function}:{line} {message}",
+			input:   "2024-01-02T15:04:05.5Z [warn] payments#charge:42 Card declined: insufficient funds",
+			want:    LogMessage{timestamp: "2024-01-02 15:04:05.5", severity: "WARNING", module: "payments", function: "charge", lineNumber: 42, message: "Card declined: insufficient funds"},
+		},
+		{
+			name:    "named group regex",
+			pattern: `^(?P<timestamp>\S+ \S+) (?P<severity>[A-Z]+) (?P<message>.*)$`,
+			input:   "2024-01-02 15:04:05.000 ERROR Disk full",
+			want:    LogMessage{timestamp: "2024-01-02 15:04:05", severity: "ERROR", message: "Disk full"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := newPatternParser(tt.pattern, timeFormat{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parser(tt.input)
+			if err != nil {
+				t.Fatalf("parser error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parser() = %+v, want %+v", got, tt.want)
+			}
+			if _, err := parser("unrelated text"); err == nil {
+				t.Errorf("parser() accepted a non-matching line")
+			}
+		})
+	}
+
+	if _, err := newPatternParser("{timestamp} {severity}", timeFormat{}); err == nil {
+		t.Errorf("newPatternParser() accepted a pattern without a message field")
+	}
+}
+
+func TestPatternWithoutTimestamp(t *testing.T) {
+	parser, err := newPatternParser("{severity} {module} {message}", timeFormat{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untimed := analyzeLogReader(context.Background(), strings.NewReader("ERROR db timeout\nINFO app ok\n"), "untimed.log", analysisOptions{parser: parser})
+	if untimed.numEntries != 2 || !untimed.startTime.IsZero() {
+		t.Fatalf("analyzeLogReader() = %d entries from %v, want 2 without a time range", untimed.numEntries, untimed.startTime)
+	}
+	timed := analyzeLogReader(context.Background(), strings.NewReader("2024-01-01 08:00:00.000 | ERROR | db:query:12 - Timeout\n"), "timed.log", analysisOptions{parser: parseLogMessage})
+	// The time range is the timed file's, in either order
+	for _, logAnalyses := range [][]LogAnalysis{{untimed, timed}, {timed, untimed}} {
+		merged := analyzelogAnalyses(logAnalyses)
+		if merged.numEntries != 3 || !merged.startTime.Equal(timed.startTime) || !merged.endTime.Equal(timed.endTime) {
+			t.Errorf("analyzelogAnalyses() = %d entries from %v to %v, want 3 from %v", merged.numEntries, merged.startTime, merged.endTime, timed.startTime)
+		}
+	}
+}
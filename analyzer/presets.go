@@ -0,0 +1,325 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Parser func(logRow string) (LogMessage, error)
+
+var presetParsers = map[string]Parser{
+	"native":    parseLogMessage,
+	"log4j":     parseLog4jMessage,
+	"python":    parsePythonMessage,
+	"slog-text": parseSlogTextMessage,
+	"slog-json": parseSlogJSONMessage,
+	"zap":       parseZapMessage,
+	"access":    parseAccessMessage,
+	"auth":      parseAuthMessage,
+	"cef":       parseCEFMessage,
+	"leef":      parseLEEFMessage,
+	"iis":       parseIISMessage,
+	"gelf":      parseGELFMessage,
+	"postgres":  parsePostgresMessage,
+	"jvm-gc":    parseJVMGCMessage,
+	"logcat":    parseLogcatMessage,
+	"ios":       parseIOSMessage,
+	"journal":   parseJournalMessage,
+	"json":      newJSONLinesParser(defaultJSONFieldKeys),
+	"syslog":    parseSyslogMessage,
+}
+
+var severityAliases = map[string]string{
+	"WARN":  "WARNING",
+	"ERR":   "ERROR",
+	"CRIT":  "CRITICAL",
+	"PANIC": "FATAL",
+}
+
+// log4j2's documented PatternLayout: %d{yyyy-MM-dd HH:mm:ss.SSS} [%t] %-5level %logger{36} - %msg%n
+var log4jPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}[.,]\d{3})\s+\[([^\]]*)\]\s+(\w+)\s+(\S+)\s+-\s+(.*)$`)
+
+// The logging cookbook format: %(asctime)s - %(name)s - %(levelname)s - %(message)s
+var pythonPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}) - (\S+) - (\w+) - (.*)$`)
+
+// The common and combined log formats of Apache and nginx, whose referer and
+// user agent are optional:
+// 203.0.113.9 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "referer" "agent"
+var accessPattern = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "(\S+) (\S+)[^"]*" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+// syslog as written to auth.log or /var/log/secure, with the classic or the
+// RFC 3339 timestamp: Oct 11 22:14:15 host sshd[4242]: Failed password for root from 203.0.113.9 port 22 ssh2
+var authPattern = regexp.MustCompile(`^(\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) \S+ ([^\s\[:]+)(?:\[(\d+)\])?: (.*)$`)
+
+var authClientIPPattern = regexp.MustCompile(`\b(?:from|rhost=) ?([0-9A-Fa-f.:]+)`)
+
+var authFailureMarkers = []string{"Failed password", "Invalid user", "authentication failure", "error:", "Connection closed by invalid user", "maximum authentication attempts"}
+
+var logfmtPairPattern = regexp.MustCompile(`([^\s="]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+func getLineParser(preset string) (Parser, error) {
+	if newParser, ok := parserFactories[preset]; ok {
+		return newParser(), nil
+	}
+	parser, ok := presetParsers[preset]
+	if !ok {
+		names := make([]string, 0, len(presetParsers)+len(parserFactories))
+		for name := range presetParsers {
+			names = append(names, name)
+		}
+		for name := range parserFactories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, errors.New("unknown preset " + preset + ", expected one of " + strings.Join(names, ", "))
+	}
+	return parser, nil
+}
+
+func normalizeSeverity(level string) string {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if alias, ok := severityAliases[level]; ok {
+		return alias
+	}
+	return level
+}
+
+func normalizeTimestamp(value string, layouts ...string) (string, error) {
+	for _, timeLayout := range layouts {
+		timestamp, err := time.Parse(timeLayout, value)
+		if err == nil {
+			return timestamp.UTC().Format(layout), nil
+		}
+	}
+	return "", errBadTimestamp
+}
+
+// splitCaller turns "pkg/file.go:42" into a module of "pkg/file.go" and a line number.
+func splitCaller(caller string) (module string, lineNumber int64) {
+	separator := strings.LastIndex(caller, ":")
+	if separator < 0 {
+		return caller, 0
+	}
+	lineNumber, err := strconv.ParseInt(caller[separator+1:], 10, 64)
+	if err != nil {
+		return caller, 0
+	}
+	return caller[:separator], lineNumber
+}
+
+func parseLog4jMessage(logRow string) (logMessage LogMessage, err error) {
+	match := log4jPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(strings.Replace(match[1], ",", ".", 1), "2006-01-02 15:04:05.000", "2006-01-02T15:04:05.000")
+	if err != nil {
+		return
+	}
+	logMessage.function = match[2]
+	logMessage.thread = match[2]
+	logMessage.severity = normalizeSeverity(match[3])
+	logMessage.module = match[4]
+	logMessage.message = strings.TrimSpace(match[5])
+	return
+}
+
+func parsePythonMessage(logRow string) (logMessage LogMessage, err error) {
+	match := pythonPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(match[1], "2006-01-02 15:04:05,000")
+	if err != nil {
+		return
+	}
+	logMessage.module = match[2]
+	logMessage.severity = normalizeSeverity(match[3])
+	logMessage.message = strings.TrimSpace(match[4])
+	return
+}
+
+func parseLogfmt(logRow string) (fields map[string]string) {
+	fields = make(map[string]string)
+	for _, match := range logfmtPairPattern.FindAllStringSubmatch(logRow, -1) {
+		value := match[2]
+		if strings.HasPrefix(value, `"`) {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		}
+		fields[match[1]] = value
+	}
+	return
+}
+
+func parseSlogTextMessage(logRow string) (logMessage LogMessage, err error) {
+	fields := parseLogfmt(logRow)
+	if fields["level"] == "" {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(fields["time"], time.RFC3339Nano)
+	if err != nil {
+		return
+	}
+	logMessage.severity = normalizeSeverity(fields["level"])
+	logMessage.module, logMessage.lineNumber = splitCaller(fields["source"])
+	logMessage.message = fields["msg"]
+	return
+}
+
+func parseSlogJSONMessage(logRow string) (logMessage LogMessage, err error) {
+	var entry struct {
+		Time   string `json:"time"`
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		Source struct {
+			Function string `json:"function"`
+			File     string `json:"file"`
+			Line     int64  `json:"line"`
+		} `json:"source"`
+	}
+	if err = json.Unmarshal([]byte(logRow), &entry); err != nil || entry.Level == "" {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(entry.Time, time.RFC3339Nano)
+	if err != nil {
+		return
+	}
+	logMessage.severity = normalizeSeverity(entry.Level)
+	logMessage.module = entry.Source.File
+	logMessage.function = entry.Source.Function
+	logMessage.lineNumber = entry.Source.Line
+	logMessage.message = entry.Msg
+	return
+}
+
+func parseZapMessage(logRow string) (logMessage LogMessage, err error) {
+	var entry struct {
+		Level  string          `json:"level"`
+		TS     json.RawMessage `json:"ts"`
+		Logger string          `json:"logger"`
+		Caller string          `json:"caller"`
+		Msg    string          `json:"msg"`
+	}
+	if err = json.Unmarshal([]byte(logRow), &entry); err != nil || entry.Level == "" {
+		return logMessage, errMissingDelimiter
+	}
+	// zap's production config writes epoch seconds, ISO8601 encoders write strings
+	if seconds, parseErr := strconv.ParseFloat(string(entry.TS), 64); parseErr == nil {
+		whole, fraction := math.Modf(seconds)
+		logMessage.timestamp = time.Unix(int64(whole), int64(fraction*1e9)).UTC().Format(layout)
+	} else {
+		var value string
+		if json.Unmarshal(entry.TS, &value) != nil {
+			return logMessage, errBadTimestamp
+		}
+		logMessage.timestamp, err = normalizeTimestamp(value, time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700")
+		if err != nil {
+			return
+		}
+	}
+	logMessage.severity = normalizeSeverity(entry.Level)
+	logMessage.module, logMessage.lineNumber = splitCaller(entry.Caller)
+	if entry.Logger != "" {
+		logMessage.module = entry.Logger
+	}
+	logMessage.message = entry.Msg
+	return
+}
+
+// parseAccessMessage maps 5xx responses to ERROR and 4xx to WARNING. The
+// message is the method, path without query string and status, so requests
+// for the same endpoint rank together. The status, path, query, response
+// size, user, referer and user agent are kept as attributes under their W3C
+// names, like sc-status and cs-uri-stem, as the w3c and iis presets do.
+func parseAccessMessage(logRow string) (logMessage LogMessage, err error) {
+	match := accessPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(match[3], "02/Jan/2006:15:04:05 -0700")
+	if err != nil {
+		return
+	}
+	logMessage.severity = getHTTPStatusSeverity(match[6])
+	path, query, _ := strings.Cut(match[5], "?")
+	logMessage.clientIP = match[1]
+	logMessage.function = match[4]
+	logMessage.message = match[4] + " " + path + " " + match[6]
+	logMessage.attributes = make(map[string]string)
+	fields := []string{"cs-username", match[2], "cs-uri-stem", path, "cs-uri-query", query, "sc-status", match[6], "sc-bytes", match[7], "cs(Referer)", match[8], "cs(User-Agent)", match[9]}
+	for index := 0; index < len(fields); index += 2 {
+		if value := fields[index+1]; value != "" && value != "-" {
+			logMessage.attributes[fields[index]] = value
+		}
+	}
+	return
+}
+
+func getHTTPStatusSeverity(status string) string {
+	switch {
+	case strings.HasPrefix(status, "5"):
+		return "ERROR"
+	case strings.HasPrefix(status, "4"):
+		return "WARNING"
+	default:
+		return "INFO"
+	}
+}
+
+// normalizeSyslogTimestamp reads the year-less syslog timestamp as the most
+// recent such time not more than a day after now.
+func normalizeSyslogTimestamp(value string, now time.Time) (string, error) {
+	timestamp, err := time.Parse("Jan _2 15:04:05", value)
+	if err != nil {
+		return normalizeTimestamp(value, time.RFC3339Nano)
+	}
+	return addMostRecentYear(timestamp, now).Format(layout), nil
+}
+
+// addMostRecentYear dates a timestamp parsed without a year in the most
+// recent year that puts it not more than a day after now.
+func addMostRecentYear(timestamp time.Time, now time.Time) time.Time {
+	timestamp = timestamp.AddDate(now.Year(), 0, 0)
+	if timestamp.After(now.Add(24 * time.Hour)) {
+		timestamp = timestamp.AddDate(-1, 0, 0)
+	}
+	return timestamp
+}
+
+// parseAuthMessage reads sshd, sudo and PAM lines. Failed logins and errors
+// are ERROR, everything else INFO; the module is the program name.
+func parseAuthMessage(logRow string) (logMessage LogMessage, err error) {
+	match := authPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeSyslogTimestamp(match[1], time.Now().UTC())
+	if err != nil {
+		return
+	}
+	logMessage.module = match[2]
+	logMessage.pid = match[3]
+	logMessage.message = strings.TrimSpace(match[4])
+	logMessage.severity = "INFO"
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(logMessage.message, marker) {
+			logMessage.severity = "ERROR"
+			break
+		}
+	}
+	if ipMatch := authClientIPPattern.FindStringSubmatch(logMessage.message); ipMatch != nil {
+		if _, parseErr := netip.ParseAddr(ipMatch[1]); parseErr == nil {
+			logMessage.clientIP = ipMatch[1]
+		}
+	}
+	return
+}
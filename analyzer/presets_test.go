@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPresetParsers(t *testing.T) {
+	tests := []struct {
+		preset string
+		input  string
+		want   LogMessage
+	}{
+		{
+			preset: "log4j",
+			input:  "2024-01-02 15:04:05.123 [main] WARN  com.example.PaymentService - Retrying charge",
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05.123", severity: "WARNING", module: "com.example.PaymentService", function: "main", thread: "main", message: "Retrying charge"},
+		},
+		{
+			preset: "python",
+			input:  "2024-01-02 15:04:05,120 - app.db - ERROR - Connection lost - retrying",
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05.12", severity: "ERROR", module: "app.db", message: "Connection lost - retrying"},
+		},
+		{
+			preset: "slog-text",
+			input:  `time=2024-01-02T16:04:05.000+01:00 level=INFO source=app/main.go:42 msg="User logged in" user=42`,
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05", severity: "INFO", module: "app/main.go", lineNumber: 42, message: "User logged in"},
+		},
+		{
+			preset: "slog-json",
+			input:  `{"time":"2024-01-02T15:04:05.5Z","level":"ERROR","source":{"function":"main.run","file":"app/main.go","line":7},"msg":"Boom"}`,
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05.5", severity: "ERROR", module: "app/main.go", function: "main.run", lineNumber: 7, message: "Boom"},
+		},
+		{
+			preset: "zap",
+			input:  `{"level":"warn","ts":1704207845.25,"caller":"app/main.go:9","msg":"Slow query"}`,
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05.25", severity: "WARNING", module: "app/main.go", lineNumber: 9, message: "Slow query"},
+		},
+		{
+			preset: "access",
+			input:  `203.0.113.9 - - [02/Jan/2024:16:04:05 +0100] "POST /login?next=%2F HTTP/1.1" 401 512 "-" "curl/8.0"`,
+			want: LogMessage{timestamp: "2024-01-02 15:04:05", severity: "WARNING", function: "POST", message: "POST /login 401", clientIP: "203.0.113.9",
+				attributes: map[string]string{"cs-uri-stem": "/login", "cs-uri-query": "next=%2F", "sc-status": "401", "sc-bytes": "512", "cs(User-Agent)": "curl/8.0"}},
+		},
+		{
+			preset: "auth",
+			input:  "2024-01-02T15:04:05.000000+00:00 bastion sshd[4242]: Failed password for root from 203.0.113.9 port 22 ssh2",
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05", severity: "ERROR", module: "sshd", pid: "4242", message: "Failed password for root from 203.0.113.9 port 22 ssh2", clientIP: "203.0.113.9"},
+		},
+		{
+			preset: "cef",
+			input:  `<134>Jan  2 15:04:05 fw1 CEF:0|Palo Alto|PAN-OS|10.1|threat\|vuln|Brute force attempt|8|rt=1704207845250 src=203.0.113.9 dst=10.0.0.5 msg=Too many logins user\=root`,
+			want: LogMessage{timestamp: "2024-01-02 15:04:05.25", severity: "ERROR", module: "PAN-OS", function: "threat|vuln", message: "Brute force attempt", clientIP: "203.0.113.9",
+				attributes: map[string]string{"rt": "1704207845250", "src": "203.0.113.9", "dst": "10.0.0.5", "msg": "Too many logins user=root"}},
+		},
+		{
+			preset: "leef",
+			input:  "LEEF:2.0|IBM|QRadar|7.5|AuthFailed|^|devTime=Jan 02 2024 15:04:05^src=198.51.100.7^sev=5^usrName=bob",
+			want: LogMessage{timestamp: "2024-01-02 15:04:05", severity: "WARNING", module: "QRadar", function: "AuthFailed", message: "AuthFailed", clientIP: "198.51.100.7",
+				attributes: map[string]string{"devTime": "Jan 02 2024 15:04:05", "src": "198.51.100.7", "sev": "5", "usrName": "bob"}},
+		},
+		{
+			preset: "logcat",
+			input:  "2024-01-02 15:04:05.123  1234  5678 E AndroidRuntime: FATAL EXCEPTION: main",
+			want:   LogMessage{timestamp: "2024-01-02 15:04:05.123", severity: "ERROR", module: "AndroidRuntime", pid: "1234", thread: "5678", message: "FATAL EXCEPTION: main"},
+		},
+		{
+			preset: "ios",
+			input:  "2024-01-02 07:04:05.123456-0800 0x1a2b     Fault       0x0                  123    0    SpringBoard: (FrontBoard) [com.apple.FrontBoard:Common] Scene update failed",
+			want: LogMessage{timestamp: "2024-01-02 15:04:05.123", severity: "CRITICAL", module: "com.apple.FrontBoard", pid: "123", thread: "1a2b", message: "Scene update failed",
+				attributes: map[string]string{"process": "SpringBoard", "library": "FrontBoard", "subsystem": "com.apple.FrontBoard", "category": "Common"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			parser, err := getLineParser(tt.preset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parser(tt.input)
+			if err != nil {
+				t.Fatalf("parser error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parser() = %+v, want %+v", got, tt.want)
+			}
+			if _, err := parser("not a log line"); err == nil {
+				t.Errorf("parser() accepted a non-matching line")
+			}
+		})
+	}
+
+	if _, err := getLineParser("cobol"); err == nil {
+		t.Errorf("getLineParser() accepted an unknown preset")
+	}
+}
+
+func TestNormalizeSyslogTimestamp(t *testing.T) {
+	now := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	for value, want := range map[string]string{
+		"Jan  2 15:04:05": "2024-01-02 15:04:05",
+		"Dec 31 23:59:59": "2023-12-31 23:59:59",
+	} {
+		got, err := normalizeSyslogTimestamp(value, now)
+		if err != nil || got != want {
+			t.Errorf("normalizeSyslogTimestamp(%q) = %q, %v, want %q", value, got, err, want)
+		}
+	}
+}
+
+func TestParseLEEF1Message(t *testing.T) {
+	got, err := parseLEEFMessage("LEEF:1.0|Microsoft|Windows|10|4625|devTime=1704207845000\tsrc=203.0.113.9\tsev=8")
+	if err != nil || got.severity != "ERROR" || got.clientIP != "203.0.113.9" || got.timestamp != "2024-01-02 15:04:05" {
+		t.Errorf("parseLEEFMessage() = %+v, %v", got, err)
+	}
+}
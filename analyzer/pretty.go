@@ -0,0 +1,261 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorGray   = "\033[90m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+var severityColors = map[string]string{
+	"TRACE":    colorGray,
+	"DEBUG":    colorGray,
+	"INFO":     colorGreen,
+	"NOTICE":   colorGreen,
+	"WARNING":  colorYellow,
+	"ERROR":    colorRed,
+	"CRITICAL": colorRed,
+	"FATAL":    colorRed,
+}
+
+type prettyOptions struct {
+	parser   Parser
+	color    bool
+	severity string
+	module   string
+	since    time.Time
+	until    time.Time
+	// limit, when set, is how many lines to print at most
+	limit int
+}
+
+func colorize(text string, color string, enabled bool) string {
+	if !enabled || color == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+func formatPrettyLogMessage(logMessage LogMessage, options prettyOptions) string {
+	source := logMessage.module + ":" + logMessage.function + ":" + strconv.FormatInt(logMessage.lineNumber, 10)
+	return colorize(fmt.Sprintf("%-23s", logMessage.timestamp), colorGray, options.color) + " " +
+		colorize(fmt.Sprintf("%-8s", logMessage.severity), severityColors[logMessage.severity], options.color) + " " +
+		colorize(fmt.Sprintf("%-40s", source), colorCyan, options.color) + " " +
+		logMessage.message
+}
+
+// prettyLogLines hands every line of reader to print to emit, until emit
+// returns false, ctx is done or reader ends. Lines that don't parse are
+// printed as they are, unless entries are filtered.
+func prettyLogLines(ctx context.Context, reader io.Reader, options prettyOptions, emit func(line string) bool) error {
+	filtering := options.severity != "" || options.module != "" || !options.since.IsZero() || !options.until.IsZero()
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		logRow := scanner.Text()
+		logMessage, err := options.parser(logRow)
+		line := logRow
+		if err != nil {
+			if filtering || strings.TrimSpace(logRow) == "" {
+				continue
+			}
+		} else {
+			if options.severity != "" && logMessage.severity != options.severity {
+				continue
+			}
+			if options.module != "" && logMessage.module != options.module {
+				continue
+			}
+			if isOutsideTimeRange(logMessage.timestamp, options.since, options.until) {
+				continue
+			}
+			line = formatPrettyLogMessage(logMessage, options)
+		}
+		if !emit(line) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// prettyPrintLog prints reader's entries, stopping after options.limit
+// lines when it is set.
+func prettyPrintLog(reader io.Reader, writer io.Writer, options prettyOptions) error {
+	printed := 0
+	return prettyLogLines(context.Background(), reader, options, func(line string) bool {
+		fmt.Fprintln(writer, line)
+		printed += 1
+		return options.limit <= 0 || printed < options.limit
+	})
+}
+
+// prettyFileLines is what one file has to print, up to the limit.
+type prettyFileLines struct {
+	lines []string
+	err   error
+}
+
+func readPrettyFileLines(ctx context.Context, logPath string, options prettyOptions) (fileLines prettyFileLines) {
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		fileLines.err = newFileError(logPath, 0, err)
+		return
+	}
+	defer logFile.Close()
+	err = prettyLogLines(ctx, logFile, options, func(line string) bool {
+		fileLines.lines = append(fileLines.lines, line)
+		return len(fileLines.lines) < options.limit
+	})
+	if err != nil && ctx.Err() == nil {
+		fileLines.err = newFileError(logPath, 0, err)
+	}
+	return
+}
+
+// prettyPrintFirst prints the first options.limit lines of logPaths, in
+// input order, reading the files on workers goroutines. No file can add more
+// than limit lines, so each stops there, and once the files before one hold
+// limit lines between them the workers are cancelled, so reading stops as
+// soon as the answer is known. It returns the errors of the files it needed.
+func prettyPrintFirst(ctx context.Context, logPaths []string, writer io.Writer, options prettyOptions, workers int) (fileErrors []error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]chan prettyFileLines, len(logPaths))
+	for index := range results {
+		results[index] = make(chan prettyFileLines, 1)
+	}
+	indexChan := make(chan int)
+	go func() {
+		defer close(indexChan)
+		for index := range logPaths {
+			select {
+			case indexChan <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for worker := 0; worker < min(workers, len(logPaths)); worker++ {
+		go func() {
+			for index := range indexChan {
+				results[index] <- readPrettyFileLines(ctx, logPaths[index], options)
+			}
+		}()
+	}
+
+	remaining := options.limit
+	for index := range logPaths {
+		var fileLines prettyFileLines
+		select {
+		case fileLines = <-results[index]:
+		case <-ctx.Done():
+			return
+		}
+		for _, line := range fileLines.lines[:min(len(fileLines.lines), remaining)] {
+			fmt.Fprintln(writer, line)
+			remaining -= 1
+		}
+		if fileLines.err != nil {
+			fileErrors = append(fileErrors, fileLines.err)
+		}
+		if remaining == 0 {
+			break
+		}
+	}
+	return
+}
+
+func runPretty(args []string) {
+	flagSet := flag.NewFlagSet("pretty", flag.ExitOnError)
+	noColor := flagSet.Bool("no-color", false, "disable ANSI colors")
+	severity := flagSet.String("severity", "", "only show entries with this severity")
+	module := flagSet.String("module", "", "only show entries from this module")
+	preset := flagSet.String("preset", "native", "input log format")
+	pattern := flagSet.String("pattern", "", "custom input format as a named-group regex or {field} template")
+	since := flagSet.String("since", "", "only show entries from this time on: a timestamp or a duration back from now such as 1h")
+	until := flagSet.String("until", "", "only show entries before this time: a timestamp or a duration back from now")
+	limit := flagSet.Int("limit", 0, "print at most this many lines, the first in input order, and stop reading once they are found")
+	workers := flagSet.Int("workers", runtime.GOMAXPROCS(0), "number of files to read at once with -limit")
+	flagSet.Parse(args)
+
+	parser, err := resolveLineParser(*preset, *pattern, timeFormat{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	options := prettyOptions{
+		parser:   parser,
+		color:    !*noColor && os.Getenv("NO_COLOR") == "",
+		severity: strings.ToUpper(*severity),
+		module:   *module,
+		limit:    *limit,
+	}
+	if *since != "" {
+		if options.since, err = parseTimeBound(*since, time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	if *until != "" {
+		if options.until, err = parseTimeBound(*until, time.Now()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
+	}
+	if *limit < 0 || *workers < 1 {
+		fmt.Fprintln(os.Stderr, "-limit can't be negative and -workers must be at least 1")
+		os.Exit(exitUsage)
+	}
+	if flagSet.NArg() == 0 {
+		if err := prettyPrintLog(os.Stdin, os.Stdout, options); err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading stdin:", err)
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	if options.limit > 0 {
+		fileErrors := prettyPrintFirst(context.Background(), flagSet.Args(), os.Stdout, options, *workers)
+		for _, err := range fileErrors {
+			fmt.Fprintln(os.Stderr, "Error reading file:", err)
+		}
+		if len(fileErrors) > 0 {
+			os.Exit(exitFailure)
+		}
+		return
+	}
+	exitStatus := exitOK
+	for _, logPath := range flagSet.Args() {
+		logFile, err := os.Open(logPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading file:", newFileError(logPath, 0, err))
+			exitStatus = exitFailure
+			continue
+		}
+		err = prettyPrintLog(logFile, os.Stdout, options)
+		logFile.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading file:", newFileError(logPath, 0, err))
+			exitStatus = exitFailure
+		}
+	}
+	if exitStatus != exitOK {
+		os.Exit(exitStatus)
+	}
+}
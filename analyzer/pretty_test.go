@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrettyPrintLog(t *testing.T) {
+	logContent := `2024-01-01 00:00:00.000 | INFO | app: start: 1 - Started
+not a log line
+2024-01-01 00:00:01.000 | ERROR | db: query: 42 - Timeout`
+
+	var output bytes.Buffer
+	if err := prettyPrintLog(strings.NewReader(logContent), &output, prettyOptions{parser: parseLogMessage}); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-01-01 00:00:00.000 INFO     app:start:1                              Started\n" +
+		"not a log line\n" +
+		"2024-01-01 00:00:01.000 ERROR    db:query:42                              Timeout\n"
+	if output.String() != want {
+		t.Errorf("prettyPrintLog() = %q, want %q", output.String(), want)
+	}
+
+	output.Reset()
+	if err := prettyPrintLog(strings.NewReader(logContent), &output, prettyOptions{parser: parseLogMessage, severity: "ERROR", color: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(output.String(), "\n") != 1 || !strings.Contains(output.String(), colorRed+"ERROR") {
+		t.Errorf("prettyPrintLog() with filter = %q", output.String())
+	}
+}
+
+func TestPrettyPrintLogLimit(t *testing.T) {
+	logContent := `2024-01-01 01:59:00.000 | ERROR | db: query: 42 - Timeout
+2024-01-01 02:00:00.000 | INFO | app: start: 1 - Started
+2024-01-01 02:00:01.000 | ERROR | db: query: 42 - Timeout
+2024-01-01 02:00:02.000 | ERROR | db: query: 43 - Deadlock`
+
+	var output bytes.Buffer
+	options := prettyOptions{parser: parseLogMessage, severity: "ERROR", since: time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC), limit: 1}
+	if err := prettyPrintLog(strings.NewReader(logContent), &output, options); err != nil {
+		t.Fatal(err)
+	}
+	if got := output.String(); strings.Count(got, "\n") != 1 || !strings.HasPrefix(got, "2024-01-01 02:00:01.000 ERROR") {
+		t.Errorf("prettyPrintLog() with limit = %q, want the first error from 02:00", got)
+	}
+}
+
+func TestPrettyPrintFirst(t *testing.T) {
+	logDir := t.TempDir()
+	var logPaths []string
+	for _, name := range []string{"a.log", "b.log", "c.log"} {
+		logPath := filepath.Join(logDir, name)
+		content := "2024-01-01 02:00:00.000 | ERROR | db: query: 42 - " + name + " 1\n" +
+			"2024-01-01 02:00:01.000 | ERROR | db: query: 42 - " + name + " 2\n"
+		if err := os.WriteFile(logPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		logPaths = append(logPaths, logPath)
+	}
+	// Files past the ones the limit needs aren't reported, whether or not
+	// they were read
+	logPaths = append(logPaths, filepath.Join(logDir, "missing.log"))
+
+	for _, workers := range []int{1, 3} {
+		var output bytes.Buffer
+		options := prettyOptions{parser: parseLogMessage, limit: 5}
+		fileErrors := prettyPrintFirst(context.Background(), logPaths, &output, options, workers)
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if len(lines) != 5 || !strings.HasSuffix(lines[0], "a.log 1") || !strings.HasSuffix(lines[4], "c.log 1") {
+			t.Errorf("prettyPrintFirst() with %d workers = %q, want the first 5 lines in file order", workers, lines)
+		}
+		if len(fileErrors) != 0 {
+			t.Errorf("prettyPrintFirst() with %d workers = %v, want no errors", workers, fileErrors)
+		}
+	}
+
+	var output bytes.Buffer
+	fileErrors := prettyPrintFirst(context.Background(), logPaths, &output, prettyOptions{parser: parseLogMessage, limit: 10}, 2)
+	if strings.Count(output.String(), "\n") != 6 || len(fileErrors) != 1 {
+		t.Errorf("prettyPrintFirst() past the files = %d lines and errors %v, want 6 and one", strings.Count(output.String(), "\n"), fileErrors)
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// A terminal gets its progress line redrawn in place often; anything else,
+// such as a CI log, gets a new line now and then.
+const (
+	progressRedrawInterval = 500 * time.Millisecond
+	progressLogInterval    = 10 * time.Second
+)
+
+// progressReporter writes how far a run is along, read from the bytes_read
+// and files_analyzed counters the workers update, until stop is called.
+type progressReporter struct {
+	writer     io.Writer
+	redraw     bool
+	totalBytes int64
+	totalFiles int
+	startBytes int64
+	startFiles int64
+	started    time.Time
+	done       chan struct{}
+	stopped    chan struct{}
+}
+
+// newProgressReporter reports on the analysis of logPaths. The total size is
+// unknown, and no ETA is given, when any of them is stdin or can't be read.
+func newProgressReporter(writer io.Writer, redraw bool, logPaths []string) *progressReporter {
+	progress := &progressReporter{writer: writer, redraw: redraw, totalFiles: len(logPaths)}
+	for _, logPath := range logPaths {
+		fileInfo, err := os.Stat(logPath)
+		if logPath == stdinPath || err != nil || !fileInfo.Mode().IsRegular() {
+			progress.totalBytes = -1
+			break
+		}
+		progress.totalBytes += fileInfo.Size()
+	}
+	return progress
+}
+
+func (progress *progressReporter) start() {
+	progress.startBytes = metrics.counter("bytes_read").Load()
+	progress.startFiles = metrics.counter("files_analyzed").Load()
+	progress.started = time.Now()
+	progress.done = make(chan struct{})
+	progress.stopped = make(chan struct{})
+	interval := progressLogInterval
+	if progress.redraw {
+		interval = progressRedrawInterval
+	}
+	go func() {
+		defer close(progress.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-progress.done:
+				return
+			case now := <-ticker.C:
+				progress.write(now)
+			}
+		}
+	}()
+}
+
+// stop writes the final state, so the last line shows what was done.
+func (progress *progressReporter) stop() {
+	close(progress.done)
+	<-progress.stopped
+	progress.write(time.Now())
+	if progress.redraw {
+		io.WriteString(progress.writer, "\n")
+	}
+}
+
+func (progress *progressReporter) write(now time.Time) {
+	line := formatProgress(
+		metrics.counter("bytes_read").Load()-progress.startBytes, progress.totalBytes,
+		metrics.counter("files_analyzed").Load()-progress.startFiles, progress.totalFiles,
+		now.Sub(progress.started))
+	if progress.redraw {
+		// Clear what is left of a longer previous line
+		io.WriteString(progress.writer, "\r"+line+"\033[K")
+		return
+	}
+	io.WriteString(progress.writer, line+"\n")
+}
+
+func formatProgress(bytesRead int64, totalBytes int64, filesDone int64, totalFiles int, elapsed time.Duration) string {
+	line := "Progress: " + humanizeBytes(bytesRead, reportOptions{})
+	if totalBytes > 0 {
+		percent := min(100, 100*float64(bytesRead)/float64(totalBytes))
+		line += " of " + humanizeBytes(totalBytes, reportOptions{}) + " (" + strconv.FormatFloat(percent, 'f', 1, 64) + "%)"
+	}
+	line += ", " + strconv.FormatInt(filesDone, 10) + " of " + strconv.Itoa(totalFiles) + " files"
+	if eta, ok := getProgressETA(bytesRead, totalBytes, elapsed); ok {
+		line += ", ETA " + humanizeDuration(eta, reportOptions{})
+	}
+	return line
+}
+
+// getProgressETA assumes the rest is read at the average rate so far. There
+// is no estimate before anything is read or when the total is unknown.
+func getProgressETA(bytesRead int64, totalBytes int64, elapsed time.Duration) (time.Duration, bool) {
+	if totalBytes <= 0 || bytesRead <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+	remaining := max(0, totalBytes-bytesRead)
+	return time.Duration(float64(elapsed) * float64(remaining) / float64(bytesRead)).Round(time.Second), true
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatProgress(t *testing.T) {
+	got := formatProgress(250_000_000, 1_000_000_000, 3, 10, time.Minute)
+	want := "Progress: 250 MB of 1 GB (25.0%), 3 of 10 files, ETA 3m"
+	if got != want {
+		t.Errorf("formatProgress() = %q, want %q", got, want)
+	}
+	// Without a known total there is no percentage or estimate
+	if got, want := formatProgress(1500, -1, 0, 1, time.Second), "Progress: 1.5 KB, 0 of 1 files"; got != want {
+		t.Errorf("formatProgress() = %q, want %q", got, want)
+	}
+}
+
+func TestGetProgressETA(t *testing.T) {
+	if _, ok := getProgressETA(0, 100, time.Second); ok {
+		t.Error("getProgressETA() estimated before anything was read")
+	}
+	// Files that grow while being read don't give a negative estimate
+	if eta, ok := getProgressETA(120, 100, time.Second); !ok || eta != 0 {
+		t.Errorf("getProgressETA() = %v, %v, want 0, true", eta, ok)
+	}
+}
+
+func TestProgressReporterTotalBytes(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if progress := newProgressReporter(nil, false, []string{logPath, logPath}); progress.totalBytes != 20 || progress.totalFiles != 2 {
+		t.Errorf("newProgressReporter() total = %d bytes, %d files, want 20 and 2", progress.totalBytes, progress.totalFiles)
+	}
+	if progress := newProgressReporter(nil, false, []string{logPath, stdinPath}); progress.totalBytes != -1 {
+		t.Errorf("newProgressReporter() total with stdin = %d, want -1", progress.totalBytes)
+	}
+}
+
+func TestStreamLogMessagesUpdatesBytesReadWhileReading(t *testing.T) {
+	logContent := strings.Repeat("2024-01-01 08:00:00.000 | INFO | db:query:12 - ok\n", bytesReadFlushSize/50+1)
+	bytesReadCounter := metrics.counter("bytes_read")
+	before := bytesReadCounter.Load()
+	parsedLineChan := make(chan parsedLine)
+	go func() {
+		defer close(parsedLineChan)
+		streamLogMessages(context.Background(), bufio.NewReader(strings.NewReader(logContent)), parseLogMessage, nil, parsedLineChan)
+	}()
+	// The counter advances before the reader is done with the file
+	for range parsedLineChan {
+		if bytesReadCounter.Load() > before {
+			break
+		}
+	}
+	if bytesReadCounter.Load() <= before {
+		t.Fatal("bytes_read didn't advance while streaming")
+	}
+	for range parsedLineChan {
+	}
+	if got := bytesReadCounter.Load() - before; got != int64(len(logContent)) {
+		t.Errorf("bytes_read grew by %d, want %d", got, len(logContent))
+	}
+}
+
+func TestProgressReporterStop(t *testing.T) {
+	var output bytes.Buffer
+	progress := newProgressReporter(&output, true, nil)
+	progress.start()
+	progress.stop()
+	if got := output.String(); !strings.HasPrefix(got, "\rProgress: ") || !strings.HasSuffix(got, "\033[K\n") {
+		t.Errorf("stop() wrote %q, want a redrawn line ending the output", got)
+	}
+}
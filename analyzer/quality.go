@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	maxMalformedRatio      = 0.1
+	maxDuplicateTimestamps = 0.5
+	minEntriesForRates     = 10
+	futureTolerance        = 24 * time.Hour
+	pastTolerance          = 10 * 365 * 24 * time.Hour
+	clockSkewTolerance     = time.Minute
+	maxOutOfOrderRatio     = 0.05
+	// maxLeadingRecords bounds the timestamps a tracker keeps for merge
+	maxLeadingRecords = 4096
+)
+
+func getDataQualityWarnings(logPath string, logMessages []LogMessage, stats parseStats, now time.Time) []string {
+	quality := qualityTracker{now: now}
+	quality.observe(logMessages)
+	return quality.warnings(logPath, stats)
+}
+
+// qualityTracker checks a file's entries as they stream past in chunks,
+// carrying the previous and latest timestamps from one chunk to the next.
+// The ranges of a split file each get their own, merged in file order.
+type qualityTracker struct {
+	now                 time.Time
+	entries             int
+	unparsed            int
+	futureEntries       int
+	pastEntries         int
+	duplicateTimestamps int
+	backwardJumps       int
+	outOfOrder          int
+	previous            time.Time
+	latest              time.Time
+	// first is the first parsed timestamp, and leadingRecords the first of
+	// those later than every one before them, which merge rechecks against
+	// the trackers before this one
+	first          time.Time
+	leadingRecords []time.Time
+}
+
+func (quality *qualityTracker) observe(logMessages []LogMessage) {
+	quality.entries += len(logMessages)
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			quality.unparsed += 1
+			continue
+		}
+		if timestamp.After(quality.now.Add(futureTolerance)) {
+			quality.futureEntries += 1
+		}
+		if timestamp.Before(quality.now.Add(-pastTolerance)) {
+			quality.pastEntries += 1
+		}
+		if !quality.previous.IsZero() {
+			if timestamp.Equal(quality.previous) {
+				quality.duplicateTimestamps += 1
+			}
+			if quality.previous.Sub(timestamp) > clockSkewTolerance {
+				quality.backwardJumps += 1
+			}
+		}
+		// Earlier than any entry before it, not just the one before
+		if timestamp.Before(quality.latest) {
+			quality.outOfOrder += 1
+		} else {
+			quality.latest = timestamp
+			if len(quality.leadingRecords) < maxLeadingRecords {
+				quality.leadingRecords = append(quality.leadingRecords, timestamp)
+			}
+		}
+		if quality.first.IsZero() {
+			quality.first = timestamp
+		}
+		quality.previous = timestamp
+	}
+}
+
+// merge adds the entries next observed after those quality did. Entries of
+// next that were in order by themselves are out of order after an earlier
+// latest timestamp; only the first maxLeadingRecords of them are rechecked,
+// so that count is a lower bound for a range almost wholly older than the
+// ranges before it.
+func (quality *qualityTracker) merge(next *qualityTracker) {
+	if !quality.previous.IsZero() && !next.first.IsZero() {
+		if next.first.Equal(quality.previous) {
+			quality.duplicateTimestamps += 1
+		}
+		if quality.previous.Sub(next.first) > clockSkewTolerance {
+			quality.backwardJumps += 1
+		}
+	}
+	for _, timestamp := range next.leadingRecords {
+		if !timestamp.Before(quality.latest) {
+			break
+		}
+		quality.outOfOrder += 1
+	}
+	quality.entries += next.entries
+	quality.unparsed += next.unparsed
+	quality.futureEntries += next.futureEntries
+	quality.pastEntries += next.pastEntries
+	quality.duplicateTimestamps += next.duplicateTimestamps
+	quality.backwardJumps += next.backwardJumps
+	quality.outOfOrder += next.outOfOrder
+	if quality.first.IsZero() {
+		quality.first = next.first
+	}
+	if !next.previous.IsZero() {
+		quality.previous = next.previous
+	}
+	if next.latest.After(quality.latest) {
+		quality.latest = next.latest
+	}
+}
+
+func (quality *qualityTracker) warnings(logPath string, stats parseStats) (warnings []string) {
+	if stats.lines >= minEntriesForRates && float64(stats.malformedLines)/float64(stats.lines) > maxMalformedRatio {
+		warnings = append(warnings, logPath+": "+strconv.FormatInt(stats.malformedLines, 10)+" of "+
+			strconv.FormatInt(stats.lines, 10)+" lines are malformed")
+	}
+	if quality.futureEntries > 0 {
+		warnings = append(warnings, logPath+": "+strconv.Itoa(quality.futureEntries)+" entries are timestamped in the future")
+	}
+	if quality.pastEntries > 0 {
+		warnings = append(warnings, logPath+": "+strconv.Itoa(quality.pastEntries)+" entries are timestamped more than ten years ago")
+	}
+	if quality.entries >= minEntriesForRates && float64(quality.duplicateTimestamps)/float64(quality.entries) > maxDuplicateTimestamps {
+		warnings = append(warnings, logPath+": "+strconv.Itoa(quality.duplicateTimestamps)+" entries repeat the previous timestamp exactly")
+	}
+	if quality.backwardJumps > 0 {
+		warnings = append(warnings, logPath+": timestamps jump backwards by more than "+clockSkewTolerance.String()+" "+
+			strconv.Itoa(quality.backwardJumps)+" times, suggesting clock skew")
+	}
+	if quality.entries >= minEntriesForRates && float64(quality.outOfOrder)/float64(quality.entries) > maxOutOfOrderRatio {
+		warnings = append(warnings, logPath+": "+strconv.Itoa(quality.outOfOrder)+" of "+strconv.Itoa(quality.entries)+
+			" entries are out of order; start and end times are the earliest and latest entries")
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetDataQualityWarnings(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	testLogs := []LogMessage{
+		{timestamp: "2024-05-01 00:10:00.000"},
+		{timestamp: "2024-05-01 00:00:00.000"},
+		{timestamp: "2030-01-01 00:00:00.000"},
+		{timestamp: "2001-01-01 00:00:00.000"},
+	}
+	stats := parseStats{lines: 20, malformedLines: 16}
+
+	got := getDataQualityWarnings("app.log", testLogs, stats, now)
+	want := []string{
+		"app.log: 16 of 20 lines are malformed",
+		"app.log: 1 entries are timestamped in the future",
+		"app.log: 1 entries are timestamped more than ten years ago",
+		"app.log: timestamps jump backwards by more than 1m0s 2 times, suggesting clock skew",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getDataQualityWarnings() = %q, want %q", got, want)
+	}
+
+	clean := []LogMessage{{timestamp: "2024-05-01 00:00:00.000"}, {timestamp: "2024-05-01 00:00:01.000"}}
+	if got := getDataQualityWarnings("app.log", clean, parseStats{lines: 2}, now); len(got) != 0 {
+		t.Errorf("getDataQualityWarnings() on clean input = %q", got)
+	}
+}
+
+func TestGetDataQualityWarningsOutOfOrder(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var testLogs []LogMessage
+	for second := 0; second < 10; second++ {
+		testLogs = append(testLogs, LogMessage{timestamp: time.Date(2024, 5, 1, 0, 0, second, 0, time.UTC).Format(layout)})
+	}
+	// Entries 5 and 4 arrive after 6, and 7 after 8: seconds apart, not a clock jump
+	testLogs[4], testLogs[6] = testLogs[6], testLogs[4]
+	testLogs[7], testLogs[8] = testLogs[8], testLogs[7]
+
+	got := getDataQualityWarnings("app.log", testLogs, parseStats{lines: 10}, now)
+	want := []string{"app.log: 3 of 10 entries are out of order; start and end times are the earliest and latest entries"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getDataQualityWarnings() = %q, want %q", got, want)
+	}
+}
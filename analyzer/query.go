@@ -0,0 +1,335 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxReportedQueries = 10
+
+// Upper bounds of the query time distribution; the last bucket is open.
+var queryTimeBounds = []time.Duration{100 * time.Millisecond, time.Second, 10 * time.Second}
+
+var (
+	queryStringPattern     = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"`)
+	queryNumberPattern     = regexp.MustCompile(`\$\d+|\b\d+(?:\.\d+)?\b`)
+	queryValueListPattern  = regexp.MustCompile(`\((?:\s*\?\s*,)+\s*\?\s*\)`)
+	queryWhitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// fingerprintQuery normalizes a statement so executions that differ only in
+// their literal values rank together: strings, numbers and $n placeholders
+// become ?, value lists collapse to (?+), whitespace is squeezed and the
+// statement is lowercased.
+func fingerprintQuery(query string) string {
+	query = queryStringPattern.ReplaceAllString(query, "?")
+	query = queryNumberPattern.ReplaceAllString(query, "?")
+	query = queryValueListPattern.ReplaceAllString(query, "(?+)")
+	query = queryWhitespacePattern.ReplaceAllString(query, " ")
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(query), "; "))
+}
+
+var (
+	mysqlUserHostPattern = regexp.MustCompile(`^# User@Host: (\S+?)\[[^\]]*\] @ (\S*) ?\[([^\]]*)\]`)
+	mysqlStatsPattern    = regexp.MustCompile(`(\w+): (\S+)`)
+	mysqlUsePattern      = regexp.MustCompile(`(?i)^use (\S+);$`)
+	mysqlTimestampSet    = regexp.MustCompile(`(?i)^SET timestamp=(\d+);$`)
+)
+
+// newMySQLSlowParser reads the MySQL and MariaDB slow query log. An entry
+// spans several lines: # comment headers with the time, user and timings,
+// then the statement, which ends at the line ending in a semicolon. Header
+// lines are returned as directives and the entry comes with its last line.
+// The message is the query fingerprint and the timings are attributes.
+func newMySQLSlowParser() Parser {
+	var pending *LogMessage
+	var query strings.Builder
+	var database string
+	return func(logRow string) (logMessage LogMessage, err error) {
+		line := strings.TrimSpace(logRow)
+		if strings.HasPrefix(line, "#") {
+			if pending == nil || query.Len() > 0 {
+				pending = &LogMessage{attributes: make(map[string]string)}
+				query.Reset()
+			}
+			switch {
+			case strings.HasPrefix(line, "# Time:"):
+				value := strings.Join(strings.Fields(strings.TrimPrefix(line, "# Time:")), " ")
+				pending.timestamp, _ = normalizeTimestamp(value, time.RFC3339Nano, "060102 15:04:05")
+			case strings.HasPrefix(line, "# User@Host:"):
+				if match := mysqlUserHostPattern.FindStringSubmatch(line); match != nil {
+					pending.attributes["user"] = match[1]
+					pending.attributes["host"] = match[2]
+					pending.clientIP = match[3]
+				}
+			default:
+				for _, match := range mysqlStatsPattern.FindAllStringSubmatch(line, -1) {
+					pending.attributes[strings.ToLower(match[1])] = match[2]
+				}
+			}
+			return logMessage, errDirectiveLine
+		}
+		// Server banners before the first entry
+		if pending == nil {
+			return logMessage, errDirectiveLine
+		}
+		if match := mysqlUsePattern.FindStringSubmatch(line); match != nil && query.Len() == 0 {
+			database = strings.Trim(match[1], "`")
+			return logMessage, errDirectiveLine
+		}
+		if match := mysqlTimestampSet.FindStringSubmatch(line); match != nil && query.Len() == 0 {
+			seconds, _ := strconv.ParseInt(match[1], 10, 64)
+			pending.timestamp = time.Unix(seconds, 0).UTC().Format(layout)
+			return logMessage, errDirectiveLine
+		}
+		if query.Len() > 0 {
+			query.WriteByte(' ')
+		}
+		query.WriteString(line)
+		if !strings.HasSuffix(line, ";") {
+			return logMessage, errDirectiveLine
+		}
+		logMessage = *pending
+		pending = nil
+		if logMessage.timestamp == "" {
+			return logMessage, errBadTimestamp
+		}
+		if schema := logMessage.attributes["schema"]; schema != "" {
+			database = schema
+		}
+		logMessage.attributes["db"] = database
+		logMessage.module = database
+		logMessage.severity = "INFO"
+		logMessage.message = fingerprintQuery(query.String())
+		return logMessage, nil
+	}
+}
+
+// PostgreSQL's default log_line_prefix '%m [%p] ', optionally followed by
+// user@database: 2024-01-02 15:04:05.123 UTC [4242] app@shop LOG:  duration: ...
+var postgresPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)(?: [A-Za-z]+| [+-]\d+)? \[(\d+)\](?:-\d+)? (?:(\S*)@(\S*) )?([A-Z]+\d?):\s+(.*)$`)
+
+var postgresDurationPattern = regexp.MustCompile(`^duration: ([\d.]+) ms(?:\s+(?:statement|execute [^:]*|parse [^:]*|bind [^:]*): (.*))?$`)
+
+var postgresLockPattern = regexp.MustCompile(`^process \d+ (still waiting for|acquired) (\w+) on .* after ([\d.]+) ms`)
+
+var postgresSeverities = map[string]string{
+	"LOG":       "INFO",
+	"STATEMENT": "INFO",
+	"DETAIL":    "INFO",
+	"HINT":      "INFO",
+	"CONTEXT":   "INFO",
+	"DEBUG1":    "DEBUG",
+	"DEBUG2":    "DEBUG",
+	"DEBUG3":    "DEBUG",
+	"DEBUG4":    "DEBUG",
+	"DEBUG5":    "DEBUG",
+}
+
+// parsePostgresMessage reads PostgreSQL server log lines. Statements logged
+// by log_min_duration_statement get their fingerprint as the message and a
+// query_time attribute. Lock waits from log_lock_waits get lock_type and
+// lock_wait, which is "waiting" when one starts and the seconds waited once
+// the lock is acquired. Lines that continue a multi-line statement are
+// skipped, so such statements are fingerprinted from their first line.
+func parsePostgresMessage(logRow string) (logMessage LogMessage, err error) {
+	if strings.HasPrefix(logRow, "\t") || strings.HasPrefix(logRow, " ") {
+		return logMessage, errDirectiveLine
+	}
+	match := postgresPattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	logMessage.timestamp, err = normalizeTimestamp(match[1], "2006-01-02 15:04:05.999999999")
+	if err != nil {
+		return
+	}
+	logMessage.pid = match[2]
+	logMessage.module = match[4]
+	logMessage.severity = normalizeSeverity(match[5])
+	if severity, ok := postgresSeverities[logMessage.severity]; ok {
+		logMessage.severity = severity
+	}
+	logMessage.message = match[6]
+	logMessage.attributes = map[string]string{"user": match[3], "db": match[4]}
+	if duration := postgresDurationPattern.FindStringSubmatch(match[6]); duration != nil {
+		milliseconds, _ := strconv.ParseFloat(duration[1], 64)
+		logMessage.attributes["query_time"] = strconv.FormatFloat(milliseconds/1000, 'f', -1, 64)
+		if duration[2] != "" {
+			logMessage.message = fingerprintQuery(duration[2])
+		}
+	} else if lock := postgresLockPattern.FindStringSubmatch(match[6]); lock != nil {
+		logMessage.attributes["lock_type"] = lock[2]
+		logMessage.attributes["lock_wait"] = "waiting"
+		if lock[1] == "acquired" {
+			milliseconds, _ := strconv.ParseFloat(lock[3], 64)
+			logMessage.attributes["lock_wait"] = strconv.FormatFloat(milliseconds/1000, 'f', -1, 64)
+		}
+	}
+	return
+}
+
+type queryStats struct {
+	count        int64
+	totalTime    float64
+	maxTime      float64
+	lockTime     float64
+	rowsExamined int64
+}
+
+// queryProfile aggregates the query_time, lock_time and lock_wait
+// attributes of the slow query presets. Times are in seconds; lockWaitTime
+// only covers waits that ended with the lock acquired.
+type queryProfile struct {
+	queries       map[string]queryStats
+	timeBuckets   []int64
+	lockWaits     int64
+	lockWaitTime  float64
+	lockWaitTypes map[string]int64
+}
+
+func newQueryProfile() queryProfile {
+	return queryProfile{
+		queries:       make(map[string]queryStats),
+		timeBuckets:   make([]int64, len(queryTimeBounds)+1),
+		lockWaitTypes: make(map[string]int64),
+	}
+}
+
+func getQueryProfile(logMessages []LogMessage) (profile queryProfile) {
+	profile = newQueryProfile()
+	for _, logMessage := range logMessages {
+		if value := logMessage.attributes["lock_wait"]; value == "waiting" {
+			profile.lockWaits += 1
+			profile.lockWaitTypes[logMessage.attributes["lock_type"]] += 1
+		} else if value != "" {
+			seconds, _ := strconv.ParseFloat(value, 64)
+			profile.lockWaitTime += seconds
+		}
+		value := logMessage.attributes["query_time"]
+		if value == "" {
+			continue
+		}
+		queryTime, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		stats := profile.queries[logMessage.message]
+		stats.count += 1
+		stats.totalTime += queryTime
+		stats.maxTime = max(stats.maxTime, queryTime)
+		lockTime, _ := strconv.ParseFloat(logMessage.attributes["lock_time"], 64)
+		stats.lockTime += lockTime
+		rowsExamined, _ := strconv.ParseInt(logMessage.attributes["rows_examined"], 10, 64)
+		stats.rowsExamined += rowsExamined
+		profile.queries[logMessage.message] = stats
+		bucket := 0
+		for bucket < len(queryTimeBounds) && time.Duration(queryTime*float64(time.Second)) >= queryTimeBounds[bucket] {
+			bucket++
+		}
+		profile.timeBuckets[bucket] += 1
+	}
+	return
+}
+
+func mergeQueryProfiles(into *queryProfile, from queryProfile) {
+	if from.queries == nil {
+		return
+	}
+	if into.queries == nil {
+		*into = newQueryProfile()
+	}
+	for query, stats := range from.queries {
+		merged := into.queries[query]
+		merged.count += stats.count
+		merged.totalTime += stats.totalTime
+		merged.maxTime = max(merged.maxTime, stats.maxTime)
+		merged.lockTime += stats.lockTime
+		merged.rowsExamined += stats.rowsExamined
+		into.queries[query] = merged
+	}
+	for bucket, count := range from.timeBuckets {
+		into.timeBuckets[bucket] += count
+	}
+	into.lockWaits += from.lockWaits
+	into.lockWaitTime += from.lockWaitTime
+	for lockType, count := range from.lockWaitTypes {
+		into.lockWaitTypes[lockType] += count
+	}
+}
+
+// getTopQueries ranks fingerprints by total time, or by count when byCount
+// is set, and keeps maxReportedQueries of them.
+func getTopQueries(queries map[string]queryStats, byCount bool) (fingerprints []string) {
+	for fingerprint := range queries {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		first, second := queries[fingerprints[i]], queries[fingerprints[j]]
+		if byCount && first.count != second.count {
+			return first.count > second.count
+		}
+		if first.totalTime != second.totalTime {
+			return first.totalTime > second.totalTime
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+	if len(fingerprints) > maxReportedQueries {
+		fingerprints = fingerprints[:maxReportedQueries]
+	}
+	return
+}
+
+func getQueryTimeBucketLabel(bucket int) string {
+	if bucket == len(queryTimeBounds) {
+		return ">= " + queryTimeBounds[bucket-1].String()
+	}
+	return "< " + queryTimeBounds[bucket].String()
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64) + "s"
+}
+
+func formatQueryStats(fingerprint string, stats queryStats, options reportOptions) string {
+	line := humanizeCount(stats.count, options) + "x, total " + formatSeconds(stats.totalTime) +
+		", max " + formatSeconds(stats.maxTime)
+	if stats.lockTime > 0 {
+		line += ", lock " + formatSeconds(stats.lockTime)
+	}
+	if stats.rowsExamined > 0 {
+		line += ", " + humanizeCount(stats.rowsExamined, options) + " rows examined"
+	}
+	return line + ": " + fingerprint
+}
+
+func formatQueryProfile(profile queryProfile, options reportOptions) (lines []string) {
+	lines = append(lines, "By Total Time: ")
+	for _, fingerprint := range getTopQueries(profile.queries, false) {
+		lines = append(lines, "   "+formatQueryStats(fingerprint, profile.queries[fingerprint], options))
+	}
+	lines = append(lines, "By Count: ")
+	for _, fingerprint := range getTopQueries(profile.queries, true) {
+		lines = append(lines, "   "+formatQueryStats(fingerprint, profile.queries[fingerprint], options))
+	}
+	lines = append(lines, "Query Time Distribution: ")
+	for bucket, count := range profile.timeBuckets {
+		lines = append(lines, "   "+getQueryTimeBucketLabel(bucket)+": "+humanizeCount(count, options))
+	}
+	if profile.lockWaits > 0 {
+		line := "Lock Waits: " + humanizeCount(profile.lockWaits, options) + ", total " + formatSeconds(profile.lockWaitTime)
+		lockTypes := make([]string, 0, len(profile.lockWaitTypes))
+		for lockType := range profile.lockWaitTypes {
+			lockTypes = append(lockTypes, lockType)
+		}
+		sort.Strings(lockTypes)
+		for _, lockType := range lockTypes {
+			line += ", " + lockType + " " + humanizeCount(profile.lockWaitTypes[lockType], options)
+		}
+		lines = append(lines, line)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFingerprintQuery(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM orders WHERE id = 42;":                        "select * from orders where id = ?",
+		"SELECT  *\tFROM users WHERE email = 'o''brien@example.com'": "select * from users where email = ?",
+		"DELETE FROM t WHERE id IN (1, 2, 3)":                        "delete from t where id in (?+)",
+		"SELECT * FROM t2 WHERE a = $1 AND b = 1.5":                  "select * from t2 where a = ? and b = ?",
+	}
+	for query, want := range tests {
+		if got := fingerprintQuery(query); got != want {
+			t.Errorf("fingerprintQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestMySQLSlowParser(t *testing.T) {
+	input := `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+# Time: 2024-01-02T15:04:05.123456Z
+# User@Host: app[app] @ web1 [10.0.0.5]  Id:    42
+# Query_time: 2.500000  Lock_time: 0.200000 Rows_sent: 1  Rows_examined: 100000
+use shop;
+SET timestamp=1704207845;
+SELECT * FROM orders WHERE id = 42;
+# Time: 2024-01-02T15:05:05.123456Z
+# User@Host: app[app] @ web1 [10.0.0.5]  Id:    42
+# Query_time: 0.050000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 10
+SET timestamp=1704207905;
+SELECT * FROM orders
+WHERE id = 7;
+`
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, analysisOptions{newParser: newMySQLSlowParser})
+	if len(logMessages) != 2 || stats.malformedLines != 0 {
+		t.Fatalf("Expected 2 entries and no malformed lines, got %d and %d", len(logMessages), stats.malformedLines)
+	}
+	got := logMessages[1]
+	if got.timestamp != "2024-01-02 15:05:05" || got.module != "shop" || got.clientIP != "10.0.0.5" ||
+		got.message != "select * from orders where id = ?" || got.Attribute("query_time") != "0.050000" {
+		t.Errorf("Second entry = %+v", got)
+	}
+
+	profile := getQueryProfile(logMessages)
+	orders := profile.queries["select * from orders where id = ?"]
+	if orders.count != 2 || orders.totalTime != 2.55 || orders.maxTime != 2.5 || orders.lockTime != 0.2 || orders.rowsExamined != 100010 {
+		t.Errorf("Query stats = %+v", orders)
+	}
+	if profile.timeBuckets[0] != 1 || profile.timeBuckets[2] != 1 {
+		t.Errorf("Time distribution = %v", profile.timeBuckets)
+	}
+}
+
+func TestParsePostgresMessage(t *testing.T) {
+	got, err := parsePostgresMessage("2024-01-02 15:04:05.123 UTC [4242] app@shop LOG:  duration: 1503.250 ms  statement: SELECT * FROM users WHERE id = 7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.timestamp != "2024-01-02 15:04:05.123" || got.severity != "INFO" || got.pid != "4242" || got.module != "shop" ||
+		got.message != "select * from users where id = ?" || got.Attribute("query_time") != "1.50325" {
+		t.Errorf("parsePostgresMessage() = %+v", got)
+	}
+
+	var logMessages []LogMessage
+	for _, line := range []string{
+		"2024-01-02 15:04:07.000 UTC [4244] LOG:  process 4244 still waiting for ShareLock on transaction 5678 after 1000.123 ms",
+		"2024-01-02 15:04:09.000 UTC [4244] LOG:  process 4244 acquired ShareLock on transaction 5678 after 3000.5 ms",
+		"2024-01-02 15:04:10.000 UTC [4245] FATAL:  terminating connection due to administrator command",
+	} {
+		logMessage, err := parsePostgresMessage(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logMessages = append(logMessages, logMessage)
+	}
+	if logMessages[2].severity != "FATAL" {
+		t.Errorf("Expected FATAL to be kept, got %q", logMessages[2].severity)
+	}
+	profile := getQueryProfile(logMessages)
+	if profile.lockWaits != 1 || profile.lockWaitTime != 3.0005 || profile.lockWaitTypes["ShareLock"] != 1 {
+		t.Errorf("Lock waits = %d, %v, %v", profile.lockWaits, profile.lockWaitTime, profile.lockWaitTypes)
+	}
+}
+
+func TestMergeQueryProfiles(t *testing.T) {
+	first := getQueryProfile([]LogMessage{{message: "select ?", attributes: map[string]string{"query_time": "1"}}})
+	second := getQueryProfile([]LogMessage{{message: "select ?", attributes: map[string]string{"query_time": "12"}}})
+	var merged queryProfile
+	mergeQueryProfiles(&merged, queryProfile{})
+	if merged.queries != nil {
+		t.Fatal("Expected merging a disabled profile to leave it disabled")
+	}
+	mergeQueryProfiles(&merged, first)
+	mergeQueryProfiles(&merged, second)
+	if stats := merged.queries["select ?"]; stats.count != 2 || stats.totalTime != 13 || stats.maxTime != 12 {
+		t.Errorf("Merged stats = %+v", stats)
+	}
+	if merged.timeBuckets[2] != 1 || merged.timeBuckets[3] != 1 {
+		t.Errorf("Merged time distribution = %v", merged.timeBuckets)
+	}
+}
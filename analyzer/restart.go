@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// restartErrorWindow is how far before a restart errors count as related.
+const restartErrorWindow = time.Minute
+
+var pidPattern = regexp.MustCompile(`\bpid[=:#] ?(\d+)`)
+
+type processRestart struct {
+	logPath      string
+	process      string
+	timestamp    time.Time
+	previousPID  string
+	pid          string
+	errorsBefore int64
+	lastError    string
+}
+
+func getLogMessagePID(logMessage LogMessage) string {
+	if logMessage.pid != "" {
+		return logMessage.pid
+	}
+	if match := pidPattern.FindStringSubmatch(logMessage.message); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// getLogMessageProcess returns the program an entry's PID belongs to. Formats
+// with a PID field, like syslog, the journal and logcat, name the program in
+// the module. A PID taken from the message has no program beside it, and the
+// module of such a format is a part of the code rather than a process, so all
+// of these entries count as one process.
+func getLogMessageProcess(logMessage LogMessage) string {
+	if logMessage.pid != "" {
+		return logMessage.module
+	}
+	return ""
+}
+
+// processHistory is what getProcessRestarts remembers about one program.
+type processHistory struct {
+	pid          string
+	recentErrors []LogMessage
+}
+
+// getProcessRestarts treats every change of PID between consecutive entries
+// of the same program in a file as a restart, and attaches the errors logged
+// by the previous process in the restartErrorWindow before it. Programs
+// logging to the same file in turn are followed separately.
+func getProcessRestarts(logPath string, logMessages []LogMessage) (processRestarts []processRestart) {
+	histories := map[string]*processHistory{}
+	for _, logMessage := range logMessages {
+		pid := getLogMessagePID(logMessage)
+		if pid == "" {
+			continue
+		}
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		process := getLogMessageProcess(logMessage)
+		history := histories[process]
+		if history == nil {
+			history = &processHistory{}
+			histories[process] = history
+		}
+		if history.pid != "" && pid != history.pid {
+			restart := processRestart{logPath: logPath, process: process, timestamp: timestamp, previousPID: history.pid, pid: pid}
+			for _, recentError := range history.recentErrors {
+				errorTime, _ := time.Parse(layout, recentError.timestamp)
+				if timestamp.Sub(errorTime) <= restartErrorWindow {
+					restart.errorsBefore += 1
+					restart.lastError = recentError.message
+				}
+			}
+			processRestarts = append(processRestarts, restart)
+			history.recentErrors = nil
+		}
+		history.pid = pid
+		if isErrorSeverity(logMessage.severity) {
+			history.recentErrors = append(history.recentErrors, logMessage)
+			// Only the errors inside the window can matter for the next restart
+			for len(history.recentErrors) > 0 {
+				oldestTime, _ := time.Parse(layout, history.recentErrors[0].timestamp)
+				if timestamp.Sub(oldestTime) <= restartErrorWindow {
+					break
+				}
+				history.recentErrors = history.recentErrors[1:]
+			}
+		}
+	}
+	return
+}
+
+func sortProcessRestarts(processRestarts []processRestart) {
+	sort.SliceStable(processRestarts, func(i, j int) bool {
+		return processRestarts[i].timestamp.Before(processRestarts[j].timestamp)
+	})
+}
+
+func formatProcessRestart(restart processRestart, options reportOptions) string {
+	line := options.formatTime(restart.timestamp, layout) + " " + restart.logPath + ": "
+	if restart.process != "" {
+		line += restart.process + " "
+	}
+	line += "pid " + restart.previousPID + " -> " + restart.pid
+	if restart.errorsBefore > 0 {
+		line += ", " + strconv.FormatInt(restart.errorsBefore, 10) + " errors in the minute before, last: " + restart.lastError
+	} else {
+		line += ", no errors before it"
+	}
+	return line
+}
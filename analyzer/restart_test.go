@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestGetProcessRestarts(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:00:00.000", severity: "INFO", message: "Started pid=100"},
+		{timestamp: "2024-01-01 00:00:10.000", severity: "ERROR", message: "Out of memory pid=100"},
+		{timestamp: "2024-01-01 00:05:00.000", severity: "ERROR", message: "Heap exhausted pid=100"},
+		{timestamp: "2024-01-01 00:05:30.000", severity: "INFO", message: "Started pid=200"},
+		{timestamp: "2024-01-01 00:06:00.000", severity: "INFO", message: "No pid on this line"},
+		{timestamp: "2024-01-01 01:00:00.000", severity: "INFO", pid: "300", message: "Started"},
+	}
+
+	restarts := getProcessRestarts("app.log", testLogs)
+	if len(restarts) != 2 {
+		t.Fatalf("Expected 2 restarts, got %+v", restarts)
+	}
+	if restarts[0].previousPID != "100" || restarts[0].pid != "200" || restarts[0].errorsBefore != 1 || restarts[0].lastError != "Heap exhausted pid=100" {
+		t.Errorf("Unexpected first restart: %+v", restarts[0])
+	}
+	if restarts[1].previousPID != "200" || restarts[1].pid != "300" || restarts[1].errorsBefore != 0 {
+		t.Errorf("Unexpected second restart: %+v", restarts[1])
+	}
+	want := "2024-01-01 00:05:30 app.log: pid 100 -> 200, 1 errors in the minute before, last: Heap exhausted pid=100"
+	if got := formatProcessRestart(restarts[0], reportOptions{}); got != want {
+		t.Errorf("formatProcessRestart() = %q, want %q", got, want)
+	}
+}
+
+func TestGetProcessRestartsFollowsEachProgram(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 00:00:00.000", severity: "INFO", module: "sshd", pid: "100", message: "Server listening"},
+		{timestamp: "2024-01-01 00:00:01.000", severity: "INFO", module: "cron", pid: "200", message: "Job started"},
+		{timestamp: "2024-01-01 00:00:02.000", severity: "ERROR", module: "sshd", pid: "100", message: "Fatal error"},
+		{timestamp: "2024-01-01 00:00:03.000", severity: "INFO", module: "cron", pid: "200", message: "Job finished"},
+		{timestamp: "2024-01-01 00:00:04.000", severity: "INFO", module: "sshd", pid: "101", message: "Server listening"},
+		{timestamp: "2024-01-01 00:00:05.000", severity: "INFO", module: "cron", pid: "200", message: "Job started"},
+	}
+
+	restarts := getProcessRestarts("syslog", testLogs)
+	if len(restarts) != 1 {
+		t.Fatalf("Expected 1 restart, got %+v", restarts)
+	}
+	if restarts[0].process != "sshd" || restarts[0].previousPID != "100" || restarts[0].pid != "101" || restarts[0].errorsBefore != 1 {
+		t.Errorf("Unexpected restart: %+v", restarts[0])
+	}
+	want := "2024-01-01 00:00:04 syslog: sshd pid 100 -> 101, 1 errors in the minute before, last: Fatal error"
+	if got := formatProcessRestart(restarts[0], reportOptions{}); got != want {
+		t.Errorf("formatProcessRestart() = %q, want %q", got, want)
+	}
+}
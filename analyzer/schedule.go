@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// timeSchedule is a recurring weekly schedule such as "Mon-Fri" between
+// "09:00-17:00". Hour ranges may wrap past midnight ("22:00-06:00").
+type timeSchedule struct {
+	days        [7]bool
+	startMinute int
+	endMinute   int
+	allDay      bool
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	// Accept both "Mon" and "Monday"
+	key := strings.ToLower(strings.TrimSpace(name))
+	if len(key) > 3 {
+		key = key[:3]
+	}
+	weekday, ok := weekdayNames[key]
+	if !ok {
+		return 0, errors.New("unknown weekday: " + name)
+	}
+	return weekday, nil
+}
+
+func parseClockMinute(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, errors.New("invalid time of day: " + clock)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func parseTimeSchedule(days string, hours string) (schedule timeSchedule, err error) {
+	if strings.TrimSpace(days) == "" {
+		for index := range schedule.days {
+			schedule.days[index] = true
+		}
+	}
+	for _, part := range strings.Split(days, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseWeekday(bounds[0])
+		if err != nil {
+			return schedule, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = parseWeekday(bounds[1]); err != nil {
+				return schedule, err
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			schedule.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	if strings.TrimSpace(hours) == "" {
+		schedule.allDay = true
+		return
+	}
+	bounds := strings.SplitN(hours, "-", 2)
+	if len(bounds) != 2 {
+		return schedule, errors.New("hours must look like 09:00-17:00, got " + hours)
+	}
+	if schedule.startMinute, err = parseClockMinute(bounds[0]); err != nil {
+		return
+	}
+	schedule.endMinute, err = parseClockMinute(bounds[1])
+	return
+}
+
+func (schedule timeSchedule) matches(moment time.Time) bool {
+	if schedule.allDay {
+		return schedule.days[moment.Weekday()]
+	}
+	minute := moment.Hour()*60 + moment.Minute()
+	if schedule.startMinute <= schedule.endMinute {
+		return schedule.days[moment.Weekday()] && minute >= schedule.startMinute && minute < schedule.endMinute
+	}
+	// A range wrapping past midnight belongs to the day it started on
+	if minute >= schedule.startMinute {
+		return schedule.days[moment.Weekday()]
+	}
+	return minute < schedule.endMinute && schedule.days[(moment.Weekday()+6)%7]
+}
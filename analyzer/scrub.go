@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// getMessageID names a message by a hash of its text, so the same message
+// gets the same ID in every file and every run without showing the text.
+func getMessageID(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return "msg-" + hex.EncodeToString(sum[:6])
+}
+
+var messageIDPattern = regexp.MustCompile(`^msg-[0-9a-f]{12}$`)
+
+// rekeyMessageCounts adds up the counts of messages that key maps to the
+// same new message.
+func rekeyMessageCounts(counts map[string]int64, key func(string) string) map[string]int64 {
+	if counts == nil {
+		return nil
+	}
+	rekeyed := make(map[string]int64, len(counts))
+	for message, count := range counts {
+		rekeyed[key(message)] += count
+	}
+	return rekeyed
+}
+
+// scrubLogAnalysis replaces all text taken from messages and lines with
+// message IDs for -aggregates-only: top messages and their trends, the
+// messages per thread, module and window, the errors before restarts and
+// unclean shutdowns, query fingerprints, request paths, malformed lines
+// and the -baseline top messages. With -message-key the IDs name keys,
+// and their raw examples are dropped. Counts, severities, times, modules,
+// threads, versions and client IPs stay as they are. The unlabeled entries
+// -infer-severity predicts from keep their text, since only their
+// predicted severities are reported.
+func scrubLogAnalysis(logAnalysis *LogAnalysis) {
+	for index, message := range logAnalysis.topFiveLogMessages {
+		if message != "" {
+			logAnalysis.topFiveLogMessages[index] = getMessageID(message)
+		}
+	}
+	logAnalysis.messageFrequencies = rekeyMessageCounts(logAnalysis.messageFrequencies, getMessageID)
+	logAnalysis.messageExamples = nil
+	logAnalysis.messageExampleCounts = nil
+	if logAnalysis.messageBucketCounts != nil {
+		messageBucketCounts := make(map[messageBucketKey]int64, len(logAnalysis.messageBucketCounts))
+		for key, count := range logAnalysis.messageBucketCounts {
+			messageBucketCounts[messageBucketKey{message: getMessageID(key.message), start: key.start}] += count
+		}
+		logAnalysis.messageBucketCounts = messageBucketCounts
+	}
+	if logAnalysis.messageTraceIDs != nil {
+		messageTraceIDs := make(map[string][]string, len(logAnalysis.messageTraceIDs))
+		for message, traceIDs := range logAnalysis.messageTraceIDs {
+			messageTraceIDs[getMessageID(message)] = traceIDs
+		}
+		logAnalysis.messageTraceIDs = messageTraceIDs
+	}
+	for thread, count := range logAnalysis.threadCounts {
+		count.errorMessages = rekeyMessageCounts(count.errorMessages, getMessageID)
+		logAnalysis.threadCounts[thread] = count
+	}
+	for module, stats := range logAnalysis.moduleStats {
+		stats.messageFrequencies = rekeyMessageCounts(stats.messageFrequencies, getMessageID)
+		logAnalysis.moduleStats[module] = stats
+	}
+	for name, stats := range logAnalysis.windowStats {
+		stats.messageFrequencies = rekeyMessageCounts(stats.messageFrequencies, getMessageID)
+		logAnalysis.windowStats[name] = stats
+	}
+	if logAnalysis.queryProfile.queries != nil {
+		queries := make(map[string]queryStats, len(logAnalysis.queryProfile.queries))
+		for fingerprint, stats := range logAnalysis.queryProfile.queries {
+			queries[getMessageID(fingerprint)] = stats
+		}
+		logAnalysis.queryProfile.queries = queries
+	}
+	logAnalysis.httpProfile.pathCounts = rekeyMessageCounts(logAnalysis.httpProfile.pathCounts, getMessageID)
+	for index := range logAnalysis.processRestarts {
+		if lastError := logAnalysis.processRestarts[index].lastError; lastError != "" {
+			logAnalysis.processRestarts[index].lastError = getMessageID(lastError)
+		}
+	}
+	for index := range logAnalysis.bootSessions {
+		for messageIndex, line := range logAnalysis.bootSessions[index].finalMessages {
+			// "timestamp severity module: message", as getBootSessions writes it
+			prefix, message, _ := strings.Cut(line, ": ")
+			logAnalysis.bootSessions[index].finalMessages[messageIndex] = prefix + ": " + getMessageID(message)
+		}
+	}
+	if baseline := logAnalysis.topMessageBaseline; baseline != nil {
+		// A baseline written without -aggregates-only is scrubbed the same way,
+		// so it stays comparable and its text stays out of the report
+		scrubbed := topMessageBaseline{path: baseline.path}
+		for _, message := range baseline.messages {
+			if !messageIDPattern.MatchString(message) {
+				message = getMessageID(message)
+			}
+			scrubbed.messages = append(scrubbed.messages, message)
+		}
+		logAnalysis.topMessageBaseline = &scrubbed
+	}
+	for index := range logAnalysis.badLines {
+		logAnalysis.badLines[index].text = getMessageID(logAnalysis.badLines[index].text)
+	}
+}
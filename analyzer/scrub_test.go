@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScrubLogAnalysis(t *testing.T) {
+	options := analysisOptions{
+		parser:         parseAccessMessage,
+		httpProfile:    true,
+		groupByModule:  true,
+		badLineSamples: 5,
+		aggregatesOnly: true,
+	}
+	inputs := []string{
+		`203.0.113.9 - - [02/Jan/2024:15:04:05 +0000] "GET /accounts/alice HTTP/1.1" 200 10 "-" "curl/8.0"` + "\n" +
+			"not a request from bob\n",
+		`198.51.100.7 - - [02/Jan/2024:15:04:06 +0000] "GET /accounts/alice HTTP/1.1" 500 10 "-" "curl/8.0"` + "\n",
+	}
+	var logAnalyses []LogAnalysis
+	for _, input := range inputs {
+		logMessages, unlabeledMessages, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, options)
+		logAnalyses = append(logAnalyses, newLogAnalysis(stdinPath, logMessages, unlabeledMessages, stats, options))
+	}
+	logAnalysis := analyzelogAnalyses(logAnalyses)
+	var report bytes.Buffer
+	if err := writeLogAnalysisJSON(&report, logAnalysis, logAnalyses, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"alice", "bob", "GET /"} {
+		if strings.Contains(report.String(), text) {
+			t.Errorf("Expected %q to be scrubbed from\n%s", text, report.String())
+		}
+	}
+	// Both files name the same path by the same ID, so their counts merge
+	id := getMessageID("GET /accounts/alice 200")
+	if logAnalysis.messageFrequencies[id] != 1 || logAnalysis.httpProfile.pathCounts[getMessageID("/accounts/alice")] != 2 {
+		t.Errorf("Unexpected scrubbed counts %v and %v", logAnalysis.messageFrequencies, logAnalysis.httpProfile.pathCounts)
+	}
+	if logAnalysis.numEntries != 2 || logAnalysis.logSeverityFrequency["ERROR"] != 1 {
+		t.Errorf("Expected the aggregates to be kept, got %d entries and %+v", logAnalysis.numEntries, logAnalysis.logSeverityFrequency)
+	}
+}
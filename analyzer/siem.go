@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timestamps in SIEM exports: CEF's rt and LEEF's devTime are epoch
+// milliseconds or one of these layouts.
+var siemTimestampLayouts = []string{
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05",
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05 MST",
+	time.RFC3339Nano,
+}
+
+// The syslog header a forwarder puts before CEF: or LEEF:, if any.
+var siemSyslogTimestampPattern = regexp.MustCompile(`^(?:<\d+>\d* ?)?(\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) `)
+
+var cefExtensionKeyPattern = regexp.MustCompile(`(?:^|\s)([\w.\[\]-]+)=`)
+
+var cefExtensionUnescaper = strings.NewReplacer(`\=`, `=`, `\\`, `\`, `\n`, "\n", `\r`, "\r")
+
+// splitSIEMHeader splits on unescaped pipes, at most n fields, the last
+// holding the rest of the line, and unescapes \| and \\ in the others.
+func splitSIEMHeader(header string, n int) (fields []string) {
+	var field strings.Builder
+	for index := 0; index < len(header); index++ {
+		if len(fields) == n-1 {
+			return append(fields, header[index:])
+		}
+		switch {
+		case header[index] == '\\' && index+1 < len(header) && (header[index+1] == '|' || header[index+1] == '\\'):
+			index++
+			field.WriteByte(header[index])
+		case header[index] == '|':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(header[index])
+		}
+	}
+	return append(fields, field.String())
+}
+
+// parseCEFExtension reads space separated key=value pairs where values may
+// themselves contain spaces, so each value runs up to the next key.
+func parseCEFExtension(extension string) (attributes map[string]string) {
+	attributes = make(map[string]string)
+	keys := cefExtensionKeyPattern.FindAllStringSubmatchIndex(extension, -1)
+	for index, match := range keys {
+		end := len(extension)
+		if index+1 < len(keys) {
+			end = keys[index+1][0]
+		}
+		key := extension[match[2]:match[3]]
+		attributes[key] = cefExtensionUnescaper.Replace(strings.TrimSpace(extension[match[1]:end]))
+	}
+	return
+}
+
+// getSIEMSeverity maps the 0-10 scale of CEF and LEEF, or CEF's Low, Medium,
+// High and Very-High, onto INFO, WARNING and ERROR.
+func getSIEMSeverity(value string) string {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "LOW", "UNKNOWN", "":
+		return "INFO"
+	case "MEDIUM":
+		return "WARNING"
+	case "HIGH", "VERY-HIGH":
+		return "ERROR"
+	}
+	severity, err := strconv.Atoi(strings.TrimSpace(value))
+	switch {
+	case err != nil:
+		return normalizeSeverity(value)
+	case severity >= 7:
+		return "ERROR"
+	case severity >= 4:
+		return "WARNING"
+	default:
+		return "INFO"
+	}
+}
+
+// getSIEMTimestamp prefers the event time attribute and falls back to the
+// syslog header the event was forwarded with.
+func getSIEMTimestamp(eventTime string, prefix string) (string, error) {
+	if eventTime != "" {
+		if milliseconds, err := strconv.ParseInt(eventTime, 10, 64); err == nil {
+			return time.UnixMilli(milliseconds).UTC().Format(layout), nil
+		}
+		return normalizeTimestamp(eventTime, siemTimestampLayouts...)
+	}
+	if match := siemSyslogTimestampPattern.FindStringSubmatch(prefix); match != nil {
+		return normalizeSyslogTimestamp(match[1], time.Now().UTC())
+	}
+	return "", errBadTimestamp
+}
+
+// parseCEFMessage reads ArcSight CEF:
+// CEF:0|Vendor|Product|Version|SignatureID|Name|Severity|key=value ...
+// The product is the module, the signature ID the function and the name the
+// message; extension keys become attributes, with src as the client IP.
+func parseCEFMessage(logRow string) (logMessage LogMessage, err error) {
+	start := strings.Index(logRow, "CEF:")
+	if start < 0 {
+		return logMessage, errMissingDelimiter
+	}
+	fields := splitSIEMHeader(logRow[start:], 8)
+	if len(fields) < 7 {
+		return logMessage, errMissingDelimiter
+	}
+	if len(fields) == 8 {
+		logMessage.attributes = parseCEFExtension(fields[7])
+	}
+	logMessage.timestamp, err = getSIEMTimestamp(logMessage.attributes["rt"], logRow[:start])
+	if err != nil {
+		return
+	}
+	logMessage.module = fields[2]
+	logMessage.function = fields[4]
+	logMessage.message = fields[5]
+	logMessage.severity = getSIEMSeverity(fields[6])
+	logMessage.clientIP = logMessage.attributes["src"]
+	return
+}
+
+// getLEEFDelimiter reads LEEF 2.0's delimiter field, a character or its hex
+// code such as x09 or 0x09.
+func getLEEFDelimiter(value string) string {
+	lower := strings.ToLower(value)
+	for _, prefix := range []string{"0x", "x"} {
+		if len(lower) > len(prefix) && strings.HasPrefix(lower, prefix) {
+			if code, err := strconv.ParseUint(lower[len(prefix):], 16, 8); err == nil {
+				return string(rune(code))
+			}
+		}
+	}
+	if value == "" {
+		return "\t"
+	}
+	return value
+}
+
+// parseLEEFMessage reads IBM QRadar LEEF 1.0, whose attributes are tab
+// separated, and LEEF 2.0, which names its delimiter in the header:
+// LEEF:2.0|Vendor|Product|Version|EventID|^|key=value^key=value
+// The product is the module and the event ID the function and message;
+// sev gives the severity and src the client IP.
+func parseLEEFMessage(logRow string) (logMessage LogMessage, err error) {
+	start := strings.Index(logRow, "LEEF:")
+	if start < 0 {
+		return logMessage, errMissingDelimiter
+	}
+	fields := splitSIEMHeader(logRow[start:], 6)
+	if len(fields) < 5 {
+		return logMessage, errMissingDelimiter
+	}
+	delimiter := "\t"
+	attributes := ""
+	if len(fields) == 6 {
+		attributes = fields[5]
+		if strings.HasPrefix(fields[0], "LEEF:2") {
+			var delimiterField string
+			delimiterField, attributes, _ = strings.Cut(attributes, "|")
+			delimiter = getLEEFDelimiter(delimiterField)
+		}
+	}
+	logMessage.attributes = make(map[string]string)
+	for _, pair := range strings.Split(attributes, delimiter) {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			logMessage.attributes[strings.TrimSpace(key)] = value
+		}
+	}
+	logMessage.timestamp, err = getSIEMTimestamp(logMessage.attributes["devTime"], logRow[:start])
+	if err != nil {
+		return
+	}
+	logMessage.module = fields[2]
+	logMessage.function = fields[4]
+	logMessage.message = fields[4]
+	logMessage.severity = getSIEMSeverity(logMessage.attributes["sev"])
+	logMessage.clientIP = logMessage.attributes["src"]
+	return
+}
@@ -0,0 +1,38 @@
+package analyzer
+
+import (
+	"unicode/utf8"
+)
+
+const sniffLength = 8000
+
+// isBinaryContent looks at the start of a file the way diff and grep do: a
+// NUL byte, or a sizeable share of control characters or invalid UTF-8, means
+// the file is not a text log.
+func isBinaryContent(data []byte) bool {
+	if len(data) > sniffLength {
+		data = data[:sniffLength]
+	}
+	if len(data) == 0 {
+		return false
+	}
+	suspicious := 0
+	total := 0
+	for len(data) > 0 {
+		character, size := utf8.DecodeRune(data)
+		total += 1
+		switch {
+		case character == 0:
+			return true
+		case character == utf8.RuneError && size == 1:
+			// A multi-byte character cut off by the sniff window is fine
+			if len(data) >= utf8.UTFMax {
+				suspicious += 1
+			}
+		case character < 0x20 && character != '\t' && character != '\n' && character != '\r' && character != '\f' && character != 0x1b:
+			suspicious += 1
+		}
+		data = data[size:]
+	}
+	return suspicious*10 > total
+}
@@ -0,0 +1,27 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestIsBinaryContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{name: "log text", content: []byte("2024-01-01 00:00:00.000 | INFO | app: f: 1 - Café opened\n"), want: false},
+		{name: "ansi colored text", content: []byte("\x1b[31mERROR\x1b[0m something\n"), want: false},
+		{name: "nul bytes", content: []byte("core\x00\x00\x01dump"), want: true},
+		{name: "pcap header", content: []byte{0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00, 0x01, 0x02, 0x03, 0x04}, want: true},
+		{name: "empty", content: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinaryContent(tt.content); got != tt.want {
+				t.Errorf("isBinaryContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
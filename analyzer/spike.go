@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// Intervals with fewer errors are never spikes, however high their rate.
+	minSpikeErrors   = 5
+	maxSpikeMessages = 3
+)
+
+// spikeOptions configures error spike detection over interval wide buckets.
+// An interval is a spike when its error rate is above maxErrorRate, or above
+// baselineFactor times the rate over the baselineBuckets intervals before it.
+// A zero threshold or factor disables that test.
+type spikeOptions struct {
+	interval        time.Duration
+	maxErrorRate    float64
+	baselineFactor  float64
+	baselineBuckets int
+}
+
+type errorSpike struct {
+	start             time.Time
+	entries           int64
+	errors            int64
+	baselineErrorRate float64
+	// Set when the interval had no baseline to compare against
+	noBaseline         bool
+	messageFrequencies map[string]int64
+}
+
+func getErrorRate(errors int64, entries int64) float64 {
+	if entries == 0 {
+		return 0
+	}
+	return float64(errors) / float64(entries)
+}
+
+// getErrorSpikes flags spiking intervals and collects the messages logged in
+// each, from the per-minute message counts.
+func getErrorSpikes(timeBucketCounts map[timeBucketKey]int64, messageBucketCounts map[messageBucketKey]int64, options spikeOptions) (spikes []errorSpike) {
+	buckets := getHistogramBuckets(timeBucketCounts, options.interval)
+	for index, bucket := range buckets {
+		errors := bucket.severityFrequency.errors()
+		if errors < minSpikeErrors {
+			continue
+		}
+		rate := getErrorRate(errors, bucket.entries)
+		spike := errorSpike{start: bucket.start, entries: bucket.entries, errors: errors}
+		var baselineEntries, baselineErrors int64
+		for previous := index - 1; previous >= 0 && previous >= index-options.baselineBuckets; previous-- {
+			baselineEntries += buckets[previous].entries
+			baselineErrors += buckets[previous].severityFrequency.errors()
+		}
+		spike.baselineErrorRate = getErrorRate(baselineErrors, baselineEntries)
+		spike.noBaseline = baselineEntries == 0
+		aboveThreshold := options.maxErrorRate > 0 && rate > options.maxErrorRate
+		aboveBaseline := options.baselineFactor > 0 && !spike.noBaseline && rate > options.baselineFactor*spike.baselineErrorRate
+		if aboveThreshold || aboveBaseline {
+			spikes = append(spikes, spike)
+		}
+	}
+	if len(spikes) == 0 {
+		return
+	}
+
+	interval := options.interval
+	if interval < bucketResolution {
+		interval = bucketResolution
+	}
+	spikeIndexes := make(map[time.Time]int, len(spikes))
+	for index, spike := range spikes {
+		spikeIndexes[spike.start] = index
+	}
+	for key, count := range messageBucketCounts {
+		index, ok := spikeIndexes[key.start.Truncate(interval)]
+		if !ok {
+			continue
+		}
+		if spikes[index].messageFrequencies == nil {
+			spikes[index].messageFrequencies = make(map[string]int64)
+		}
+		spikes[index].messageFrequencies[key.message] += count
+	}
+	return
+}
+
+func getTopSpikeMessages(spike errorSpike) (messages []string) {
+	messages = rankLogMessages(spike.messageFrequencies)
+	if len(messages) > maxSpikeMessages {
+		messages = messages[:maxSpikeMessages]
+	}
+	return
+}
+
+func formatErrorSpike(spike errorSpike, options reportOptions) (lines []string) {
+	line := options.formatTime(spike.start, histogramLabelLayout) + ": " + humanizeCount(spike.errors, options) + " errors in " +
+		humanizeCount(spike.entries, options) + " entries (" + formatPercent(getErrorRate(spike.errors, spike.entries))
+	if spike.noBaseline {
+		line += ", no baseline)"
+	} else {
+		line += ", baseline " + formatPercent(spike.baselineErrorRate) + ")"
+	}
+	lines = append(lines, line)
+	for _, message := range getTopSpikeMessages(spike) {
+		lines = append(lines, "   "+strconv.FormatInt(spike.messageFrequencies[message], 10)+"x "+message)
+	}
+	return
+}
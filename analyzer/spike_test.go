@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetErrorSpikes(t *testing.T) {
+	var testLogs []LogMessage
+	// A steady 10% error rate for half an hour, then 6 of 10 entries fail
+	for minute := 0; minute < 30; minute++ {
+		severity := "INFO"
+		if minute%10 == 0 {
+			severity = "ERROR"
+		}
+		timestamp := time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC).Format(layout)
+		testLogs = append(testLogs, LogMessage{timestamp: timestamp, severity: severity, message: "Steady"})
+	}
+	for index := 0; index < 10; index++ {
+		logMessage := LogMessage{timestamp: "2024-01-01 00:31:00.000", severity: "INFO", message: "Request"}
+		if index < 6 {
+			logMessage.severity = "ERROR"
+			logMessage.message = "Database timeout"
+		}
+		testLogs = append(testLogs, logMessage)
+	}
+	timeBucketCounts := getTimeBucketCounts(testLogs)
+	messageBucketCounts := getMessageBucketCounts(testLogs)
+
+	spikes := getErrorSpikes(timeBucketCounts, messageBucketCounts, spikeOptions{interval: 10 * time.Minute, baselineFactor: 3, baselineBuckets: 3})
+	if len(spikes) != 1 || !spikes[0].start.Equal(time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC)) || spikes[0].errors != 6 {
+		t.Fatalf("Expected one spike at 00:30 with 6 errors, got %+v", spikes)
+	}
+	if spikes[0].baselineErrorRate != 0.1 {
+		t.Errorf("Expected a 10%% baseline, got %v", spikes[0].baselineErrorRate)
+	}
+	if got, want := getTopSpikeMessages(spikes[0]), []string{"Database timeout", "Request"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getTopSpikeMessages() = %v, want %v", got, want)
+	}
+
+	// A fixed threshold above the spike's 6 of 10 flags nothing
+	if spikes := getErrorSpikes(timeBucketCounts, messageBucketCounts, spikeOptions{interval: 10 * time.Minute, maxErrorRate: 0.6}); len(spikes) != 0 {
+		t.Errorf("Expected no spikes above a 60%% threshold, got %+v", spikes)
+	}
+}
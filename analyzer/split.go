@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// defaultRangeBytes is the least size of the ranges a large file is split
+// into. Smaller ranges would spend more on merging than they gain.
+const defaultRangeBytes = 64 << 20
+
+// rangeSlots bounds the ranges parsed at once across all files, so large
+// files split while others are analyzed don't hold a chunk of entries per
+// range per file.
+var rangeSlots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// fileRange is the part of a file from byte start up to end. firstLine and
+// endLine are the lines it spans, counting from 0, when it was cut at the
+// blocks of a line index.
+type fileRange struct {
+	start     int64
+	end       int64
+	firstLine int64
+	endLine   int64
+}
+
+// canSplitFile reports whether a file's ranges can be counted apart and
+// merged. Besides what needs the whole file, versions are carried from one
+// entry to the next, and the w3c and mysql-slow parsers carry state from
+// one line to the next.
+func (options analysisOptions) canSplitFile() bool {
+	return !options.needsWholeFile() && options.versionPattern == nil && options.newParser == nil
+}
+
+// getFileRanges splits size bytes of logFile into up to parts ranges that
+// each start a line, or with -multiline a line that starts an entry, so
+// every entry is read whole by one range. With a line index and no
+// -multiline, the ranges start at its blocks, which -line-filter skips by.
+// A file without enough boundaries gets fewer ranges.
+func getFileRanges(logFile io.ReaderAt, size int64, parts int64, options analysisOptions) (ranges []fileRange, err error) {
+	if options.lineIndex != nil && options.entryStartPattern == nil {
+		return getIndexRanges(options.lineIndex, parts), nil
+	}
+	var start int64
+	for part := int64(1); part <= parts; part++ {
+		end, limit := size, size
+		if part < parts {
+			end, limit = part*size/parts, (part+1)*size/parts
+		}
+		if end < size {
+			var found bool
+			if end, found, err = findRangeStart(logFile, max(end, start+1), limit, options.entryStartPattern); err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+		}
+		ranges = append(ranges, fileRange{start: start, end: end})
+		start = end
+	}
+	return
+}
+
+// findRangeStart returns the offset of the first line from offset up to
+// limit that starts a line, and an entry when entryStartPattern is set.
+func findRangeStart(logFile io.ReaderAt, offset int64, limit int64, entryStartPattern *regexp.Regexp) (int64, bool, error) {
+	// Reading from the byte before offset finds offset itself when a line
+	// ends there
+	offset -= 1
+	reader := bufio.NewReaderSize(io.NewSectionReader(logFile, offset, limit-offset), streamBufferSize)
+	partialRow, err := reader.ReadString('\n')
+	offset += int64(len(partialRow))
+	for err == nil && offset < limit {
+		if entryStartPattern == nil {
+			return offset, true, nil
+		}
+		var logRow string
+		logRow, err = reader.ReadString('\n')
+		if err == nil && strings.TrimSpace(logRow) != "" && !isContinuationLine(strings.TrimRight(logRow, "\r\n"), entryStartPattern) {
+			return offset, true, nil
+		}
+		offset += int64(len(logRow))
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return 0, false, err
+}
+
+func getIndexRanges(index *lineIndex, parts int64) (ranges []fileRange) {
+	var firstLine int64
+	for part := int64(1); part <= parts; part++ {
+		endLine := index.lines
+		if part < parts {
+			endLine = part * index.blocks() / parts * index.blockLines
+		}
+		if endLine <= firstLine {
+			continue
+		}
+		ranges = append(ranges, fileRange{start: index.lineOffset(firstLine), end: index.lineOffset(endLine), firstLine: firstLine, endLine: endLine})
+		firstLine = endLine
+	}
+	return
+}
+
+// analyzeFileRanges splits a large log file into ranges, up to one per
+// core, and counts them concurrently into one analysis. It returns false,
+// having read nothing, when the file is too small to be worth splitting or
+// options need it read in one pass.
+func analyzeFileRanges(ctx context.Context, logFile *os.File, logPath string, options analysisOptions) (logAnalysis LogAnalysis, split bool) {
+	if !options.canSplitFile() {
+		return
+	}
+	fileInfo, err := logFile.Stat()
+	if err != nil || !fileInfo.Mode().IsRegular() {
+		return
+	}
+	rangeBytes := options.rangeBytes
+	if rangeBytes <= 0 {
+		rangeBytes = defaultRangeBytes
+	}
+	parts := min(int64(cap(rangeSlots)), fileInfo.Size()/rangeBytes)
+	if parts < 2 {
+		return
+	}
+	head := make([]byte, sniffLength)
+	headLength, _ := logFile.ReadAt(head, 0)
+	if isBinaryContent(head[:headLength]) {
+		return
+	}
+	ranges, err := getFileRanges(logFile, fileInfo.Size(), parts, options)
+	if err != nil || len(ranges) < 2 {
+		return
+	}
+	return countFileRanges(ctx, logFile, logPath, ranges, options), true
+}
+
+// countFileRanges counts the ranges of logFile concurrently and merges them
+// in file order, as if the file had been read in one pass.
+func countFileRanges(ctx context.Context, logFile io.ReaderAt, logPath string, ranges []fileRange, options analysisOptions) (logAnalysis LogAnalysis) {
+	metrics.counter("files_split").Add(1)
+	logAnalyses := make([]LogAnalysis, len(ranges))
+	rangeStats := make([]parseStats, len(ranges))
+	qualities := make([]qualityTracker, len(ranges))
+	var rangesDone sync.WaitGroup
+	for index := range ranges {
+		rangesDone.Add(1)
+		go func(index int) {
+			defer rangesDone.Done()
+			select {
+			case rangeSlots <- struct{}{}:
+				defer func() { <-rangeSlots }()
+			case <-ctx.Done():
+				rangeStats[index].interrupted = true
+				return
+			}
+			rangeOptions := options
+			rangeOptions.fileRange = &ranges[index]
+			reader := io.NewSectionReader(logFile, ranges[index].start, ranges[index].end-ranges[index].start)
+			logAnalyses[index], _, rangeStats[index], qualities[index] = countLogReader(ctx, reader, logPath, rangeOptions)
+		}(index)
+	}
+	rangesDone.Wait()
+
+	logAnalysis = newMergedLogAnalysis()
+	var stats parseStats
+	quality := qualities[0]
+	for index := range ranges {
+		mergeLogAnalysis(&logAnalysis, logAnalyses[index])
+		mergeParseStats(&stats, rangeStats[index], options.badLineSamples)
+		if index > 0 {
+			quality.merge(&qualities[index])
+		}
+	}
+	finishLogAnalysis(&logAnalysis, logPath, stats, &quality, options)
+	return
+}
+
+// mergeParseStats adds the stats of a range to those of the ranges before
+// it, numbering its bad lines and read error from the start of the file.
+func mergeParseStats(into *parseStats, from parseStats, badLineSamples int) {
+	for _, line := range from.badLines {
+		if len(into.badLines) >= badLineSamples {
+			break
+		}
+		line.lineNumber += into.rawLines
+		into.badLines = append(into.badLines, line)
+	}
+	if into.readErr == nil && from.readErr != nil {
+		readErr := *from.readErr
+		readErr.Line += into.rawLines
+		into.readErr = &readErr
+	}
+	for reason, count := range from.parseErrorCounts {
+		if into.parseErrorCounts == nil {
+			into.parseErrorCounts = make(map[string]int64)
+		}
+		into.parseErrorCounts[reason] += count
+	}
+	into.bytesRead += from.bytesRead
+	into.lines += from.lines
+	into.rawLines += from.rawLines
+	into.malformedLines += from.malformedLines
+	into.filteredLines += from.filteredLines
+	into.interrupted = into.interrupted || from.interrupted
+}
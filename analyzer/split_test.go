@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getSplitTestInput returns entries with stack traces, malformed and blank
+// lines, and timestamps that repeat and go backwards, so every range
+// boundary has something to get wrong.
+func getSplitTestInput() string {
+	var input strings.Builder
+	for entry := 0; entry < 40; entry++ {
+		second := entry
+		if entry%7 == 3 {
+			second = entry - 2
+		}
+		timestamp := "2024-01-01 08:00:" + strconv.Itoa(10+second) + ".000"
+		switch entry % 5 {
+		case 0:
+			input.WriteString(timestamp + " | ERROR | db:query:12 - Timeout after " + strconv.Itoa(entry%3) + "s\n")
+			input.WriteString("  at db.query(db.go:12)\n  at api.serve(api.go:10)\n")
+		case 1:
+			input.WriteString(timestamp + " | INFO | api:serve:11 - Request from 10.0.0." + strconv.Itoa(entry%4) + " [thread-1]\n")
+		case 2:
+			input.WriteString(timestamp + " garbage " + strconv.Itoa(entry) + "\n\n")
+		default:
+			input.WriteString(timestamp + " | WARNING | db:query:12 - Slow query\n")
+		}
+	}
+	return input.String()
+}
+
+func writeSplitTestLog(t *testing.T, input string) (*os.File, int64) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(logPath, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logFile.Close() })
+	return logFile, int64(len(input))
+}
+
+func TestGetFileRanges(t *testing.T) {
+	input := getSplitTestInput()
+	logFile, size := writeSplitTestLog(t, input)
+	for _, options := range []analysisOptions{{}, {entryStartPattern: defaultEntryStartPattern}} {
+		ranges, err := getFileRanges(logFile, size, 6, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranges) < 2 || ranges[0].start != 0 || ranges[len(ranges)-1].end != size {
+			t.Fatalf("getFileRanges() = %+v, want ranges over all %d bytes", ranges, size)
+		}
+		for index, fileRange := range ranges[1:] {
+			if fileRange.start != ranges[index].end {
+				t.Errorf("range %+v doesn't follow %+v", fileRange, ranges[index])
+			}
+			if input[fileRange.start-1] != '\n' {
+				t.Errorf("range %+v starts within a line", fileRange)
+			}
+			if options.entryStartPattern != nil && strings.HasPrefix(input[fileRange.start:], "  at") {
+				t.Errorf("range %+v starts within a stack trace", fileRange)
+			}
+		}
+	}
+}
+
+func TestCountFileRangesMatchesWholeFile(t *testing.T) {
+	input := getSplitTestInput()
+	logFile, size := writeSplitTestLog(t, input)
+	options := analysisOptions{
+		histogramBucket:   time.Hour,
+		threadPattern:     defaultThreadPattern,
+		groupByModule:     true,
+		groupByClientIP:   true,
+		entryStartPattern: defaultEntryStartPattern,
+		badLineSamples:    20,
+		inferSeverity:     true,
+		messageKeyer:      templateMessageKeyer{},
+	}
+	report := func(logAnalysis LogAnalysis) string {
+		var output bytes.Buffer
+		if err := writeLogAnalysisJSON(&output, analyzelogAnalyses([]LogAnalysis{logAnalysis}), nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		return output.String()
+	}
+
+	want := report(analyzeLogReader(context.Background(), strings.NewReader(input), "app.log", options))
+	for _, parts := range []int64{2, 3, 7} {
+		ranges, err := getFileRanges(logFile, size, parts, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := report(countFileRanges(context.Background(), logFile, "app.log", ranges, options)); got != want {
+			t.Errorf("countFileRanges() in %d ranges = %s, want %s", len(ranges), got, want)
+		}
+	}
+}
+
+func TestCountFileRangesSkipsIndexBlocks(t *testing.T) {
+	logPath := writeIndexedLog(t, getIndexTestLines(), 2)
+	index, err := loadLineIndex(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.close()
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+	filter, _ := parseLineFilter("Timeout")
+	options := analysisOptions{lineFilter: filter, lineIndex: index}
+
+	ranges, err := getFileRanges(logFile, index.lineOffset(index.lines), 3, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 3 || ranges[1].firstLine != 2 || ranges[2].firstLine != 6 || ranges[2].endLine != 10 {
+		t.Fatalf("getFileRanges() = %+v, want ranges starting at lines 0, 2 and 6", ranges)
+	}
+	logAnalysis := countFileRanges(context.Background(), logFile, logPath, ranges, options)
+	if logAnalysis.numEntries != 1 || logAnalysis.lines != 10 {
+		t.Errorf("countFileRanges() counted %d entries of %d lines, want 1 of 10", logAnalysis.numEntries, logAnalysis.lines)
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// topMessageBaseline is the top message list of a previous -format json
+// report, given with -baseline.
+type topMessageBaseline struct {
+	path     string
+	messages []string
+}
+
+type topMessageStability struct {
+	score float64
+	// shared counts the messages in both lists; entered lists the current
+	// ones missing from the baseline, and left the baseline ones missing now
+	shared  int
+	entered []string
+	left    []string
+}
+
+// loadTopMessageBaseline reads the top messages of a JSON report, which is
+// decrypted first when it was written with -encrypt-key and key is set.
+func loadTopMessageBaseline(baselinePath string, key []byte) (baseline topMessageBaseline, err error) {
+	data, err := readInputFile(baselinePath, key)
+	if err != nil {
+		return
+	}
+	var report Analysis
+	if err = json.Unmarshal(data, &report); err != nil {
+		return baseline, errors.New("baseline " + baselinePath + " is not a JSON report: " + err.Error())
+	}
+	baseline.path = baselinePath
+	baseline.messages = []string{}
+	for _, topMessage := range report.TopMessages {
+		baseline.messages = append(baseline.messages, topMessage.Message)
+	}
+	return
+}
+
+// getRankPositions ranks each message by its place in messages, starting
+// at 1, and those missing from it just after the last.
+func getRankPositions(messages []string, union []string) (ranks []float64) {
+	for _, message := range union {
+		rank := slices.Index(messages, message)
+		if rank < 0 {
+			rank = len(messages)
+		}
+		ranks = append(ranks, float64(rank+1))
+	}
+	return
+}
+
+// getTopMessageStability compares the current top messages with the
+// baseline's by Spearman's rank correlation over the messages in either
+// list: 1 when they are in the same order, around 0 when unrelated and -1
+// when reversed. ok is false when either list is empty.
+func getTopMessageStability(current []string, baseline []string) (stability topMessageStability, ok bool) {
+	current = slices.DeleteFunc(slices.Clone(current), func(message string) bool { return message == "" })
+	if len(current) == 0 || len(baseline) == 0 {
+		return stability, false
+	}
+	union := slices.Clone(current)
+	for _, message := range baseline {
+		if slices.Contains(current, message) {
+			stability.shared += 1
+		} else {
+			union = append(union, message)
+			stability.left = append(stability.left, message)
+		}
+	}
+	for _, message := range current {
+		if !slices.Contains(baseline, message) {
+			stability.entered = append(stability.entered, message)
+		}
+	}
+	currentRanks, baselineRanks := getRankPositions(current, union), getRankPositions(baseline, union)
+	var currentMean, baselineMean float64
+	for index := range union {
+		currentMean += currentRanks[index] / float64(len(union))
+		baselineMean += baselineRanks[index] / float64(len(union))
+	}
+	var covariance, currentVariance, baselineVariance float64
+	for index := range union {
+		covariance += (currentRanks[index] - currentMean) * (baselineRanks[index] - baselineMean)
+		currentVariance += (currentRanks[index] - currentMean) * (currentRanks[index] - currentMean)
+		baselineVariance += (baselineRanks[index] - baselineMean) * (baselineRanks[index] - baselineMean)
+	}
+	if currentVariance == 0 || baselineVariance == 0 {
+		// A single message in both lists: the same one, or not
+		if stability.shared == len(union) {
+			stability.score = 1
+		}
+		return stability, true
+	}
+	stability.score = covariance / math.Sqrt(currentVariance*baselineVariance)
+	return stability, true
+}
+
+func formatTopMessageStability(stability topMessageStability, baseline topMessageBaseline) (lines []string) {
+	lines = append(lines, "score "+strconv.FormatFloat(stability.score, 'f', 2, 64)+" against "+baseline.path+", "+
+		strconv.Itoa(stability.shared)+" of "+strconv.Itoa(len(baseline.messages))+" baseline top messages still in the top")
+	for _, message := range stability.entered {
+		lines = append(lines, "new: "+message)
+	}
+	for _, message := range stability.left {
+		lines = append(lines, "gone: "+message)
+	}
+	return
+}
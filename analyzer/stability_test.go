@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGetTopMessageStability(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  []string
+		baseline []string
+		want     float64
+	}{
+		{"same order", []string{"a", "b", "c", "d", "e"}, []string{"a", "b", "c", "d", "e"}, 1},
+		{"reversed", []string{"e", "d", "c", "b", "a"}, []string{"a", "b", "c", "d", "e"}, -1},
+		{"padded", []string{"a", "b", "", "", ""}, []string{"a", "b"}, 1},
+		{"single", []string{"a", "", "", "", ""}, []string{"a"}, 1},
+		{"single replaced", []string{"b", "", "", "", ""}, []string{"a"}, -1},
+	}
+	for _, tt := range tests {
+		stability, ok := getTopMessageStability(tt.current, tt.baseline)
+		if !ok || stability.score != tt.want {
+			t.Errorf("%s: score = %v (%v), want %v", tt.name, stability.score, ok, tt.want)
+		}
+	}
+
+	stability, _ := getTopMessageStability([]string{"a", "b", "x", "c", "d"}, []string{"a", "b", "c", "d", "e"})
+	if stability.shared != 4 || !slices.Equal(stability.entered, []string{"x"}) || !slices.Equal(stability.left, []string{"e"}) {
+		t.Errorf("Expected 4 shared, x new and e gone, got %+v", stability)
+	}
+	if stability.score <= 0.5 || stability.score >= 1 {
+		t.Errorf("Expected one swap to lower the score a little, got %v", stability.score)
+	}
+	if _, ok := getTopMessageStability([]string{"", "", "", "", ""}, []string{"a"}); ok {
+		t.Errorf("Expected no score without current top messages")
+	}
+}
+
+func TestLoadTopMessageBaseline(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00.000", severity: "ERROR", message: "Timeout"},
+		{timestamp: "2024-01-01 08:00:01.000", severity: "ERROR", message: "Timeout"},
+		{timestamp: "2024-01-01 08:00:02.000", severity: "INFO", message: "Started"},
+	}
+	logAnalysis := analyzelogAnalyses([]LogAnalysis{newLogAnalysis("a.log", testLogs, nil, parseStats{lines: 3}, analysisOptions{})})
+	var report bytes.Buffer
+	if err := writeLogAnalysisJSON(&report, logAnalysis, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	key := bytes.Repeat([]byte{7}, 32)
+	encrypted, err := encryptBytes(report.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"plain.json": report.Bytes(), "encrypted.json": encrypted} {
+		baselinePath := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(baselinePath, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		baseline, err := loadTopMessageBaseline(baselinePath, key)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !slices.Equal(baseline.messages, []string{"Timeout", "Started"}) {
+			t.Errorf("%s: expected the report's top messages, got %q", name, baseline.messages)
+		}
+	}
+
+	// The same run against its own report is perfectly stable
+	options := analysisOptions{topMessageBaseline: &topMessageBaseline{path: "plain.json", messages: []string{"Timeout", "Started"}}}
+	logAnalysis = analyzelogAnalyses([]LogAnalysis{newLogAnalysis("a.log", testLogs, nil, parseStats{lines: 3}, options)})
+	report.Reset()
+	if err := writeLogAnalysisJSON(&report, logAnalysis, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(report.Bytes(), []byte(`"stability": {`)) || !bytes.Contains(report.Bytes(), []byte(`"score": 1.000000,`)) {
+		t.Errorf("Expected a stability score of 1 in\n%s", report.String())
+	}
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+const streamBufferSize = 64 * 1024
+
+// bytesReadFlushSize is how many bytes a worker reads before adding them to
+// the shared bytes_read counter, so -progress sees large files advance
+// without every line contending on it.
+const bytesReadFlushSize = 1 << 20
+
+type parsedLine struct {
+	logMessage LogMessage
+	err        error
+	// lineNumber counts from 1 and includes blank lines; logRow is only
+	// kept for lines that failed to parse
+	lineNumber int64
+	logRow     string
+	// skippedLines counts the non-blank lines of blocks passed over
+	// unread, which is all such a parsedLine stands for
+	skippedLines int64
+}
+
+// streamLogMessages parses reader line by line and sends each result on
+// parsedLineChan until the reader ends or ctx is done. bufio.Reader is used
+// rather than bufio.Scanner so a single oversized line cannot stop the stream.
+// skip, when set, passes over the blocks of an indexed file that can't match
+// -line-filter. lineNumber is the number of the last line read.
+func streamLogMessages(ctx context.Context, reader *bufio.Reader, parser Parser, skip *blockSkip, parsedLineChan chan<- parsedLine) (bytesRead int64, lineNumber int64, err error) {
+	var flushed int64
+	bytesReadCounter := metrics.counter("bytes_read")
+	defer func() { bytesReadCounter.Add(bytesRead - flushed) }()
+	for {
+		if skip != nil {
+			if lines, nonBlankLines, size := skip.next(lineNumber); lines > 0 {
+				discarded, discardErr := reader.Discard(int(size))
+				bytesRead += int64(discarded)
+				lineNumber += lines
+				if errors.Is(discardErr, io.EOF) {
+					return bytesRead, lineNumber, nil
+				}
+				if discardErr != nil {
+					return bytesRead, lineNumber, discardErr
+				}
+				if nonBlankLines == 0 {
+					continue
+				}
+				select {
+				case parsedLineChan <- parsedLine{err: errLineFiltered, skippedLines: nonBlankLines}:
+				case <-ctx.Done():
+					return bytesRead, lineNumber, ctx.Err()
+				}
+				continue
+			}
+		}
+		logRow, readErr := reader.ReadString('\n')
+		bytesRead += int64(len(logRow))
+		if bytesRead-flushed >= bytesReadFlushSize {
+			bytesReadCounter.Add(bytesRead - flushed)
+			flushed = bytesRead
+		}
+		// The last read at the end of a file ending in a newline is empty
+		if logRow != "" {
+			lineNumber++
+		}
+		if strings.TrimSpace(logRow) != "" {
+			logRow = strings.TrimRight(logRow, "\r\n")
+			logMessage, parseErr := parser(logRow)
+			if errors.Is(parseErr, errDirectiveLine) {
+				continue
+			}
+			parsed := parsedLine{logMessage: logMessage, err: parseErr, lineNumber: lineNumber}
+			if parseErr != nil {
+				parsed.logRow = logRow
+			}
+			select {
+			case parsedLineChan <- parsed:
+			case <-ctx.Done():
+				return bytesRead, lineNumber, ctx.Err()
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return
+		}
+		if readErr != nil {
+			return bytesRead, lineNumber, readErr
+		}
+	}
+}
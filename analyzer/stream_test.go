@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStreamLogMessages(t *testing.T) {
+	longMessage := strings.Repeat("x", 200*1024)
+	logContent := "2024-01-01 00:00:00.000 | INFO | app: f: 1 - Started\r\n" +
+		"\n" +
+		"garbage\n" +
+		"2024-01-01 00:00:01.000 | ERROR | app: f: 2 - " + longMessage
+
+	parsedLineChan := make(chan parsedLine)
+	var bytesRead int64
+	var err error
+	go func() {
+		bytesRead, _, err = streamLogMessages(context.Background(), bufio.NewReader(strings.NewReader(logContent)), parseLogMessage, nil, parsedLineChan)
+		close(parsedLineChan)
+	}()
+
+	var parsedLines []parsedLine
+	for parsed := range parsedLineChan {
+		parsedLines = append(parsedLines, parsed)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytesRead != int64(len(logContent)) {
+		t.Errorf("streamLogMessages() read %d bytes, want %d", bytesRead, len(logContent))
+	}
+	if len(parsedLines) != 3 {
+		t.Fatalf("streamLogMessages() sent %d lines, want 3", len(parsedLines))
+	}
+	if parsedLines[0].err != nil || parsedLines[0].logMessage.message != "Started" {
+		t.Errorf("first line = %+v", parsedLines[0])
+	}
+	if parsedLines[1].err == nil {
+		t.Errorf("garbage line parsed without error")
+	}
+	if parsedLines[2].err != nil || len(parsedLines[2].logMessage.message) != len(longMessage) {
+		t.Errorf("long line was not parsed whole: err = %v", parsedLines[2].err)
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 5424: <165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+var rfc5424Pattern = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\"]|\\.|"(?:[^"\\]|\\.)*")*\])+)(?: (.*))?$`)
+
+// RFC 3164 as sent over the network, with a priority, and as written to
+// /var/log/syslog and /var/log/messages, without one, with the classic or
+// the RFC 3339 timestamp of rsyslog's file format. The host is missing
+// from some relayed messages: <34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed
+var rfc3164Pattern = regexp.MustCompile(`^(?:<(\d{1,3})>)?(\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) (?:(\S*[^\s:]) )?([^\s\[:]+)(?:\[([^\]]*)\])?: ?(.*)$`)
+
+var (
+	syslogSDElementPattern = regexp.MustCompile(`\[([^\s\]]+)((?:\s+[^=\s\]]+="(?:[^"\\]|\\.)*")*)\]`)
+	syslogSDParamPattern   = regexp.MustCompile(`([^=\s\]]+)="((?:[^"\\]|\\.)*)"`)
+	syslogSDEscapes        = strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`)
+)
+
+var syslogFacilities = []string{"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7"}
+
+// getSyslogSeverity maps syslog levels 0-2 to CRITICAL, 3 to ERROR, 4 to
+// WARNING, 5 to NOTICE, 6 to INFO and 7 to DEBUG.
+func getSyslogSeverity(level int64) string {
+	switch {
+	case level <= 2:
+		return "CRITICAL"
+	case level == 3:
+		return "ERROR"
+	case level == 4:
+		return "WARNING"
+	case level == 5:
+		return "NOTICE"
+	case level == 6:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// decodeSyslogPriority splits a PRI value into the severity, its low three
+// bits, and the facility name kept as an attribute.
+func decodeSyslogPriority(value string, logMessage *LogMessage) error {
+	priority, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || priority > 191 {
+		return errMissingSeverity
+	}
+	logMessage.severity = getSyslogSeverity(priority % 8)
+	logMessage.attributes[facilityAttribute] = syslogFacilities[priority/8]
+	return nil
+}
+
+func getSyslogValue(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
+
+// parseSyslogMessage reads RFC 5424 and RFC 3164 syslog lines. The
+// priority decodes into the severity and the facility attribute; lines
+// without one, as in syslog files, are INFO. The app name or tag is the
+// module, and the host, the RFC 5424 message ID and its structured data
+// parameters, as "SD-ID.name", are kept as attributes.
+func parseSyslogMessage(logRow string) (logMessage LogMessage, err error) {
+	logMessage.attributes = make(map[string]string)
+	logMessage.severity = "INFO"
+	if match := rfc5424Pattern.FindStringSubmatch(logRow); match != nil {
+		if err = decodeSyslogPriority(match[1], &logMessage); err != nil {
+			return
+		}
+		if logMessage.timestamp, err = normalizeTimestamp(match[2], time.RFC3339Nano); err != nil {
+			return
+		}
+		for attribute, value := range map[string]string{"host": match[3], "msgid": match[6]} {
+			if value = getSyslogValue(value); value != "" {
+				logMessage.attributes[attribute] = value
+			}
+		}
+		logMessage.module = getSyslogValue(match[4])
+		logMessage.pid = getSyslogValue(match[5])
+		for _, element := range syslogSDElementPattern.FindAllStringSubmatch(match[7], -1) {
+			for _, param := range syslogSDParamPattern.FindAllStringSubmatch(element[2], -1) {
+				logMessage.attributes[element[1]+"."+param[1]] = syslogSDEscapes.Replace(param[2])
+			}
+		}
+		logMessage.message = strings.TrimPrefix(match[8], "\ufeff")
+		return
+	}
+	match := rfc3164Pattern.FindStringSubmatch(logRow)
+	if match == nil {
+		return logMessage, errMissingDelimiter
+	}
+	if match[1] != "" {
+		if err = decodeSyslogPriority(match[1], &logMessage); err != nil {
+			return
+		}
+	}
+	if logMessage.timestamp, err = normalizeSyslogTimestamp(match[2], time.Now().UTC()); err != nil {
+		return
+	}
+	if match[3] != "" {
+		logMessage.attributes["host"] = match[3]
+	}
+	logMessage.module = match[4]
+	logMessage.pid = match[5]
+	logMessage.message = strings.TrimSpace(match[6])
+	return
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSyslogMessage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LogMessage
+	}{
+		{
+			input: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"][origin ip="192.0.2.1"] An application event`,
+			want: LogMessage{timestamp: "2003-10-11 22:14:15.003", severity: "NOTICE", module: "evntslog", message: "An application event",
+				attributes: map[string]string{"facility": "local4", "host": "mymachine.example.com", "msgid": "ID47",
+					"exampleSDID@32473.iut": "3", "exampleSDID@32473.eventSource": `App"lication`, "origin.ip": "192.0.2.1"}},
+		},
+		{
+			input: "<11>1 2024-01-02T16:04:05+01:00 web1 nginx 4242 - - \ufeffupstream timed out",
+			want: LogMessage{timestamp: "2024-01-02 15:04:05", severity: "ERROR", module: "nginx", pid: "4242", message: "upstream timed out",
+				attributes: map[string]string{"facility": "user", "host": "web1"}},
+		},
+		{
+			input: "<34>2024-01-02T15:04:05.000000+00:00 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8",
+			want: LogMessage{timestamp: "2024-01-02 15:04:05", severity: "CRITICAL", module: "su", pid: "123", message: "'su root' failed for lonvick on /dev/pts/8",
+				attributes: map[string]string{"facility": "auth", "host": "mymachine"}},
+		},
+		{
+			input: "2024-01-02T15:04:05.123456+00:00 web1 systemd[1]: Started Session 42 of user root.",
+			want: LogMessage{timestamp: "2024-01-02 15:04:05.123", severity: "INFO", module: "systemd", pid: "1", message: "Started Session 42 of user root.",
+				attributes: map[string]string{"host": "web1"}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseSyslogMessage(tt.input)
+		if err != nil {
+			t.Fatalf("parseSyslogMessage(%q) error = %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSyslogMessage(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	// Relayed messages may lack the host
+	got, err := parseSyslogMessage("<13>Jan  2 15:04:05 cron: job: done")
+	if err != nil || got.module != "cron" || got.message != "job: done" || got.Attribute("host") != "" || !strings.HasSuffix(got.timestamp, "-01-02 15:04:05") {
+		t.Errorf("parseSyslogMessage() without a host = %+v, %v", got, err)
+	}
+	for _, input := range []string{"<999>Jan  2 15:04:05 host app: x", "<13>1 - host app - - - x", "not syslog"} {
+		if _, err := parseSyslogMessage(input); err == nil {
+			t.Errorf("parseSyslogMessage(%q) accepted a bad line", input)
+		}
+	}
+}
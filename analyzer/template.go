@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// messageTemplateRules replace the parts of a message that vary between
+// otherwise identical messages, in order: UUIDs and addresses before the
+// numbers they are made of. Numbers may carry a unit, like 250ms or 4KiB.
+var messageTemplateRules = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4}\b|\b(?:[0-9a-fA-F]{1,4}:){1,6}:(?:[0-9a-fA-F]{1,4}(?::[0-9a-fA-F]{1,4})*)?`), "<ip>"},
+	{regexp.MustCompile(`\b\d+(?:\.\d+)?(?:(?:ns|us|ms|s|m|h|d|[KkMG]i?B|B)\b|%|\b)`), "<num>"},
+}
+
+// Hex IDs are 0x numbers or words of six or more hex digits; those without
+// a digit, like "facade", are words.
+var hexIDPattern = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-fA-F]{6,})\b`)
+
+// templateMessage turns "Connection to 10.0.0.5 failed after 3 retries"
+// into "Connection to <ip> failed after <num> retries".
+func templateMessage(message string) string {
+	for _, rule := range messageTemplateRules {
+		message = rule.pattern.ReplaceAllString(message, rule.placeholder)
+	}
+	return hexIDPattern.ReplaceAllStringFunc(message, func(word string) string {
+		if strings.HasPrefix(word, "0x") || strings.ContainsAny(word, "0123456789") {
+			return "<hex>"
+		}
+		return word
+	})
+}
@@ -0,0 +1,23 @@
+package analyzer
+
+import "testing"
+
+func TestTemplateMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Connection to 10.0.0.5:5432 failed after 3 retries", "Connection to <ip> failed after <num> retries"},
+		{"Request 3f2b8c1e-9d4a-4b6f-8e2d-1a2b3c4d5e6f took 250ms", "Request <uuid> took <num>"},
+		{"Listening on fe80::1ff:fe23:4567:890a", "Listening on <ip>"},
+		{"Segfault at 0x7ffd5e8c in worker", "Segfault at <hex> in worker"},
+		{"Commit a1b2c3d4e5 deployed to facade", "Commit <hex> deployed to facade"},
+		{"Disk 93% full", "Disk <num> full"},
+		{"Cache warmed", "Cache warmed"},
+	}
+	for _, test := range tests {
+		if got := templateMessage(test.message); got != test.want {
+			t.Errorf("templateMessage(%q) = %q, want %q", test.message, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const maxReportedThreads = 10
+const maxThreadErrors = 3
+
+// defaultThreadPattern finds the IDs most formats embed in the message text,
+// such as "goroutine 42", "tid=1234" or "thread=worker-3".
+var defaultThreadPattern = regexp.MustCompile(`\bgoroutine #?(\d+)|\b(?:thread|tid|pid)[=:#] ?([\w.-]+)`)
+
+type threadCount struct {
+	entries       int64
+	errors        int64
+	errorMessages map[string]int64
+}
+
+// getThreadCounts counts entries per thread, using the parser's thread or pid
+// field when the format has one and the last capture group of threadPattern in the
+// message otherwise, skipping groups of alternatives that did not take part in
+// the match. Entries without a thread ID are not counted.
+func getThreadCounts(logMessages []LogMessage, threadPattern *regexp.Regexp) (threadCounts map[string]threadCount) {
+	threadCounts = make(map[string]threadCount)
+	for _, logMessage := range logMessages {
+		thread := logMessage.thread
+		if thread == "" {
+			thread = logMessage.pid
+		}
+		if thread == "" {
+			match := threadPattern.FindStringSubmatch(logMessage.message)
+			for index := len(match) - 1; index > 0 && thread == ""; index-- {
+				thread = match[index]
+			}
+		}
+		if thread == "" {
+			continue
+		}
+		count := threadCounts[thread]
+		count.entries += 1
+		if isErrorSeverity(logMessage.severity) {
+			count.errors += 1
+			if count.errorMessages == nil {
+				count.errorMessages = make(map[string]int64)
+			}
+			count.errorMessages[logMessage.messageKey()] += 1
+		}
+		threadCounts[thread] = count
+	}
+	return
+}
+
+func mergeThreadCounts(into map[string]threadCount, from map[string]threadCount) {
+	for thread, count := range from {
+		merged := into[thread]
+		merged.entries += count.entries
+		merged.errors += count.errors
+		for message, errors := range count.errorMessages {
+			if merged.errorMessages == nil {
+				merged.errorMessages = make(map[string]int64)
+			}
+			merged.errorMessages[message] += errors
+		}
+		into[thread] = merged
+	}
+}
+
+// getSortedThreads ranks threads by entry count, busiest first, and keeps
+// at most maxReportedThreads of them.
+func getSortedThreads(threadCounts map[string]threadCount) (threads []string) {
+	for thread := range threadCounts {
+		threads = append(threads, thread)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		if threadCounts[threads[i]].entries != threadCounts[threads[j]].entries {
+			return threadCounts[threads[i]].entries > threadCounts[threads[j]].entries
+		}
+		return threads[i] < threads[j]
+	})
+	if len(threads) > maxReportedThreads {
+		threads = threads[:maxReportedThreads]
+	}
+	return
+}
+
+func getTopThreadErrors(count threadCount) (messages []string) {
+	for message := range count.errorMessages {
+		messages = append(messages, message)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if count.errorMessages[messages[i]] != count.errorMessages[messages[j]] {
+			return count.errorMessages[messages[i]] > count.errorMessages[messages[j]]
+		}
+		return messages[i] < messages[j]
+	})
+	if len(messages) > maxThreadErrors {
+		messages = messages[:maxThreadErrors]
+	}
+	return
+}
+
+func formatThreadCounts(threadCounts map[string]threadCount, options reportOptions) (lines []string) {
+	for _, thread := range getSortedThreads(threadCounts) {
+		count := threadCounts[thread]
+		lines = append(lines, thread+": "+humanizeCount(count.entries, options)+" entries, "+
+			humanizeCount(count.errors, options)+" errors")
+		for _, message := range getTopThreadErrors(count) {
+			lines = append(lines, "   "+strconv.FormatInt(count.errorMessages[message], 10)+"x "+message)
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetThreadCounts(t *testing.T) {
+	testLogs := []LogMessage{
+		{severity: "ERROR", message: "goroutine 7: connection reset"},
+		{severity: "ERROR", message: "goroutine 7: connection reset"},
+		{severity: "INFO", message: "request served tid=8"},
+		{severity: "ERROR", thread: "main", message: "Shutdown failed"},
+		{severity: "INFO", message: "No thread here"},
+		{severity: "INFO", message: "pid 12 is not a key=value pair"},
+	}
+
+	threadCounts := getThreadCounts(testLogs, defaultThreadPattern)
+	if len(threadCounts) != 3 {
+		t.Fatalf("Expected 3 threads, got %v", threadCounts)
+	}
+	if count := threadCounts["7"]; count.entries != 2 || count.errors != 2 || count.errorMessages["goroutine 7: connection reset"] != 2 {
+		t.Errorf("Unexpected counts for goroutine 7: %+v", count)
+	}
+	if count := threadCounts["main"]; count.entries != 1 || count.errors != 1 {
+		t.Errorf("Unexpected counts for main: %+v", count)
+	}
+
+	merged := make(map[string]threadCount)
+	mergeThreadCounts(merged, threadCounts)
+	mergeThreadCounts(merged, threadCounts)
+	if merged["7"].entries != 4 || merged["7"].errorMessages["goroutine 7: connection reset"] != 4 {
+		t.Errorf("Unexpected merged counts for goroutine 7: %+v", merged["7"])
+	}
+
+	want := []string{"7", "8", "main"}
+	if got := getSortedThreads(threadCounts); !reflect.DeepEqual(got, want) {
+		t.Errorf("getSortedThreads() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// autoTimeLayouts are tried in order by -time-format auto, after epoch
+// numbers: the native layout, ISO 8601 with and without a zone, log4j's
+// comma, a zone offset after a space, Apache's access log and ctime.
+var autoTimeLayouts = []string{
+	layout,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"02/Jan/2006:15:04:05 -0700",
+	time.ANSIC,
+}
+
+// timeFormat reads the timestamps of the native format and -pattern
+// formats. Timestamps without a zone are in location, and all of them are
+// stored in UTC.
+type timeFormat struct {
+	// layouts are Go reference layouts; nil keeps the parser's own
+	layouts []string
+	// epoch accepts Unix seconds, milliseconds, microseconds or nanoseconds
+	epoch    bool
+	location *time.Location
+}
+
+// newTimeFormat reads -time-format and -timezone. The format is "auto",
+// "iso8601", "epoch" or a Go layout like "02.01.2006 15:04:05"; an empty
+// one keeps each parser's layouts. The timezone is an IANA name like
+// "Europe/Berlin", "Local", or empty for UTC.
+func newTimeFormat(format string, timezone string) (timeFormat timeFormat, err error) {
+	switch format {
+	case "":
+	case "auto":
+		timeFormat.layouts = autoTimeLayouts
+		timeFormat.epoch = true
+	case "iso8601", "rfc3339":
+		timeFormat.layouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+	case "epoch":
+		timeFormat.layouts = []string{}
+		timeFormat.epoch = true
+	default:
+		// A layout without reference fields formats as itself
+		if time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(format) == format {
+			return timeFormat, errors.New("time format " + format + " has no date or time fields, expected auto, iso8601, epoch or a layout like 2006-01-02T15:04:05")
+		}
+		timeFormat.layouts = []string{format}
+	}
+	if timezone != "" {
+		if timeFormat.location, err = time.LoadLocation(timezone); err != nil {
+			return timeFormat, errors.New("unknown timezone " + timezone)
+		}
+	}
+	return
+}
+
+func (timeFormat timeFormat) isSet() bool {
+	return timeFormat.layouts != nil || timeFormat.location != nil
+}
+
+// normalize parses value and formats it in the native layout in UTC, trying
+// defaultLayouts when the format doesn't name any.
+func (timeFormat timeFormat) normalize(value string, defaultLayouts ...string) (string, error) {
+	if timeFormat.epoch && strings.Trim(value, "0123456789.") == "" {
+		if epoch, err := parseJSONEpoch(value); err == nil {
+			return epoch.UTC().Format(layout), nil
+		}
+	}
+	layouts := timeFormat.layouts
+	if layouts == nil {
+		layouts = defaultLayouts
+	}
+	location := timeFormat.location
+	if location == nil {
+		location = time.UTC
+	}
+	for _, timeLayout := range layouts {
+		if timestamp, err := time.ParseInLocation(timeLayout, value, location); err == nil {
+			return timestamp.UTC().Format(layout), nil
+		}
+	}
+	return "", errBadTimestamp
+}
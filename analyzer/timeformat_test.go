@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormatNormalize(t *testing.T) {
+	auto, err := newTimeFormat("auto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin := auto
+	berlin.location = time.FixedZone("CET", 3600)
+	custom, err := newTimeFormat("02.01.2006 15:04:05", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		format timeFormat
+		value  string
+		want   string
+	}{
+		{"native", auto, "2024-01-02 15:04:05.000", "2024-01-02 15:04:05"},
+		{"iso8601 with offset", auto, "2024-01-02T16:04:05.250+01:00", "2024-01-02 15:04:05.25"},
+		{"iso8601 without zone", auto, "2024-01-02T15:04:05", "2024-01-02 15:04:05"},
+		{"epoch millis", auto, "1704207845000", "2024-01-02 15:04:05"},
+		{"epoch seconds", auto, "1704207845", "2024-01-02 15:04:05"},
+		{"offset after a space", auto, "2024-01-02 10:04:05 -0500", "2024-01-02 15:04:05"},
+		{"zone for timestamps without one", berlin, "2024-01-02 16:04:05", "2024-01-02 15:04:05"},
+		{"zone doesn't override an offset", berlin, "2024-01-02T15:04:05Z", "2024-01-02 15:04:05"},
+		{"custom layout", custom, "02.01.2024 15:04:05", "2024-01-02 15:04:05"},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.normalize(tt.value, layout)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: normalize(%q) = %q, %v, want %q", tt.name, tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := custom.normalize("2024-01-02 15:04:05", layout); err != errBadTimestamp {
+		t.Errorf("Expected a custom layout to replace the defaults, got %v", err)
+	}
+}
+
+func TestNewTimeFormat(t *testing.T) {
+	for _, spec := range [][2]string{{"iso8601", ""}, {"epoch", "UTC"}, {"2006-01-02T15:04:05", "Local"}} {
+		if _, err := newTimeFormat(spec[0], spec[1]); err != nil {
+			t.Errorf("newTimeFormat(%q, %q) failed: %v", spec[0], spec[1], err)
+		}
+	}
+	for _, spec := range [][2]string{{"yyyy-mm-dd", ""}, {"auto", "Mars/Olympus_Mons"}} {
+		if _, err := newTimeFormat(spec[0], spec[1]); err == nil {
+			t.Errorf("Expected newTimeFormat(%q, %q) to fail", spec[0], spec[1])
+		}
+	}
+}
+
+func TestNativeParserTimeFormat(t *testing.T) {
+	format, err := newTimeFormat("auto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := resolveLineParser("native", "", format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logMessage, err := parser("2024-01-02T16:04:05+01:00 | ERROR | app.module: function: 1 - Database error")
+	if err != nil || logMessage.timestamp != "2024-01-02 15:04:05" {
+		t.Errorf("Expected the ISO 8601 timestamp in UTC, got %q, %v", logMessage.timestamp, err)
+	}
+	if _, err := resolveLineParser("syslog", "", format); err == nil {
+		t.Errorf("Expected -time-format to be rejected for a preset with its own timestamps")
+	}
+	zoneOnly, err := newTimeFormat("", "Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveLineParser("syslog", "", zoneOnly); err != nil {
+		t.Errorf("Expected -timezone alone to be accepted for -preset syslog, got %v", err)
+	}
+}
+
+func TestReportOptionsFormatTime(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if got := (reportOptions{}).formatTime(timestamp, layout); got != "2024-01-02 15:04:05" {
+		t.Errorf("formatTime() in UTC = %q", got)
+	}
+	if got := (reportOptions{location: time.FixedZone("CET", 3600)}).formatTime(timestamp, layout); got != "2024-01-02 16:04:05 CET" {
+		t.Errorf("formatTime() in CET = %q", got)
+	}
+}
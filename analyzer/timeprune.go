@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"bytes"
+	"os"
+	"time"
+)
+
+// timeScanBytes is how much of each end of a file is searched for a
+// timestamp before the file is read in full anyway.
+const timeScanBytes = 64 * 1024
+
+// isFileOutsideTimeRange reports whether every entry of logFile falls outside
+// the -since/-until window, going by the first timestamp in its head and the
+// last in its tail. Logs are written in time order, so a file that ends
+// before the window or starts after it has nothing in it. Files without a
+// timestamp near either end, and those read with a parser that carries state
+// from line to line, are read in full.
+func isFileOutsideTimeRange(logFile *os.File, options analysisOptions) bool {
+	if (options.since.IsZero() && options.until.IsZero()) || options.newParser != nil {
+		return false
+	}
+	fileInfo, err := logFile.Stat()
+	if err != nil || !fileInfo.Mode().IsRegular() {
+		return false
+	}
+	parser := options.parser
+	if parser == nil {
+		parser = parseLogMessage
+	}
+	size := fileInfo.Size()
+	headLines, err := readFileEnd(logFile, 0, min(size, timeScanBytes), size)
+	if err != nil {
+		return false
+	}
+	first, ok := findEntryTime(headLines, parser, false)
+	if !ok {
+		return false
+	}
+	if !options.until.IsZero() && !first.Before(options.until) {
+		return true
+	}
+	tailStart := max(0, size-timeScanBytes)
+	tailLines, err := readFileEnd(logFile, tailStart, size, size)
+	if err != nil {
+		return false
+	}
+	last, ok := findEntryTime(tailLines, parser, true)
+	return ok && !options.since.IsZero() && last.Before(options.since)
+}
+
+// readFileEnd reads the bytes of logFile from start to end and returns the
+// whole lines among them, dropping those cut off by either bound.
+func readFileEnd(logFile *os.File, start int64, end int64, size int64) ([][]byte, error) {
+	data := make([]byte, end-start)
+	if _, err := logFile.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	if start > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && (end < size || len(lines[len(lines)-1]) == 0) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// findEntryTime returns the time of the first line that parses with a
+// timestamp, or of the last one when fromEnd is set.
+func findEntryTime(lines [][]byte, parser Parser, fromEnd bool) (time.Time, bool) {
+	for index := range lines {
+		if fromEnd {
+			index = len(lines) - 1 - index
+		}
+		logMessage, err := parser(string(bytes.TrimSuffix(lines[index], []byte("\r"))))
+		if err != nil {
+			continue
+		}
+		if entryTime, err := time.Parse(layout, logMessage.timestamp); err == nil {
+			return entryTime, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"errors"
+	"time"
+)
+
+var timeBoundLayouts = []string{layout, time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// parseTimeBound reads a -since/-until value: either a duration counted back
+// from now ("1h") or an absolute UTC timestamp.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration).UTC(), nil
+	}
+	for _, timeLayout := range timeBoundLayouts {
+		if bound, err := time.Parse(timeLayout, value); err == nil {
+			return bound.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New("invalid time bound: " + value)
+}
+
+// isOutsideTimeRange reports whether an entry falls outside [since, until).
+// Zero bounds are open, and entries without a usable timestamp are outside
+// any bounded range.
+func isOutsideTimeRange(timestamp string, since time.Time, until time.Time) bool {
+	if since.IsZero() && until.IsZero() {
+		return false
+	}
+	entryTime, err := time.Parse(layout, timestamp)
+	if err != nil {
+		return true
+	}
+	return (!since.IsZero() && entryTime.Before(since)) || (!until.IsZero() && !entryTime.Before(until))
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "1h", want: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{value: "2024-01-01 10:30:00.000", want: time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2024-01-01T10:30:00+01:00", want: time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)},
+		{value: "2024-01-01", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.value, now)
+		if err != nil {
+			t.Errorf("parseTimeBound(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+	if _, err := parseTimeBound("yesterday", now); err == nil {
+		t.Errorf("parseTimeBound() accepted an invalid bound")
+	}
+}
+
+func TestAnalyzeLogFilesTimeRange(t *testing.T) {
+	tmpFileName := createTestLogFile(t, `2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error
+2024-01-01 01:00:00.000 | INFO | app.module: function: 2 - User logged in
+2024-01-01 01:30:00.000 | ERROR | app.module: function: 3 - Database error
+2024-01-01 02:00:00.000 | INFO | app.module: function: 4 - User logged in`)
+	defer os.Remove(tmpFileName)
+
+	options := analysisOptions{
+		since: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		until: time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC),
+	}
+	analysis := analyzeLogFiles(context.Background(), []string{tmpFileName}, options)
+	if analysis.numEntries != 2 {
+		t.Errorf("Expected 2 entries in the time range, got %d", analysis.numEntries)
+	}
+	if !analysis.startTime.Equal(options.since) {
+		t.Errorf("Expected start time %v, got %v", options.since, analysis.startTime)
+	}
+	if analysis.logSeverityFrequency["ERROR"] != 1 {
+		t.Errorf("Expected 1 error in the time range, got %d", analysis.logSeverityFrequency["ERROR"])
+	}
+}
+
+func TestIsFileOutsideTimeRange(t *testing.T) {
+	logPath := createTestLogFile(t, `2024-01-01 00:00:00.000 | ERROR | app.module: function: 1 - Database error
+not an entry
+2024-01-01 01:00:00.000 | INFO | app.module: function: 2 - User logged in
+2024-01-01 02:00:00.000 | INFO | app.module: function: 3 - User logged in
+`)
+	defer os.Remove(logPath)
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logFile.Close()
+
+	hour := func(hour int) time.Time { return time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		since, until time.Time
+		want         bool
+	}{
+		{want: false},
+		{since: hour(1), until: hour(3), want: false},
+		{since: hour(2), want: false},
+		{since: hour(3), want: true},
+		{until: hour(0), want: true},
+		{until: hour(1), want: false},
+	}
+	for _, tt := range tests {
+		options := analysisOptions{since: tt.since, until: tt.until}
+		if got := isFileOutsideTimeRange(logFile, options); got != tt.want {
+			t.Errorf("isFileOutsideTimeRange(since %v, until %v) = %v, want %v", tt.since, tt.until, got, tt.want)
+		}
+	}
+
+	analysis := analyzeLogFiles(context.Background(), []string{logPath}, analysisOptions{since: hour(3)})
+	if analysis.bytesRead != 0 || analysis.numEntries != 0 {
+		t.Errorf("analyzeLogFiles() read %d bytes of a file outside the range, want 0", analysis.bytesRead)
+	}
+}
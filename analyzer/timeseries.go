@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+func getPeriodStart(timestamp time.Time, period string) (time.Time, error) {
+	year, month, day := timestamp.Date()
+	switch period {
+	case "hour":
+		return timestamp.Truncate(time.Hour), nil
+	case "day":
+		return time.Date(year, month, day, 0, 0, 0, 0, timestamp.Location()), nil
+	case "week":
+		offset := (int(timestamp.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, timestamp.Location()), nil
+	case "month":
+		return time.Date(year, month, 1, 0, 0, 0, 0, timestamp.Location()), nil
+	}
+	if interval, err := time.ParseDuration(period); err == nil && interval > 0 {
+		return timestamp.Truncate(interval), nil
+	}
+	return time.Time{}, errors.New("unknown period: " + period)
+}
+
+func getNextPeriodStart(periodStart time.Time, period string) time.Time {
+	switch period {
+	case "hour":
+		return periodStart.Add(time.Hour)
+	case "week":
+		return periodStart.AddDate(0, 0, 7)
+	case "month":
+		return periodStart.AddDate(0, 1, 0)
+	case "day":
+		return periodStart.AddDate(0, 0, 1)
+	}
+	interval, _ := time.ParseDuration(period)
+	return periodStart.Add(interval)
+}
+
+func writeTimeSeriesCSV(writer io.Writer, timeBucketCounts map[timeBucketKey]int64, period string) error {
+	periodCounts := make(map[time.Time]map[string]int64)
+	var firstPeriod, lastPeriod time.Time
+	// Columns beyond DEBUG to ERROR are only added for severities that occur
+	var severityFrequency LogSeverityFrequency
+	for key, count := range timeBucketCounts {
+		addSeverityCount(&severityFrequency, key.severity, count)
+		periodStart, err := getPeriodStart(key.start, period)
+		if err != nil {
+			return err
+		}
+		if periodCounts[periodStart] == nil {
+			periodCounts[periodStart] = make(map[string]int64)
+		}
+		periodCounts[periodStart]["entries"] += count
+		periodCounts[periodStart][key.severity] += count
+		if firstPeriod.IsZero() || periodStart.Before(firstPeriod) {
+			firstPeriod = periodStart
+		}
+		if periodStart.After(lastPeriod) {
+			lastPeriod = periodStart
+		}
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	severities := getSortedSeverities(severityFrequency)
+	header := append([]string{"period", "entries"}, severities...)
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	if len(periodCounts) > 0 {
+		// Periods without entries are written as zero rows so charts keep a continuous axis
+		for periodStart := firstPeriod; !periodStart.After(lastPeriod); periodStart = getNextPeriodStart(periodStart, period) {
+			counts := periodCounts[periodStart]
+			row := []string{periodStart.Format(layout), strconv.FormatInt(counts["entries"], 10)}
+			for _, severity := range severities {
+				row = append(row, strconv.FormatInt(counts[severity], 10))
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeTimeSeriesCSVFile(csvPath string, timeBucketCounts map[timeBucketKey]int64, period string, key []byte) error {
+	csvFile, err := createOutputFile(csvPath, key)
+	if err != nil {
+		return err
+	}
+	if err := writeTimeSeriesCSV(csvFile, timeBucketCounts, period); err != nil {
+		csvFile.Close()
+		return err
+	}
+	return csvFile.Close()
+}
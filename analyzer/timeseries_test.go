@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTimeSeriesCSV(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00.000", severity: "INFO"},
+		{timestamp: "2024-01-01 09:30:00.000", severity: "ERROR"},
+		{timestamp: "2024-01-03 10:00:00.000", severity: "WARNING"},
+	}
+
+	var output bytes.Buffer
+	if err := writeTimeSeriesCSV(&output, getTimeBucketCounts(testLogs), "day"); err != nil {
+		t.Fatal(err)
+	}
+	want := "period,entries,DEBUG,INFO,WARNING,ERROR\n" +
+		"2024-01-01 00:00:00,2,0,1,0,1\n" +
+		"2024-01-02 00:00:00,0,0,0,0,0\n" +
+		"2024-01-03 00:00:00,1,0,0,1,0\n"
+	if output.String() != want {
+		t.Errorf("writeTimeSeriesCSV() = %q, want %q", output.String(), want)
+	}
+
+	output.Reset()
+	if err := writeTimeSeriesCSV(&output, getTimeBucketCounts(testLogs[:2]), "45m"); err != nil {
+		t.Fatal(err)
+	}
+	want = "period,entries,DEBUG,INFO,WARNING,ERROR\n" +
+		"2024-01-01 07:30:00,1,0,1,0,0\n" +
+		"2024-01-01 08:15:00,0,0,0,0,0\n" +
+		"2024-01-01 09:00:00,1,0,0,0,1\n"
+	if output.String() != want {
+		t.Errorf("writeTimeSeriesCSV() with a duration = %q, want %q", output.String(), want)
+	}
+
+	if err := writeTimeSeriesCSV(&output, getTimeBucketCounts(testLogs), "fortnight"); err == nil {
+		t.Errorf("writeTimeSeriesCSV() with unknown period should fail")
+	}
+}
@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+const maxTraceIDsPerMessage = 3
+
+var traceParentPattern = regexp.MustCompile(`\b[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}\b`)
+var traceIDPattern = regexp.MustCompile(`(?i)\btrace[_-]?id["']?\s*[=:]\s*["']?([0-9a-f]{16,32})\b`)
+var spanIDPattern = regexp.MustCompile(`(?i)\bspan[_-]?id["']?\s*[=:]\s*["']?([0-9a-f]{16})\b`)
+
+// hasTraceContext reports whether message has a run of 16 hex digits, which
+// every trace and span ID has, so most messages skip the patterns.
+func hasTraceContext(message string) bool {
+	var run int
+	for index := 0; index < len(message); index++ {
+		char := message[index] | 0x20
+		if char >= '0' && char <= '9' || char >= 'a' && char <= 'f' {
+			if run += 1; run == 16 {
+				return true
+			}
+		} else {
+			run = 0
+		}
+	}
+	return false
+}
+
+func extractTraceID(message string) string {
+	if !hasTraceContext(message) {
+		return ""
+	}
+	if match := traceParentPattern.FindStringSubmatch(message); match != nil {
+		return match[1]
+	}
+	if match := traceIDPattern.FindStringSubmatch(message); match != nil {
+		return strings.ToLower(match[1])
+	}
+	return ""
+}
+
+// getTraceMessageKey returns message with its traceparent, trace ID and
+// span ID replaced by placeholders, or "" when it has none of them.
+func getTraceMessageKey(message string) string {
+	if !hasTraceContext(message) {
+		return ""
+	}
+	key := traceParentPattern.ReplaceAllString(message, "<traceparent>")
+	key = replaceSubmatches(traceIDPattern, key, "<trace>")
+	key = replaceSubmatches(spanIDPattern, key, "<span>")
+	if key == message {
+		return ""
+	}
+	return key
+}
+
+// replaceSubmatches replaces the first group of every match of pattern.
+func replaceSubmatches(pattern *regexp.Regexp, text string, placeholder string) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var builder strings.Builder
+	previousEnd := 0
+	for _, match := range matches {
+		builder.WriteString(text[previousEnd:match[2]])
+		builder.WriteString(placeholder)
+		previousEnd = match[3]
+	}
+	builder.WriteString(text[previousEnd:])
+	return builder.String()
+}
+
+// keyTraceMessages keys the entries that carry trace context by their
+// message without it, when no -message-key is set. Otherwise the entry of
+// every trace would be a message of its own, which never ranks among the
+// top messages its trace IDs are listed under.
+func keyTraceMessages(logMessages []LogMessage) {
+	for index := range logMessages {
+		if key := getTraceMessageKey(logMessages[index].message); key != "" {
+			logMessages[index].key = key
+		}
+	}
+}
+
+// getMessageTraceIDs collects trace IDs under the key the entries are
+// counted by, so they line up with the top messages.
+func getMessageTraceIDs(logMessages []LogMessage) (messageTraceIDs map[string][]string) {
+	messageTraceIDs = make(map[string][]string)
+	for _, logMessage := range logMessages {
+		key := logMessage.messageKey()
+		if len(messageTraceIDs[key]) >= maxTraceIDsPerMessage {
+			continue
+		}
+		if traceID := extractTraceID(logMessage.message); traceID != "" {
+			messageTraceIDs[key] = append(messageTraceIDs[key], traceID)
+		}
+	}
+	return
+}
+
+func mergeMessageTraceIDs(into map[string][]string, from map[string][]string) {
+	for message, traceIDs := range from {
+		for _, traceID := range traceIDs {
+			if len(into[message]) >= maxTraceIDsPerMessage {
+				break
+			}
+			into[message] = append(into[message], traceID)
+		}
+	}
+}
+
+func formatTraceLink(traceID string, urlTemplate string) string {
+	if urlTemplate == "" {
+		return traceID
+	}
+	return strings.ReplaceAll(urlTemplate, "{traceId}", traceID)
+}
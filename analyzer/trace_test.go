@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestExtractTraceID(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Payment failed traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{message: "Payment failed trace_id=4BF92F3577B34DA6", want: "4bf92f3577b34da6"},
+		{message: `Payment failed {"traceId": "4bf92f3577b34da6a3ce929d0e0e4736"}`, want: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{message: "Payment failed for order 1234", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := extractTraceID(tt.message); got != tt.want {
+				t.Errorf("extractTraceID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	link := formatTraceLink("abc123", "https://tempo.example.com/trace/{traceId}")
+	if link != "https://tempo.example.com/trace/abc123" {
+		t.Errorf("formatTraceLink() = %q", link)
+	}
+}
+
+func TestGetTraceMessageKey(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Payment failed traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "Payment failed traceparent=<traceparent>"},
+		{message: "Payment failed trace_id=4BF92F3577B34DA6 span_id=00f067aa0ba902b7", want: "Payment failed trace_id=<trace> span_id=<span>"},
+		{message: "Payment failed for order 1234", want: ""},
+	}
+	for _, tt := range tests {
+		if got := getTraceMessageKey(tt.message); got != tt.want {
+			t.Errorf("getTraceMessageKey(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestTraceIDsLineUpWithTopMessages(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 08:00:00", severity: "ERROR", message: "Payment failed trace_id=4bf92f3577b34da6"},
+		{timestamp: "2024-01-01 08:00:01", severity: "ERROR", message: "Payment failed trace_id=5bf92f3577b34da6"},
+		{timestamp: "2024-01-01 08:00:02", severity: "INFO", message: "Started"},
+	}
+	logAnalysis := analyzelogAnalyses([]LogAnalysis{newLogAnalysis("a.log", testLogs, nil, parseStats{lines: 3}, analysisOptions{})})
+	top := logAnalysis.topFiveLogMessages[0]
+	if top != "Payment failed trace_id=<trace>" || logAnalysis.topFiveLogMessageFrequencies[0] != 2 {
+		t.Fatalf("Expected the failures of both traces to rank as one message, got %q with %d", top, logAnalysis.topFiveLogMessageFrequencies[0])
+	}
+	if got := logAnalysis.messageTraceIDs[top]; len(got) != 2 || got[0] != "4bf92f3577b34da6" {
+		t.Errorf("Expected both trace IDs under the top message, got %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type severityTransition struct {
+	module string
+	from   string
+	to     string
+}
+
+// getSeverityTransitions counts, per module, how often an entry of one
+// severity is followed by the module's next entry of another (or the same)
+// severity. It shows whether a module warns before it fails.
+func getSeverityTransitions(logMessages []LogMessage) (transitions map[severityTransition]int64) {
+	transitions = make(map[severityTransition]int64)
+	previousSeverities := make(map[string]string)
+	for _, logMessage := range logMessages {
+		if _, ok := severityRanks[logMessage.severity]; !ok {
+			continue
+		}
+		if previous, ok := previousSeverities[logMessage.module]; ok {
+			transitions[severityTransition{module: logMessage.module, from: previous, to: logMessage.severity}] += 1
+		}
+		previousSeverities[logMessage.module] = logMessage.severity
+	}
+	return
+}
+
+func getTransitionModules(transitions map[severityTransition]int64) (modules []string) {
+	seen := make(map[string]bool)
+	for transition := range transitions {
+		if !seen[transition.module] {
+			seen[transition.module] = true
+			modules = append(modules, transition.module)
+		}
+	}
+	sort.Strings(modules)
+	return
+}
+
+// getTransitionSeverities lists the severities of a module's matrix: the
+// reported ones and any other known level its transitions involve.
+func getTransitionSeverities(transitions map[severityTransition]int64, module string) []string {
+	var seen LogSeverityFrequency
+	for transition, count := range transitions {
+		if transition.module == module {
+			addSeverityCount(&seen, transition.from, count)
+			addSeverityCount(&seen, transition.to, count)
+		}
+	}
+	return getSortedSeverities(seen)
+}
+
+// writeSeverityTransitions prints a from/to matrix for every module, with
+// rows for the earlier entry's severity and columns for the next one's.
+func writeSeverityTransitions(writer io.Writer, transitions map[severityTransition]int64, options reportOptions) error {
+	for _, module := range getTransitionModules(transitions) {
+		io.WriteString(writer, "   "+module+": \n")
+		severities := getTransitionSeverities(transitions, module)
+		table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+		io.WriteString(table, "      from \\ to\t"+strings.Join(severities, "\t")+"\t\n")
+		for _, from := range severities {
+			values := make([]string, 0, len(severities))
+			for _, to := range severities {
+				values = append(values, humanizeCount(transitions[severityTransition{module: module, from: from, to: to}], options))
+			}
+			io.WriteString(table, "      "+from+"\t"+strings.Join(values, "\t")+"\t\n")
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGetSeverityTransitions(t *testing.T) {
+	testLogs := []LogMessage{
+		{module: "db", severity: "INFO"},
+		{module: "web", severity: "INFO"},
+		{module: "db", severity: "ERROR"},
+		{module: "web", severity: "WARNING"},
+		{module: "web", severity: "ERROR"},
+		{module: "db", severity: "ERROR"},
+		{module: "db", severity: "CUSTOM"},
+	}
+
+	transitions := getSeverityTransitions(testLogs)
+	want := map[severityTransition]int64{
+		{module: "db", from: "INFO", to: "ERROR"}:     1,
+		{module: "db", from: "ERROR", to: "ERROR"}:    1,
+		{module: "web", from: "INFO", to: "WARNING"}:  1,
+		{module: "web", from: "WARNING", to: "ERROR"}: 1,
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("getSeverityTransitions() = %v, want %v", transitions, want)
+	}
+	for transition, count := range want {
+		if transitions[transition] != count {
+			t.Errorf("Transition %+v = %d, want %d", transition, transitions[transition], count)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := writeSeverityTransitions(&output, transitions, reportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(output.String(), "\n")
+	if lines[0] != "   db: " || strings.Join(strings.Fields(lines[3]), " ") != "INFO 0 0 0 1" {
+		t.Errorf("Unexpected transition matrix:\n%s", output.String())
+	}
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	trendBuckets = 10
+	// A message whose fitted slope moves it by more than this fraction of its
+	// mean per bucket is considered rising or falling.
+	trendThreshold = 0.05
+)
+
+type messageBucketKey struct {
+	message string
+	start   time.Time
+}
+
+type messageTrend struct {
+	direction    string
+	slopePerHour float64
+}
+
+func getMessageBucketCounts(logMessages []LogMessage) (messageBucketCounts map[messageBucketKey]int64) {
+	messageBucketCounts = make(map[messageBucketKey]int64)
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		messageBucketCounts[messageBucketKey{message: logMessage.messageKey(), start: timestamp.Truncate(bucketResolution)}] += 1
+	}
+	return
+}
+
+func getLinearFitSlope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for index, value := range values {
+		x := float64(index)
+		sumX += x
+		sumY += value
+		sumXY += x * value
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
+
+func getMessageTrend(message string, messageBucketCounts map[messageBucketKey]int64, startTime time.Time, endTime time.Time) (trend messageTrend) {
+	trend.direction = "stable"
+	bucketWidth := endTime.Sub(startTime) / trendBuckets
+	if bucketWidth <= 0 {
+		return
+	}
+	counts := make([]float64, trendBuckets)
+	var total float64
+	for key, count := range messageBucketCounts {
+		if key.message != message {
+			continue
+		}
+		index := int(key.start.Sub(startTime) / bucketWidth)
+		if index < 0 {
+			index = 0
+		}
+		if index >= trendBuckets {
+			index = trendBuckets - 1
+		}
+		counts[index] += float64(count)
+		total += float64(count)
+	}
+	if total == 0 {
+		return
+	}
+	slope := getLinearFitSlope(counts)
+	trend.slopePerHour = slope / bucketWidth.Hours()
+	mean := total / trendBuckets
+	switch {
+	case slope/mean > trendThreshold:
+		trend.direction = "rising"
+	case slope/mean < -trendThreshold:
+		trend.direction = "falling"
+	}
+	return
+}
+
+func formatMessageTrend(trend messageTrend) string {
+	slope := strconv.FormatFloat(trend.slopePerHour, 'f', 1, 64)
+	if slope == "-0.0" {
+		slope = "0.0"
+	}
+	if slope[0] != '-' {
+		slope = "+" + slope
+	}
+	return trend.direction + ", " + slope + "/h"
+}
@@ -0,0 +1,29 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetMessageTrend(t *testing.T) {
+	var testLogs []LogMessage
+	for minute := 0; minute < 10; minute++ {
+		timestamp := time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC).Format(layout)
+		testLogs = append(testLogs, LogMessage{timestamp: timestamp, message: "Steady"})
+		for repeat := 0; repeat <= minute; repeat++ {
+			testLogs = append(testLogs, LogMessage{timestamp: timestamp, message: "Growing"})
+		}
+	}
+	startTime, _, _ := getTimeRange(testLogs)
+	endTime := time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)
+	messageBucketCounts := getMessageBucketCounts(testLogs)
+
+	growing := getMessageTrend("Growing", messageBucketCounts, startTime, endTime)
+	if growing.direction != "rising" || growing.slopePerHour != 60 {
+		t.Errorf("getMessageTrend(Growing) = %+v, want rising at 60/h", growing)
+	}
+	steady := getMessageTrend("Steady", messageBucketCounts, startTime, endTime)
+	if steady.direction != "stable" || steady.slopePerHour != 0 {
+		t.Errorf("getMessageTrend(Steady) = %+v, want stable at 0/h", steady)
+	}
+}
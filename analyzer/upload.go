@@ -0,0 +1,182 @@
+package analyzer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const amzDateLayout string = "20060102T150405Z"
+
+// formatUploadDestination fills the {date}, {time} and {label} placeholders
+// of an upload destination so scheduled runs land under distinct keys.
+func formatUploadDestination(destination string, label string, now time.Time) string {
+	now = now.UTC()
+	replacer := strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{time}", now.Format("150405"),
+		"{label}", label,
+	)
+	return replacer.Replace(destination)
+}
+
+// uploadAnalysis PUTs payload to an s3://bucket/key, gs://bucket/key or
+// plain HTTP(S) destination. S3 requests are signed with the AWS_* credentials
+// from the environment and go to AWS_ENDPOINT_URL when set, for S3 compatible
+// stores. GCS requests use the GOOGLE_OAUTH_ACCESS_TOKEN bearer token.
+func uploadAnalysis(destination string, payload []byte, contentType string, now time.Time) error {
+	var request *http.Request
+	var err error
+	switch {
+	case strings.HasPrefix(destination, "s3://"):
+		request, err = newS3UploadRequest(strings.TrimPrefix(destination, "s3://"), payload, now)
+	case strings.HasPrefix(destination, "gs://"):
+		request, err = http.NewRequest(http.MethodPut, "https://storage.googleapis.com/"+
+			awsURIEncode(strings.TrimPrefix(destination, "gs://"), false), bytes.NewReader(payload))
+		if err == nil {
+			token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+			if token == "" {
+				return errors.New("GOOGLE_OAUTH_ACCESS_TOKEN is not set")
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+	case strings.HasPrefix(destination, "http://") || strings.HasPrefix(destination, "https://"):
+		request, err = http.NewRequest(http.MethodPut, destination, bytes.NewReader(payload))
+	default:
+		return errors.New("unsupported upload destination: " + destination)
+	}
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return errors.New("upload failed: " + response.Status)
+	}
+	return nil
+}
+
+func newS3UploadRequest(bucketKey string, payload []byte, now time.Time) (request *http.Request, err error) {
+	bucket, key, found := strings.Cut(bucketKey, "/")
+	if !found || bucket == "" || key == "" {
+		return nil, errors.New("S3 destinations look like s3://bucket/key")
+	}
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := "https://" + bucket + ".s3." + region + ".amazonaws.com/" + awsURIEncode(key, false)
+	if customEndpoint := os.Getenv("AWS_ENDPOINT_URL"); customEndpoint != "" {
+		// S3 compatible stores generally only support path-style addressing
+		endpoint = strings.TrimSuffix(customEndpoint, "/") + "/" + bucket + "/" + awsURIEncode(key, false)
+	}
+	request, err = http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return
+	}
+	if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+	signAWSRequest(request, payload, accessKey, secretKey, region, now)
+	return
+}
+
+// awsURIEncode escapes everything but the unreserved characters, as required
+// by AWS Signature Version 4. Slashes are kept unless encodeSlash is set.
+func awsURIEncode(value string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, character := range []byte(value) {
+		switch {
+		case 'A' <= character && character <= 'Z', 'a' <= character && character <= 'z', '0' <= character && character <= '9',
+			character == '-', character == '.', character == '_', character == '~':
+			builder.WriteByte(character)
+		case character == '/' && !encodeSlash:
+			builder.WriteByte(character)
+		default:
+			builder.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{character})))
+		}
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func signAWSRequest(request *http.Request, payload []byte, accessKey string, secretKey string, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateLayout)
+	shortDate := now.Format("20060102")
+	payloadHash := sha256.Sum256(payload)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaderValues := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, "x-amz-") {
+			signedHeaderValues[lowerName] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	signedHeaderNames := make([]string, 0, len(signedHeaderValues))
+	for name := range signedHeaderValues {
+		signedHeaderNames = append(signedHeaderNames, name)
+	}
+	sort.Strings(signedHeaderNames)
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaderNames {
+		canonicalHeaders.WriteString(name + ":" + signedHeaderValues[name] + "\n")
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalQueryString(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := shortDate + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), shortDate)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQueryString(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsURIEncode(name, true)+"="+awsURIEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
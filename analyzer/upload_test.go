@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadAnalysisToS3(t *testing.T) {
+	var gotPath, gotAuthorization, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		gotPath = request.URL.EscapedPath()
+		gotAuthorization = request.Header.Get("Authorization")
+		body, _ := io.ReadAll(request.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	destination := formatUploadDestination("s3://archive/runs/{date}/{label} {time}.json", "nightly", now)
+	if destination != "s3://archive/runs/2024-01-02/nightly 030405.json" {
+		t.Errorf("formatUploadDestination() = %q", destination)
+	}
+	if err := uploadAnalysis(destination, []byte(`{"entries": 1}`), "application/json", now); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/archive/runs/2024-01-02/nightly%20030405.json" {
+		t.Errorf("Unexpected upload path %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuthorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240102/eu-west-1/s3/aws4_request, "+
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Unexpected Authorization header %q", gotAuthorization)
+	}
+	if gotBody != `{"entries": 1}` {
+		t.Errorf("Unexpected upload body %q", gotBody)
+	}
+
+	if err := uploadAnalysis("ftp://archive/run.json", nil, "application/json", now); err == nil {
+		t.Errorf("uploadAnalysis() accepted an unsupported destination")
+	}
+}
@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const unknownVersion = "unknown"
+
+type versionCount struct {
+	entries int64
+	errors  int64
+}
+
+// getVersionCounts attributes every entry to the most recent version string
+// seen earlier in the same file, since processes usually log their version
+// once at startup rather than on every line.
+func getVersionCounts(logMessages []LogMessage, versionPattern *regexp.Regexp) (versionCounts map[string]versionCount) {
+	versionCounts, _ = continueVersionCounts(logMessages, versionPattern, unknownVersion)
+	return
+}
+
+// continueVersionCounts counts a chunk of a file starting from the version
+// the chunks before it ended with, and returns the one it ends with.
+func continueVersionCounts(logMessages []LogMessage, versionPattern *regexp.Regexp, currentVersion string) (versionCounts map[string]versionCount, lastVersion string) {
+	versionCounts = make(map[string]versionCount)
+	for _, logMessage := range logMessages {
+		if match := versionPattern.FindStringSubmatch(logMessage.message); match != nil {
+			currentVersion = match[len(match)-1]
+		}
+		count := versionCounts[currentVersion]
+		count.entries += 1
+		if isErrorSeverity(logMessage.severity) {
+			count.errors += 1
+		}
+		versionCounts[currentVersion] = count
+	}
+	return versionCounts, currentVersion
+}
+
+func compareVersions(left string, right string) bool {
+	leftParts := strings.FieldsFunc(left, func(character rune) bool { return character == '.' || character == '-' })
+	rightParts := strings.FieldsFunc(right, func(character rune) bool { return character == '.' || character == '-' })
+	for index := 0; index < len(leftParts) && index < len(rightParts); index++ {
+		leftNumber, leftErr := strconv.Atoi(leftParts[index])
+		rightNumber, rightErr := strconv.Atoi(rightParts[index])
+		if leftErr == nil && rightErr == nil {
+			if leftNumber != rightNumber {
+				return leftNumber < rightNumber
+			}
+			continue
+		}
+		if leftParts[index] != rightParts[index] {
+			return leftParts[index] < rightParts[index]
+		}
+	}
+	return len(leftParts) < len(rightParts)
+}
+
+func getSortedVersions(versionCounts map[string]versionCount) (versions []string) {
+	for version := range versionCounts {
+		if version != unknownVersion {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j])
+	})
+	if _, ok := versionCounts[unknownVersion]; ok {
+		versions = append(versions, unknownVersion)
+	}
+	return
+}
+
+func formatVersionComparison(versionCounts map[string]versionCount, options reportOptions) (lines []string) {
+	var previousRate float64
+	hasPrevious := false
+	for _, version := range getSortedVersions(versionCounts) {
+		count := versionCounts[version]
+		rate := float64(count.errors) / float64(count.entries)
+		line := version + ": " + humanizeCount(count.errors, options) + " errors in " + humanizeCount(count.entries, options) +
+			" entries (" + formatPercent(rate) + ")"
+		if hasPrevious && version != unknownVersion {
+			delta := (rate - previousRate) * 100
+			sign := "+"
+			if delta < 0 {
+				sign = ""
+			}
+			line += ", " + sign + strconv.FormatFloat(delta, 'f', 1, 64) + " points vs previous version"
+		}
+		if version != unknownVersion {
+			previousRate = rate
+			hasPrevious = true
+		}
+		lines = append(lines, line)
+	}
+	return
+}
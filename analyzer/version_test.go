@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestGetVersionCounts(t *testing.T) {
+	versionPattern := regexp.MustCompile(`version v?(\d+(?:\.\d+)+)`)
+	testLogs := []LogMessage{
+		{severity: "INFO", message: "Warming up"},
+		{severity: "INFO", message: "Starting payments version 2.3.0"},
+		{severity: "ERROR", message: "Card declined"},
+		{severity: "INFO", message: "Charged"},
+		{severity: "INFO", message: "Starting payments version v2.10.1"},
+		{severity: "ERROR", message: "Card declined"},
+		{severity: "ERROR", message: "Card declined"},
+	}
+
+	got := getVersionCounts(testLogs, versionPattern)
+	want := map[string]versionCount{
+		"unknown": {entries: 1},
+		"2.3.0":   {entries: 3, errors: 1},
+		"2.10.1":  {entries: 3, errors: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getVersionCounts() = %v, want %v", got, want)
+	}
+
+	lines := formatVersionComparison(got, reportOptions{})
+	wantLines := []string{
+		"2.3.0: 1 errors in 3 entries (33.3%)",
+		"2.10.1: 2 errors in 3 entries (66.7%), +33.3 points vs previous version",
+		"unknown: 0 errors in 1 entries (0.0%)",
+	}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("formatVersionComparison() = %q, want %q", lines, wantLines)
+	}
+}
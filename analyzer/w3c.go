@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"errors"
+	"strings"
+)
+
+// errDirectiveLine marks header lines such as W3C's #Fields, which are
+// neither entries nor malformed and are not counted.
+var errDirectiveLine = errors.New("Directive line")
+
+// IIS writes these fields unless the site is configured otherwise; they
+// apply until the file's own #Fields directive is read.
+var iisDefaultW3CFields = strings.Fields("date time s-ip cs-method cs-uri-stem cs-uri-query s-port cs-username c-ip cs(User-Agent) cs(Referer) sc-status sc-substatus sc-win32-status time-taken")
+
+// parserFactories hold presets whose parser keeps state from one line to
+// the next, so every input needs its own.
+var parserFactories = map[string]func() Parser{
+	"w3c":        newW3CParser,
+	"mysql-slow": newMySQLSlowParser,
+}
+
+// newW3CParser reads the W3C extended log file format written by IIS and
+// others. Each #Fields directive sets the columns of the lines after it, and
+// every column is kept as an attribute. Like access logs, 5xx responses are
+// ERROR and 4xx WARNING, and the message is the method, path and status.
+func newW3CParser() Parser {
+	fields := iisDefaultW3CFields
+	return func(logRow string) (logMessage LogMessage, err error) {
+		if strings.HasPrefix(logRow, "#") {
+			if directive, ok := strings.CutPrefix(logRow, "#Fields:"); ok {
+				fields = strings.Fields(directive)
+			}
+			return logMessage, errDirectiveLine
+		}
+		values := strings.Fields(logRow)
+		if len(values) != len(fields) {
+			return logMessage, errMissingDelimiter
+		}
+		logMessage.attributes = make(map[string]string, len(fields))
+		for index, field := range fields {
+			if values[index] != "-" {
+				logMessage.attributes[field] = values[index]
+			}
+		}
+		attributes := logMessage.attributes
+		logMessage.timestamp, err = normalizeTimestamp(attributes["date"]+" "+attributes["time"], "2006-01-02 15:04:05", "2006-01-02 15:04:05.000")
+		if err != nil {
+			return
+		}
+		logMessage.severity = getHTTPStatusSeverity(attributes["sc-status"])
+		logMessage.module = attributes["s-sitename"]
+		logMessage.function = attributes["cs-method"]
+		logMessage.message = strings.TrimSpace(attributes["cs-method"] + " " + attributes["cs-uri-stem"] + " " + attributes["sc-status"])
+		logMessage.clientIP = attributes["c-ip"]
+		return
+	}
+}
+
+// parseIISMessage reads the older comma separated IIS log file format:
+// client IP, user, date, time, service, server, server IP, time taken,
+// bytes received, bytes sent, status, Windows status, method, target and
+// parameters. The named columns are kept as attributes like for w3c.
+func parseIISMessage(logRow string) (logMessage LogMessage, err error) {
+	values := strings.Split(strings.TrimSuffix(strings.TrimSpace(logRow), ","), ",")
+	if len(values) < 15 {
+		return logMessage, errMissingDelimiter
+	}
+	names := []string{"c-ip", "cs-username", "date", "time", "s-sitename", "s-computername", "s-ip", "time-taken", "cs-bytes", "sc-bytes", "sc-status", "sc-win32-status", "cs-method", "cs-uri-stem", "cs-uri-query"}
+	logMessage.attributes = make(map[string]string, len(names))
+	for index, name := range names {
+		if value := strings.TrimSpace(values[index]); value != "-" {
+			logMessage.attributes[name] = value
+		}
+	}
+	attributes := logMessage.attributes
+	logMessage.timestamp, err = normalizeTimestamp(attributes["date"]+" "+attributes["time"], "1/2/2006 15:04:05", "01/02/06 15:04:05")
+	if err != nil {
+		return
+	}
+	logMessage.severity = getHTTPStatusSeverity(attributes["sc-status"])
+	logMessage.module = attributes["s-sitename"]
+	logMessage.function = attributes["cs-method"]
+	logMessage.message = strings.TrimSpace(attributes["cs-method"] + " " + attributes["cs-uri-stem"] + " " + attributes["sc-status"])
+	logMessage.clientIP = attributes["c-ip"]
+	return
+}
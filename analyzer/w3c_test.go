@@ -0,0 +1,45 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestW3CParser(t *testing.T) {
+	parser := newW3CParser()
+	if _, err := parser("#Software: Microsoft Internet Information Services 10.0"); !errors.Is(err, errDirectiveLine) {
+		t.Errorf("Expected a directive line, got %v", err)
+	}
+	parser("#Fields: date time c-ip cs-method cs-uri-stem sc-status time-taken")
+	got, err := parser("2024-01-02 15:04:05 203.0.113.9 GET /api/orders 503 1200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.timestamp != "2024-01-02 15:04:05" || got.severity != "ERROR" || got.message != "GET /api/orders 503" ||
+		got.clientIP != "203.0.113.9" || got.Attribute("time-taken") != "1200" {
+		t.Errorf("parser() = %+v", got)
+	}
+	if _, err := parser("2024-01-02 15:04:05 203.0.113.9 GET"); !errors.Is(err, errMissingDelimiter) {
+		t.Errorf("Expected a short line to be malformed, got %v", err)
+	}
+
+	// Directives are skipped rather than counted as malformed lines
+	input := "#Fields: date time cs-method cs-uri-stem sc-status\n2024-01-02 15:04:05 GET / 200\n"
+	logMessages, _, stats := parseLogReader(context.Background(), strings.NewReader(input), stdinPath, analysisOptions{newParser: newW3CParser})
+	if len(logMessages) != 1 || stats.malformedLines != 0 {
+		t.Errorf("Expected 1 entry and no malformed lines, got %d and %d", len(logMessages), stats.malformedLines)
+	}
+}
+
+func TestParseIISMessage(t *testing.T) {
+	got, err := parseIISMessage("192.0.2.7, -, 1/2/2024, 15:04:05, W3SVC1, WEB01, 10.0.0.1, 15, 300, 1500, 404, 2, GET, /missing.htm, -,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.timestamp != "2024-01-02 15:04:05" || got.severity != "WARNING" || got.module != "W3SVC1" ||
+		got.message != "GET /missing.htm 404" || got.clientIP != "192.0.2.7" {
+		t.Errorf("parseIISMessage() = %+v", got)
+	}
+}
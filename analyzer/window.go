@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// namedWindow is a daily time-of-day range such as incident=02:00-03:00,
+// matched against the UTC timestamps of the entries.
+type namedWindow struct {
+	name     string
+	schedule timeSchedule
+}
+
+type windowStats struct {
+	entries            int64
+	severityFrequency  LogSeverityFrequency
+	messageFrequencies map[string]int64
+}
+
+func parseNamedWindow(value string) (window namedWindow, err error) {
+	name, hours, found := strings.Cut(value, "=")
+	if !found || name == "" || hours == "" {
+		return window, errors.New("windows look like name=02:00-03:00, got " + value)
+	}
+	window.name = name
+	window.schedule, err = parseTimeSchedule("", hours)
+	if err == nil && window.schedule.allDay {
+		err = errors.New("window " + name + " needs a time range")
+	}
+	return
+}
+
+// getWindowStats sorts every entry into each window it falls in, in a single
+// pass, so all windows are compared over exactly the same data.
+func getWindowStats(logMessages []LogMessage, windows []namedWindow) (stats map[string]windowStats) {
+	stats = make(map[string]windowStats, len(windows))
+	for _, window := range windows {
+		stats[window.name] = windowStats{messageFrequencies: make(map[string]int64)}
+	}
+	for _, logMessage := range logMessages {
+		timestamp, err := time.Parse(layout, logMessage.timestamp)
+		if err != nil {
+			continue
+		}
+		for _, window := range windows {
+			if !window.schedule.matches(timestamp) {
+				continue
+			}
+			windowStat := stats[window.name]
+			windowStat.entries += 1
+			countSeverity(&windowStat.severityFrequency, logMessage.severity)
+			windowStat.messageFrequencies[logMessage.messageKey()] += 1
+			stats[window.name] = windowStat
+		}
+	}
+	return
+}
+
+func mergeWindowStats(into map[string]windowStats, from map[string]windowStats) {
+	for name, windowStat := range from {
+		merged := into[name]
+		if merged.messageFrequencies == nil {
+			merged.messageFrequencies = make(map[string]int64)
+		}
+		merged.entries += windowStat.entries
+		mergeLogSeverityFrequency(&merged.severityFrequency, windowStat.severityFrequency)
+		for message, frequency := range windowStat.messageFrequencies {
+			merged.messageFrequencies[message] += frequency
+		}
+		into[name] = merged
+	}
+}
+
+func getWindowErrorRate(windowStat windowStats) float64 {
+	if windowStat.entries == 0 {
+		return 0
+	}
+	return float64(windowStat.severityFrequency.errors()) / float64(windowStat.entries)
+}
+
+// writeWindowComparison prints one column per window, in the order given.
+func writeWindowComparison(writer io.Writer, windowNames []string, stats map[string]windowStats, options reportOptions) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	type row struct {
+		label string
+		value func(windowStat windowStats) string
+	}
+	rows := []row{
+		{"Entries", func(windowStat windowStats) string { return humanizeCount(windowStat.entries, options) }},
+	}
+	// Every window gets a row for each severity any of them has
+	var allSeverities LogSeverityFrequency
+	for _, name := range windowNames {
+		mergeLogSeverityFrequency(&allSeverities, stats[name].severityFrequency)
+	}
+	for _, severity := range getSortedSeverities(allSeverities) {
+		rows = append(rows, row{severity, func(windowStat windowStats) string {
+			return humanizeCount(windowStat.severityFrequency[severity], options)
+		}})
+	}
+	rows = append(rows, []row{
+		{"Error rate", func(windowStat windowStats) string { return formatPercent(getWindowErrorRate(windowStat)) }},
+		{"Top message", func(windowStat windowStats) string {
+			if messages := rankLogMessages(windowStat.messageFrequencies); len(messages) > 0 {
+				return messages[0]
+			}
+			return "-"
+		}},
+	}...)
+	io.WriteString(table, "   \t"+strings.Join(windowNames, "\t")+"\n")
+	for _, row := range rows {
+		values := make([]string, 0, len(windowNames))
+		for _, name := range windowNames {
+			values = append(values, row.value(stats[name]))
+		}
+		io.WriteString(table, "   "+row.label+"\t"+strings.Join(values, "\t")+"\n")
+	}
+	return table.Flush()
+}
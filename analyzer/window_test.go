@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWindowComparison(t *testing.T) {
+	testLogs := []LogMessage{
+		{timestamp: "2024-01-01 01:30:00.000", severity: "INFO", message: "Heartbeat"},
+		{timestamp: "2024-01-01 02:10:00.000", severity: "ERROR", message: "Database timeout"},
+		{timestamp: "2024-01-01 02:20:00.000", severity: "ERROR", message: "Database timeout"},
+		{timestamp: "2024-01-01 02:30:00.000", severity: "INFO", message: "Heartbeat"},
+		{timestamp: "2024-01-02 02:40:00.000", severity: "WARNING", message: "Retrying"},
+	}
+	var windows []namedWindow
+	for _, value := range []string{"incident=02:00-03:00", "baseline=01:00-02:00"} {
+		window, err := parseNamedWindow(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		windows = append(windows, window)
+	}
+
+	stats := getWindowStats(testLogs, windows)
+	merged := make(map[string]windowStats)
+	mergeWindowStats(merged, stats)
+	if incident := merged["incident"]; incident.entries != 4 || incident.severityFrequency["ERROR"] != 2 || incident.severityFrequency["WARNING"] != 1 {
+		t.Errorf("Unexpected incident window stats: %+v", incident)
+	}
+	if baseline := merged["baseline"]; baseline.entries != 1 || baseline.severityFrequency["INFO"] != 1 {
+		t.Errorf("Unexpected baseline window stats: %+v", baseline)
+	}
+
+	var output bytes.Buffer
+	if err := writeWindowComparison(&output, []string{"incident", "baseline"}, merged, reportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(output.String(), "\n")
+	if !strings.Contains(lines[0], "incident") || strings.Index(lines[0], "incident") > strings.Index(lines[0], "baseline") {
+		t.Errorf("Expected windows as columns in the given order:\n%s", output.String())
+	}
+	if !strings.Contains(output.String(), "Error rate   50.0%") || !strings.Contains(output.String(), "Database timeout") {
+		t.Errorf("Unexpected comparison:\n%s", output.String())
+	}
+
+	if _, err := parseNamedWindow("incident"); err == nil {
+		t.Errorf("parseNamedWindow() accepted a window without a range")
+	}
+}